@@ -0,0 +1,110 @@
+package api
+
+import (
+	"fmt"
+)
+
+// Area is an entry in the area registry. Devices and entities are assigned to areas by AreaId.
+type Area struct {
+	AreaId  string   `json:"area_id"`
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases,omitempty"`
+	FloorId string   `json:"floor_id,omitempty"`
+	Icon    string   `json:"icon,omitempty"`
+	Labels  []string `json:"labels,omitempty"`
+	Picture string   `json:"picture,omitempty"`
+}
+
+type AreaListMessage struct{}
+
+func (AreaListMessage) Type() string { return "config/area_registry/list" }
+
+// areaFields are the fields of an area that can be set when creating or updating it. Empty fields are sent as null,
+// which clears them.
+type areaFields struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases"`
+	FloorId *string  `json:"floor_id"`
+	Icon    *string  `json:"icon"`
+	Labels  []string `json:"labels"`
+	Picture *string  `json:"picture"`
+}
+
+func newAreaFields(area Area) areaFields {
+	return areaFields{
+		Name:    area.Name,
+		Aliases: emptyIfNil(area.Aliases),
+		FloorId: nullable(area.FloorId),
+		Icon:    nullable(area.Icon),
+		Labels:  emptyIfNil(area.Labels),
+		Picture: nullable(area.Picture),
+	}
+}
+
+type AreaCreateMessage struct {
+	areaFields
+}
+
+func (AreaCreateMessage) Type() string { return "config/area_registry/create" }
+
+type AreaUpdateMessage struct {
+	AreaId string `json:"area_id"`
+	areaFields
+}
+
+func (AreaUpdateMessage) Type() string { return "config/area_registry/update" }
+
+type AreaDeleteMessage struct {
+	AreaId string `json:"area_id"`
+}
+
+func (AreaDeleteMessage) Type() string { return "config/area_registry/delete" }
+
+func (c *Client) ListAreas() ([]Area, error) {
+	retI, err := c.RawWebsocketRequestAs(AreaListMessage{}, []Area{})
+	if err != nil {
+		return nil, fmt.Errorf("listing areas: %w", err)
+	}
+	return retI.([]Area), nil
+}
+
+// GetArea returns the area with the given ID. If there is none, the error satisfies IsNotFound.
+func (c *Client) GetArea(id string) (*Area, error) {
+	areas, err := c.ListAreas()
+	if err != nil {
+		return nil, err
+	}
+	for _, area := range areas {
+		if area.AreaId == id {
+			return &area, nil
+		}
+	}
+	return nil, notFound("area", id)
+}
+
+// CreateArea creates an area with the fields of the given one, other than AreaId, which homeassistant derives from
+// the name. It returns the area as created.
+func (c *Client) CreateArea(area Area) (*Area, error) {
+	retI, err := c.RawWebsocketRequestAs(AreaCreateMessage{newAreaFields(area)}, (*Area)(nil))
+	if err != nil {
+		return nil, fmt.Errorf("creating area %q: %w", area.Name, err)
+	}
+	return retI.(*Area), nil
+}
+
+// UpdateArea sets all the fields of the area with the given one's AreaId to those of the given one, and returns the
+// updated area.
+func (c *Client) UpdateArea(area Area) (*Area, error) {
+	retI, err := c.RawWebsocketRequestAs(AreaUpdateMessage{area.AreaId, newAreaFields(area)}, (*Area)(nil))
+	if err != nil {
+		return nil, fmt.Errorf("updating area %q: %w", area.AreaId, err)
+	}
+	return retI.(*Area), nil
+}
+
+func (c *Client) DeleteArea(id string) error {
+	if _, err := c.RawWebsocketRequest(AreaDeleteMessage{id}); err != nil {
+		return fmt.Errorf("deleting area %q: %w", id, err)
+	}
+	return nil
+}
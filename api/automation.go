@@ -1,27 +1,99 @@
 package api
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 )
 
 type AutomationId string
 
+// AutomationListEntry describes an automation as seen through its state. Id is the automation's config ID, and is
+// empty for automations that were not given an ID (e.g., some automations defined in YAML).
 type AutomationListEntry struct {
 	FriendlyName  string       `json:"friendly_name"`
 	Id            AutomationId `json:"id"`
+	EntityId      string       `json:"entity_id"`
+	State         string       `json:"state"`
+	Mode          string       `json:"mode"`
+	Current       int          `json:"current"`
 	LastTriggered time.Time    `json:"last_triggered"`
 }
 
+// Automation is the configuration of a single automation. Newer versions of homeassistant write the trigger,
+// condition, and action lists under plural keys (`triggers`, `conditions`, `actions`); either spelling is accepted, and
+// PluralKeys records which was used so that it can be preserved. Extra holds any keys not otherwise understood (e.g.,
+// `max`, `trace`, or `variables`), so that they survive being decoded and re-encoded.
 type Automation struct {
-	Action    AutomationAction      `json:"action"`
-	Alias     string                `json:"alias"`
-	Condition []AutomationCondition `json:"condition,omitempty"`
-	Id        AutomationId          `json:"id"`
-	Trigger   []AutomationTrigger   `json:"trigger"`
+	Action      ActionSequence `json:"action,omitempty"`
+	Alias       string         `json:"alias,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Mode        string         `json:"mode,omitempty"`
+	Condition   ConditionList  `json:"condition,omitempty"`
+	Id          AutomationId   `json:"id,omitempty"`
+	Trigger     TriggerList    `json:"trigger,omitempty"`
+	// UseBlueprint is set instead of Trigger, Condition, and Action for automations created from a blueprint.
+	UseBlueprint *UseBlueprint          `json:"use_blueprint,omitempty"`
+	PluralKeys   bool                   `json:"-"`
+	Extra        map[string]interface{} `json:"-"`
 }
 
+var automationListKeys = []string{"trigger", "condition", "action"}
+
+func (a *Automation) UnmarshalJSON(data []byte) error {
+	var generic map[string]interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return err
+	}
+
+	plural := false
+	for _, key := range automationListKeys {
+		if v, ok := generic[key+"s"]; ok {
+			if _, ok := generic[key]; ok {
+				return fmt.Errorf("automation has both %q and %q", key, key+"s")
+			}
+			generic[key] = v
+			delete(generic, key+"s")
+			plural = true
+		}
+	}
+
+	var ret Automation
+	used, err := fieldsFromMap("automation", generic, &ret)
+	if err != nil {
+		return err
+	}
+	ret.PluralKeys = plural
+	ret.Extra = extraFields(generic, used)
+
+	*a = ret
+	return nil
+}
+
+func (a Automation) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.toMap())
+}
+
+// toMap returns the fields of the automation keyed by their names, including Extra.
+func (a Automation) toMap() map[string]interface{} {
+	ret := mergeFields(a.Extra, fieldsToMap(&a))
+	if a.PluralKeys {
+		for _, key := range automationListKeys {
+			if v, ok := ret[key]; ok {
+				ret[key+"s"] = v
+				delete(ret, key)
+			}
+		}
+	}
+	return ret
+}
+
+var _ json.Unmarshaler = (*Automation)(nil)
+var _ json.Marshaler = Automation{}
+
 func (c *Client) GetAutomation(id AutomationId) (*Automation, error) {
 	retI, err := c.RawRESTGetAs("config/automation/config/"+string(id), nil, (*Automation)(nil))
 	if err != nil {
@@ -31,27 +103,77 @@ func (c *Client) GetAutomation(id AutomationId) (*Automation, error) {
 	return retI.(*Automation), nil
 }
 
+// SaveAutomation creates or updates the given automation. If the automation has no Id, one is generated the same way
+// the homeassistant UI does (milliseconds since the epoch) and stored in a.Id before saving.
+func (c *Client) SaveAutomation(a *Automation) error {
+	if a.Id == "" {
+		a.Id = AutomationId(strconv.FormatInt(time.Now().UnixMilli(), 10))
+	}
+
+	_, err := process(c.Post("config/automation/config/"+string(a.Id), a))
+	if err != nil {
+		return fmt.Errorf("saving automation %q: %w", a.Id, err)
+	}
+	return nil
+}
+
+// DeleteAutomation deletes the automation with the given ID.
+func (c *Client) DeleteAutomation(id AutomationId) error {
+	_, err := process(c.Delete("config/automation/config/"+string(id), nil))
+	if err != nil {
+		return fmt.Errorf("deleting automation %q: %w", id, err)
+	}
+	return nil
+}
+
+// Validate performs basic checks for problems that would cause homeassistant to reject the automation on save.
+func (a *Automation) Validate() error {
+	if a.UseBlueprint != nil {
+		if a.UseBlueprint.Path == "" {
+			return errors.New("automation uses a blueprint, but gives no path")
+		}
+		return nil
+	}
+	if len(a.Trigger) == 0 {
+		return errors.New("automation has no triggers")
+	}
+	if len(a.Action) == 0 {
+		return errors.New("automation has no actions")
+	}
+	return nil
+}
+
 func (c *Client) ListAutomations() ([]AutomationListEntry, error) {
 	states, err := c.ListStates()
 	if err != nil {
 		return nil, fmt.Errorf("automations are discovered via states, but could not get states: %w", err)
 	}
+	return AutomationsFromStates(states)
+}
 
+// AutomationsFromStates returns the automations among the given states, as ListAutomations does; this spares a caller
+// that already has the states from retrieving them again.
+func AutomationsFromStates(states []State) ([]AutomationListEntry, error) {
 	var ret []AutomationListEntry
 	for _, state := range states {
 		if !strings.HasPrefix(state.EntityId, "automation.") {
 			continue
 		}
 
-		entry := AutomationListEntry{}
-		entry.Id = AutomationId(state.Context.Id)
-		attr, ok := state.Attributes["friendly_name"]
-		if ok {
-			entry.FriendlyName, _ = attr.(string)
+		entry := AutomationListEntry{
+			EntityId: state.EntityId,
+			State:    state.State,
+		}
+		if id, ok := state.Attributes["id"].(string); ok {
+			entry.Id = AutomationId(id)
 		}
-		attr, ok = state.Attributes["last_triggered"]
-		if ok {
-			lt, _ := attr.(string)
+		entry.FriendlyName, _ = state.Attributes["friendly_name"].(string)
+		entry.Mode, _ = state.Attributes["mode"].(string)
+		if current, ok := state.Attributes["current"].(float64); ok {
+			entry.Current = int(current)
+		}
+		if lt, _ := state.Attributes["last_triggered"].(string); lt != "" {
+			var err error
 			entry.LastTriggered, err = time.Parse(time.RFC3339, lt)
 			if err != nil {
 				return nil, fmt.Errorf("could not parse last_triggered time %q: %w", lt, err)
@@ -63,3 +185,65 @@ func (c *Client) ListAutomations() ([]AutomationListEntry, error) {
 
 	return ret, nil
 }
+
+// AutomationEntityId returns the entity ID of the automation with the given config ID. As a convenience, if id already
+// looks like an automation entity ID, it is returned unchanged.
+func (c *Client) AutomationEntityId(id AutomationId) (string, error) {
+	if strings.HasPrefix(string(id), "automation.") {
+		return string(id), nil
+	}
+
+	automations, err := c.ListAutomations()
+	if err != nil {
+		return "", err
+	}
+	for _, automation := range automations {
+		if automation.Id == id {
+			return automation.EntityId, nil
+		}
+	}
+	return "", fmt.Errorf("no automation with id %q", id)
+}
+
+// TriggerAutomation runs the actions of the automation with the given config or entity ID. If skipCondition is true,
+// the automation's conditions are not checked first.
+func (c *Client) TriggerAutomation(id AutomationId, skipCondition bool) error {
+	entityId, err := c.AutomationEntityId(id)
+	if err != nil {
+		return err
+	}
+	return c.callAutomationService("trigger", map[string]interface{}{
+		"entity_id":      entityId,
+		"skip_condition": skipCondition,
+	})
+}
+
+// EnableAutomation turns on the automation with the given config or entity ID, so that its triggers are active.
+func (c *Client) EnableAutomation(id AutomationId) error {
+	entityId, err := c.AutomationEntityId(id)
+	if err != nil {
+		return err
+	}
+	return c.callAutomationService("turn_on", map[string]interface{}{"entity_id": entityId})
+}
+
+// DisableAutomation turns off the automation with the given config or entity ID, so that its triggers are ignored.
+func (c *Client) DisableAutomation(id AutomationId) error {
+	entityId, err := c.AutomationEntityId(id)
+	if err != nil {
+		return err
+	}
+	return c.callAutomationService("turn_off", map[string]interface{}{"entity_id": entityId})
+}
+
+// ReloadAutomations causes homeassistant to re-read all automations from its configuration.
+func (c *Client) ReloadAutomations() error {
+	return c.callAutomationService("reload", nil)
+}
+
+func (c *Client) callAutomationService(service string, data map[string]interface{}) error {
+	if _, err := process(c.Post("services/automation/"+service, data)); err != nil {
+		return fmt.Errorf("calling automation.%s: %w", service, err)
+	}
+	return nil
+}
@@ -1,26 +1,179 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
-	"strings"
 )
 
+// ActionSequence is a list of actions run in order, such as the actions of an automation or the branches of a
+// `choose`. Like homeassistant, it accepts a single action in place of a list, and is encoded the same way.
+type ActionSequence []AutomationAction
+
+func (s *ActionSequence) UnmarshalJSON(data []byte) error {
+	if isJSONList(data) {
+		var list []AutomationAction
+		if err := json.Unmarshal(data, &list); err != nil {
+			return err
+		}
+		*s = list
+		return nil
+	}
+
+	var single AutomationAction
+	if err := json.Unmarshal(data, &single); err != nil {
+		return err
+	}
+	single.single = true
+	*s = ActionSequence{single}
+	return nil
+}
+
+func (s ActionSequence) MarshalJSON() ([]byte, error) {
+	if len(s) == 1 && s[0].single {
+		return json.Marshal(s[0])
+	}
+	return json.Marshal([]AutomationAction(s))
+}
+
+var _ json.Marshaler = ActionSequence{}
+var _ json.Unmarshaler = (*ActionSequence)(nil)
+
+// isJSONList returns true if data is a JSON array.
+func isJSONList(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("["))
+}
+
+// AutomationAction is a single step of an automation or script. Action holds the kind-specific part of the step; the
+// remaining fields are accepted by every kind of action. Extra holds any keys not otherwise understood, so that they
+// survive being decoded and re-encoded.
 type AutomationAction struct {
 	Action
+	Alias           string
+	Enabled         *bool
+	ContinueOnError bool
+	Extra           map[string]interface{}
+	// single records that the action was written by itself, in place of a list.
+	single bool
 }
 
-func (a *AutomationAction) MarshalJSON() ([]byte, error) {
-	return json.Marshal(a.Action)
+type actionCommon struct {
+	Alias           string `json:"alias,omitempty"`
+	Enabled         *bool  `json:"enabled,omitempty"`
+	ContinueOnError bool   `json:"continue_on_error,omitempty"`
 }
 
-var _ json.Marshaler = (*AutomationAction)(nil)
+func (a AutomationAction) MarshalJSON() ([]byte, error) {
+	var ret map[string]interface{}
+	switch action := a.Action.(type) {
+	case nil:
+	case *ConditionAction:
+		if action.Condition == nil {
+			return nil, errors.New("condition action has no condition")
+		}
+		ret = conditionToMap(action.Condition)
+	default:
+		ret = fieldsToMap(action)
+	}
+
+	return json.Marshal(mergeFields(
+		a.Extra,
+		ret,
+		fieldsToMap(&actionCommon{a.Alias, a.Enabled, a.ContinueOnError})))
+}
 
+var _ json.Marshaler = AutomationAction{}
+
+// Action is implemented by each kind of action. KeyFieldName is the key whose presence identifies the kind.
 type Action interface {
 	KeyFieldName() string
 }
 
+// actionKinds lists the prototype for each kind of action, in the order homeassistant checks for their key fields.
+var actionKinds = []Action{
+	(*DelayAction)(nil),
+	(*WaitAction)(nil),
+	(*ConditionAction)(nil),
+	(*EventAction)(nil),
+	(*ServiceAction)(nil),
+	(*DeviceAction)(nil),
+	(*SceneAction)(nil),
+	(*RepeatAction)(nil),
+	(*ChooseAction)(nil),
+	(*IfAction)(nil),
+	(*WaitForTriggerAction)(nil),
+	(*VariablesAction)(nil),
+	(*StopAction)(nil),
+	(*ParallelAction)(nil),
+	(*SequenceAction)(nil),
+	(*SetConversationResponseAction)(nil),
+}
+
+func actionPrototype(generic map[string]interface{}) Action {
+	for _, candidate := range actionKinds {
+		if _, ok := generic[candidate.KeyFieldName()]; ok {
+			return candidate
+		}
+		if _, ok := candidate.(*ServiceAction); ok {
+			// `action` is the newer spelling of `service`, and `service_template` the older
+			for _, key := range []string{"action", "service_template"} {
+				if _, ok := generic[key]; ok {
+					return candidate
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (a *AutomationAction) UnmarshalJSON(data []byte) error {
+	var generic map[string]interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return err
+	}
+
+	if len(generic) == 0 {
+		*a = AutomationAction{}
+		return nil
+	}
+
+	var common actionCommon
+	commonUsed, err := fieldsFromMap("action", generic, &common)
+	if err != nil {
+		return fmt.Errorf("action %q: %w", string(data), err)
+	}
+	*a = AutomationAction{Alias: common.Alias, Enabled: common.Enabled, ContinueOnError: common.ContinueOnError}
+
+	prototype := actionPrototype(generic)
+	if prototype == nil {
+		return fmt.Errorf("action %q was not a recognized type", string(data))
+	}
+
+	if _, ok := prototype.(*ConditionAction); ok {
+		condition, used, err := conditionFromMap(generic)
+		if err != nil {
+			return fmt.Errorf("condition action: %w", err)
+		}
+		a.Action = &ConditionAction{condition}
+		a.Extra = extraFields(generic, mergeUsed(used, commonUsed), "condition")
+		return nil
+	}
+
+	action := reflect.New(reflect.TypeOf(prototype).Elem()).Interface()
+	used, err := fieldsFromMap(prototype.KeyFieldName(), generic, action)
+	if err != nil {
+		return fmt.Errorf("%q action: %w", prototype.KeyFieldName(), err)
+	}
+	a.Action = action.(Action)
+	a.Extra = extraFields(generic, mergeUsed(used, commonUsed))
+	return nil
+}
+
+var _ json.Unmarshaler = (*AutomationAction)(nil)
+
+// EventAction fires an event (homeassistant's `fire_event` action).
 type EventAction struct {
 	Event             string                 `json:"event"`
 	EventData         map[string]interface{} `json:"event_data,omitempty"`
@@ -29,24 +182,44 @@ type EventAction struct {
 
 func (*EventAction) KeyFieldName() string { return "event" }
 
+// ServiceAction calls a service. Newer versions of homeassistant write the service name as `action`, older ones as
+// `service`; whichever was used is preserved.
 type ServiceAction struct {
-	Service  string                 `json:"service"`
-	EntityId *string                `json:"entity_id,omitempty"`
-	Data     map[string]interface{} `json:"data,omitempty"`
+	Action           string                 `json:"action,omitempty"`
+	Service          string                 `json:"service,omitempty"`
+	ServiceTemplate  string                 `json:"service_template,omitempty"`
+	Data             map[string]interface{} `json:"data,omitempty"`
+	DataTemplate     map[string]interface{} `json:"data_template,omitempty"`
+	Target           map[string]interface{} `json:"target,omitempty"`
+	EntityId         StringList             `json:"entity_id,omitempty"`
+	ResponseVariable string                 `json:"response_variable,omitempty"`
 }
 
 func (*ServiceAction) KeyFieldName() string { return "service" }
 
+// ServiceName returns the name of the service called, e.g. `light.turn_on`, regardless of spelling.
+func (s *ServiceAction) ServiceName() string {
+	if s.Action != "" {
+		return s.Action
+	}
+	if s.Service != "" {
+		return s.Service
+	}
+	return s.ServiceTemplate
+}
+
 type DeviceAction struct {
 	DeviceId string `json:"device_id"`
 	Domain   string `json:"domain"`
-	EntityId string `json:"entity_id"`
+	EntityId string `json:"entity_id,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Subtype  string `json:"subtype,omitempty"`
 }
 
 func (*DeviceAction) KeyFieldName() string { return "device_id" }
 
 type DelayAction struct {
-	Delay int `json:"delay"`
+	Delay Duration `json:"delay"`
 }
 
 func (*DelayAction) KeyFieldName() string { return "delay" }
@@ -58,62 +231,99 @@ type SceneAction struct {
 func (*SceneAction) KeyFieldName() string { return "scene" }
 
 type WaitAction struct {
-	WaitTemplate string `json:"wait_template"`
-	Timeout      int    `json:"timeout,omitempty"`
+	WaitTemplate      string   `json:"wait_template"`
+	Timeout           Duration `json:"timeout,omitempty"`
+	ContinueOnTimeout *bool    `json:"continue_on_timeout,omitempty"`
 }
 
 func (*WaitAction) KeyFieldName() string { return "wait_template" }
 
-func (a *AutomationAction) UnmarshalJSON(data []byte) error {
-	var generic map[string]interface{}
-	if err := json.Unmarshal(data, &generic); err != nil {
-		return err
-	}
+type WaitForTriggerAction struct {
+	WaitForTrigger    TriggerList `json:"wait_for_trigger"`
+	Timeout           Duration    `json:"timeout,omitempty"`
+	ContinueOnTimeout *bool       `json:"continue_on_timeout,omitempty"`
+}
 
-	if len(generic) == 0 {
-		return nil
-	}
+func (*WaitForTriggerAction) KeyFieldName() string { return "wait_for_trigger" }
 
-	var prototype Action
-	for _, candidate := range []Action{
-		(*EventAction)(nil),
-		(*ServiceAction)(nil),
-		(*DeviceAction)(nil),
-		(*DelayAction)(nil),
-		(*SceneAction)(nil),
-		(*WaitAction)(nil),
-	} {
-		if _, ok := generic[candidate.KeyFieldName()]; ok {
-			prototype = candidate
-			break
-		}
-	}
+// ConditionAction stops the automation or script if the condition does not pass. It is written as the condition
+// itself.
+type ConditionAction struct {
+	Condition Condition
+}
 
-	if prototype == nil {
-		return fmt.Errorf("action %q was not a recognized type", string(data))
-	}
+func (*ConditionAction) KeyFieldName() string { return "condition" }
 
-	typ := reflect.TypeOf(prototype).Elem()
-	action := reflect.New(typ)
+type ChooseAction struct {
+	Choose  []ChooseOption `json:"choose"`
+	Default ActionSequence `json:"default,omitempty"`
+}
 
-	for fieldIdx := 0; fieldIdx < typ.NumField(); fieldIdx++ {
-		targetField := action.Elem().Field(fieldIdx)
-		fieldName := strings.Split(typ.Field(fieldIdx).Tag.Get("json"), ",")[0]
-		valueToAssign, ok := generic[fieldName]
-		if !ok {
-			continue
-		}
+func (*ChooseAction) KeyFieldName() string { return "choose" }
 
-		if reflect.TypeOf(valueToAssign) != targetField.Type() {
-			return fmt.Errorf("%q expects %s for field %s, but input had %s", prototype.KeyFieldName(),
-				reflect.TypeOf(targetField), fieldName, reflect.TypeOf(valueToAssign))
-		}
+// ChooseOption is one branch of a ChooseAction, whose Sequence runs if all of its Conditions pass.
+type ChooseOption struct {
+	Alias      string         `json:"alias,omitempty"`
+	Conditions ConditionList  `json:"conditions"`
+	Sequence   ActionSequence `json:"sequence"`
+}
 
-		targetField.Set(reflect.ValueOf(valueToAssign))
-	}
+type IfAction struct {
+	If   ConditionList  `json:"if"`
+	Then ActionSequence `json:"then"`
+	Else ActionSequence `json:"else,omitempty"`
+}
 
-	a.Action = action.Interface().(Action)
-	return nil
+func (*IfAction) KeyFieldName() string { return "if" }
+
+type RepeatAction struct {
+	Repeat RepeatLoop `json:"repeat"`
 }
 
-var _ json.Unmarshaler = (*AutomationAction)(nil)
+func (*RepeatAction) KeyFieldName() string { return "repeat" }
+
+// RepeatLoop describes how a RepeatAction repeats its Sequence; exactly one of Count, While, Until, or ForEach is set.
+type RepeatLoop struct {
+	// Count is a number or a template.
+	Count    interface{}    `json:"count,omitempty"`
+	While    ConditionList  `json:"while,omitempty"`
+	Until    ConditionList  `json:"until,omitempty"`
+	ForEach  interface{}    `json:"for_each,omitempty"`
+	Sequence ActionSequence `json:"sequence"`
+}
+
+func (r RepeatLoop) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fieldsToMap(&r))
+}
+
+type ParallelAction struct {
+	Parallel ActionSequence `json:"parallel"`
+}
+
+func (*ParallelAction) KeyFieldName() string { return "parallel" }
+
+type SequenceAction struct {
+	Sequence ActionSequence `json:"sequence"`
+}
+
+func (*SequenceAction) KeyFieldName() string { return "sequence" }
+
+type VariablesAction struct {
+	Variables map[string]interface{} `json:"variables"`
+}
+
+func (*VariablesAction) KeyFieldName() string { return "variables" }
+
+type StopAction struct {
+	Stop             string `json:"stop"`
+	Error            bool   `json:"error,omitempty"`
+	ResponseVariable string `json:"response_variable,omitempty"`
+}
+
+func (*StopAction) KeyFieldName() string { return "stop" }
+
+type SetConversationResponseAction struct {
+	SetConversationResponse string `json:"set_conversation_response"`
+}
+
+func (*SetConversationResponseAction) KeyFieldName() string { return "set_conversation_response" }
@@ -2,60 +2,123 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
-	"strings"
 )
 
-type AutomationCondition struct{ Condition }
+// AutomationCondition is a single condition of an automation. Condition holds the kind-specific part of the condition;
+// the remaining fields are accepted by every kind. Extra holds any keys not otherwise understood, so that they survive
+// being decoded and re-encoded.
+type AutomationCondition struct {
+	Condition
+	Alias   string
+	Enabled *bool
+	Extra   map[string]interface{}
+	// shorthand records that the condition was written as a bare template string.
+	shorthand bool
+	// single records that the condition was written by itself, in place of a list.
+	single bool
+}
+
+type conditionCommon struct {
+	Alias   string `json:"alias,omitempty"`
+	Enabled *bool  `json:"enabled,omitempty"`
+}
+
+// ConditionList is a list of conditions that must all pass. Like homeassistant, it accepts a single condition in place
+// of a list, and is encoded the same way.
+type ConditionList []AutomationCondition
+
+func (l *ConditionList) UnmarshalJSON(data []byte) error {
+	if isJSONList(data) {
+		var list []AutomationCondition
+		if err := json.Unmarshal(data, &list); err != nil {
+			return err
+		}
+		*l = list
+		return nil
+	}
+
+	var single AutomationCondition
+	if err := json.Unmarshal(data, &single); err != nil {
+		return err
+	}
+	single.single = true
+	*l = ConditionList{single}
+	return nil
+}
+
+func (l ConditionList) MarshalJSON() ([]byte, error) {
+	if len(l) == 1 && l[0].single {
+		return json.Marshal(l[0])
+	}
+	return json.Marshal([]AutomationCondition(l))
+}
+
+var _ json.Marshaler = ConditionList{}
+var _ json.Unmarshaler = (*ConditionList)(nil)
 
 func (a *AutomationCondition) UnmarshalJSON(data []byte) error {
+	// a bare string is shorthand for a template condition
+	var template string
+	if err := json.Unmarshal(data, &template); err == nil {
+		*a = AutomationCondition{Condition: &TemplateCondition{ValueTemplate: template}, shorthand: true}
+		return nil
+	}
+
 	var generic map[string]interface{}
 	if err := json.Unmarshal(data, &generic); err != nil {
 		return err
 	}
-	condition, err := conditionFromMap(generic)
+	condition, used, err := conditionFromMap(generic)
+	if err != nil {
+		return err
+	}
+
+	var common conditionCommon
+	commonUsed, err := fieldsFromMap("condition", generic, &common)
 	if err != nil {
 		return err
 	}
 
-	a.Condition = condition
+	*a = AutomationCondition{
+		Condition: condition,
+		Alias:     common.Alias,
+		Enabled:   common.Enabled,
+		Extra:     extraFields(generic, mergeUsed(used, commonUsed), "condition"),
+	}
 	return nil
 }
 
 func (a AutomationCondition) MarshalJSON() ([]byte, error) {
-	return json.Marshal(conditionToMap(a.Condition))
+	if a.Condition == nil {
+		return nil, errors.New("condition has no kind")
+	}
+	if template, ok := a.Condition.(*TemplateCondition); ok && a.shorthand {
+		return json.Marshal(template.ValueTemplate)
+	}
+
+	return json.Marshal(mergeFields(
+		a.Extra,
+		conditionToMap(a.Condition),
+		fieldsToMap(&conditionCommon{a.Alias, a.Enabled})))
 }
 
 var _ json.Unmarshaler = (*AutomationCondition)(nil)
 var _ json.Marshaler = (*AutomationCondition)(nil)
 
 func conditionToMap(c Condition) map[string]interface{} {
-	ret := map[string]interface{}{}
+	ret := fieldsToMap(c)
 	ret["condition"] = c.ConditionKey()
-	typ := reflect.TypeOf(c).Elem()
-	val := reflect.ValueOf(c).Elem()
-	for fieldIdx := 0; fieldIdx < typ.NumField(); fieldIdx++ {
-		name := strings.Split(typ.Field(fieldIdx).Tag.Get("json"), ",")[0]
-		switch v := val.Field(fieldIdx).Interface().(type) {
-		case []Condition:
-			var list []map[string]interface{}
-			for _, c := range v {
-				list = append(list, conditionToMap(c))
-			}
-			ret[name] = list
-		default:
-			ret[name] = v
-		}
-	}
-
 	return ret
 }
 
-func conditionFromMap(generic map[string]interface{}) (Condition, error) {
+// conditionFromMap decodes the kind-specific part of a condition, returning it along with the keys it consumed.
+func conditionFromMap(generic map[string]interface{}) (Condition, map[string]bool, error) {
 	conditionName, ok := generic["condition"]
 	if !ok {
-		return nil, fmt.Errorf("condition %v did not have `condition` key", generic)
+		return nil, nil, fmt.Errorf("condition %v did not have `condition` key", generic)
 	}
 
 	var prototype Condition
@@ -70,6 +133,8 @@ candidates:
 		(*ZoneCondition)(nil),
 		(*TimeCondition)(nil),
 		(*TemplateCondition)(nil),
+		(*TriggerCondition)(nil),
+		(*DeviceCondition)(nil),
 	} {
 		if conditionName == candidate.ConditionKey() {
 			prototype = candidate
@@ -77,60 +142,16 @@ candidates:
 		}
 	}
 	if prototype == nil {
-		return nil, fmt.Errorf("no match for condition %q", conditionName)
+		return nil, nil, fmt.Errorf("no match for condition %q", conditionName)
 	}
 
-	typ := reflect.TypeOf(prototype).Elem()
-	condition := reflect.New(typ)
-
-	for fieldIdx := 0; fieldIdx < typ.NumField(); fieldIdx++ {
-		fieldName := strings.Split(typ.Field(fieldIdx).Tag.Get("json"), ",")[0]
-		valueToAssign, ok := generic[fieldName]
-		if !ok {
-			continue
-		}
-		targetField := condition.Elem().Field(fieldIdx)
-		switch targetField.Interface().(type) {
-		case []Condition:
-			conditions, ok := valueToAssign.([]interface{})
-			if !ok {
-				return nil, fmt.Errorf("%s attribute was %T, not []interface{}", fieldName, valueToAssign)
-			}
-
-			for conditionIdx, condI := range conditions {
-				condGeneric, ok := condI.(map[string]interface{})
-				if !ok {
-					return nil, fmt.Errorf("%s attribute entry %d was %T, not "+
-						"map[string]interface{}", fieldName, conditionIdx, condI)
-				}
-
-				cond, err := conditionFromMap(condGeneric)
-				if err != nil {
-					return nil, fmt.Errorf("%s attribute entry %d could not be decoded as "+
-						"Condition: %w", fieldName, conditionIdx, err)
-				}
-
-				targetField.Set(reflect.Append(targetField, reflect.ValueOf(cond)))
-			}
-		case StringOrFloat:
-			switch v := valueToAssign.(type) {
-			case string:
-				condition.Elem().Field(fieldIdx).Set(reflect.ValueOf(StringOrIntFromString(v)))
-			case float64:
-				condition.Elem().Field(fieldIdx).Set(reflect.ValueOf(StringOrIntFromFloat64(v)))
-			default:
-				return nil, fmt.Errorf("%s attribute was %T, not string or int", fieldName, valueToAssign)
-			}
-		default:
-			if reflect.TypeOf(valueToAssign) != targetField.Type() {
-				return nil, fmt.Errorf("%q expects %s for field %s, but input had %s", conditionName,
-					reflect.TypeOf(targetField), fieldName, reflect.TypeOf(valueToAssign))
-			}
-			targetField.Set(reflect.ValueOf(valueToAssign))
-		}
+	condition := reflect.New(reflect.TypeOf(prototype).Elem()).Interface()
+	used, err := fieldsFromMap(prototype.ConditionKey(), generic, condition)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return condition.Interface().(Condition), nil
+	return condition.(Condition), used, nil
 }
 
 type Condition interface {
@@ -138,56 +159,69 @@ type Condition interface {
 }
 
 type AndCondition struct {
-	Conditions []Condition `json:"conditions"`
+	Conditions ConditionList `json:"conditions"`
 }
 
 func (*AndCondition) ConditionKey() string { return "and" }
 
 type OrCondition struct {
-	Conditions []Condition `json:"conditions"`
+	Conditions ConditionList `json:"conditions"`
 }
 
 func (*OrCondition) ConditionKey() string { return "or" }
 
 type NotCondition struct {
-	Conditions []Condition `json:"conditions"`
+	Conditions ConditionList `json:"conditions"`
 }
 
 func (*NotCondition) ConditionKey() string { return "not" }
 
+// StateCondition passes if the entities' state (or Attribute) is State, and has been for For. Match is `all` (the
+// default) if every entity must match, or `any` if one is enough.
 type StateCondition struct {
-	EntityId string        `json:"entity_id"`
-	State    StringOrFloat `json:"state"`
+	EntityId  StringList `json:"entity_id"`
+	State     StateList  `json:"state"`
+	Attribute string     `json:"attribute,omitempty"`
+	For       Duration   `json:"for,omitempty"`
+	Match     string     `json:"match,omitempty"`
 }
 
 func (*StateCondition) ConditionKey() string { return "state" }
 
+// NumericStateCondition passes if the entity's state (or Attribute, or ValueTemplate) is numeric and within the
+// bounds. Above and Below may each be a number or the entity ID of a numeric entity.
 type NumericStateCondition struct {
-	EntityId string  `json:"entity_id"`
-	Above    float64 `json:"above"`
+	EntityId      StringList    `json:"entity_id"`
+	Attribute     string        `json:"attribute,omitempty"`
+	Above         StringOrFloat `json:"above,omitempty"`
+	Below         StringOrFloat `json:"below,omitempty"`
+	ValueTemplate string        `json:"value_template,omitempty"`
 }
 
 func (*NumericStateCondition) ConditionKey() string { return "numeric_state" }
 
 type SunCondition struct {
-	AfterOffset  float64 `json:"after_offset"`
-	BeforeOffset float64 `json:"before_offset"`
-	After        string  `json:"after"`
-	Before       string  `json:"before"`
+	AfterOffset  Duration `json:"after_offset,omitempty"`
+	BeforeOffset Duration `json:"before_offset,omitempty"`
+	After        string   `json:"after,omitempty"`
+	Before       string   `json:"before,omitempty"`
 }
 
 func (*SunCondition) ConditionKey() string { return "sun" }
 
 type ZoneCondition struct {
-	EntityId string `json:"entity_id"`
-	Zone     string `json:"zone"`
+	EntityId StringList `json:"entity_id"`
+	Zone     StringList `json:"zone"`
 }
 
 func (*ZoneCondition) ConditionKey() string { return "zone" }
 
+// TimeCondition passes between After and Before (each HH:MM[:SS] or the entity ID of an input_datetime or timestamp
+// sensor), on the given days of the week (`mon`, `tue`, ...).
 type TimeCondition struct {
-	After  string `json:"after"`
-	Before string `json:"before"`
+	After   string     `json:"after,omitempty"`
+	Before  string     `json:"before,omitempty"`
+	Weekday StringList `json:"weekday,omitempty"`
 }
 
 func (*TimeCondition) ConditionKey() string { return "time" }
@@ -198,6 +232,23 @@ type TemplateCondition struct {
 
 func (*TemplateCondition) ConditionKey() string { return "template" }
 
+// TriggerCondition passes if the automation was triggered by a trigger with one of the given IDs.
+type TriggerCondition struct {
+	Id StringList `json:"id"`
+}
+
+func (*TriggerCondition) ConditionKey() string { return "trigger" }
+
+type DeviceCondition struct {
+	DeviceId string `json:"device_id"`
+	Domain   string `json:"domain"`
+	EntityId string `json:"entity_id,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Subtype  string `json:"subtype,omitempty"`
+}
+
+func (*DeviceCondition) ConditionKey() string { return "device" }
+
 // StringOrFloat represents a value that is either a string or an integer.
 type StringOrFloat struct {
 	*string
@@ -216,6 +267,19 @@ func (s StringOrFloat) String() string {
 	return *s.string
 }
 
+// IsSet returns true if a value was given, as either a string or a number.
+func (s StringOrFloat) IsSet() bool {
+	return s.string != nil || s.float64 != nil
+}
+
+// Float returns the value if it was given as a number; ok is false otherwise.
+func (s StringOrFloat) Float() (f float64, ok bool) {
+	if s.float64 == nil {
+		return 0, false
+	}
+	return *s.float64, true
+}
+
 func StringOrIntFromString(s string) StringOrFloat {
 	ret := StringOrFloat{string: new(string)}
 	*ret.string = s
@@ -228,7 +292,7 @@ func StringOrIntFromFloat64(f float64) StringOrFloat {
 	return ret
 }
 
-func (s StringOrFloat) UnmarshalJSON(data []byte) error {
+func (s *StringOrFloat) UnmarshalJSON(data []byte) error {
 	var something interface{}
 	if err := json.Unmarshal(data, &something); err != nil {
 		return err
@@ -0,0 +1,518 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EvalContext is what conditions are evaluated against.
+type EvalContext struct {
+	// States holds the state of each entity, by entity ID.
+	States map[string]State
+	// Now is the time at which conditions are evaluated. Its location determines the time of day and day of week.
+	Now time.Time
+	// Latitude and Longitude locate the homeassistant instance, in degrees north and east, for sun conditions.
+	Latitude  float64
+	Longitude float64
+	// Sunrise and Sunset, if set, are used in place of the times calculated from Latitude and Longitude.
+	Sunrise time.Time
+	Sunset  time.Time
+	// TriggerId is the ID of the trigger that fired, for trigger conditions.
+	TriggerId string
+}
+
+// NewEvalContext returns an EvalContext for the given states and config (which supplies location and time zone) at
+// the given time.
+func NewEvalContext(states []State, config *Config, now time.Time) (EvalContext, error) {
+	ret := EvalContext{States: map[string]State{}, Now: now}
+	for _, state := range states {
+		ret.States[state.EntityId] = state
+	}
+	if config != nil {
+		location, err := time.LoadLocation(config.TimeZone)
+		if err != nil {
+			return EvalContext{}, fmt.Errorf("loading time zone %q: %w", config.TimeZone, err)
+		}
+		ret.Now = now.In(location)
+		ret.Latitude, ret.Longitude = config.Latitude, config.Longitude
+	}
+	return ret, nil
+}
+
+// CurrentEvalContext returns an EvalContext for the server's current states, as of now.
+func (c *Client) CurrentEvalContext() (EvalContext, error) {
+	states, err := c.ListStates()
+	if err != nil {
+		return EvalContext{}, fmt.Errorf("listing states: %w", err)
+	}
+	config, err := c.GetConfig()
+	if err != nil {
+		return EvalContext{}, fmt.Errorf("getting config: %w", err)
+	}
+	return NewEvalContext(states, config, time.Now())
+}
+
+// ConditionTrace explains the result of evaluating a condition, with a trace for each nested condition.
+type ConditionTrace struct {
+	Condition string `json:"condition"`
+	Alias     string `json:"alias,omitempty"`
+	Result    bool   `json:"result"`
+	// Unsupported is true if the result depends on something that cannot be evaluated locally, such as a template.
+	// Result is false in that case.
+	Unsupported bool              `json:"unsupported,omitempty"`
+	Detail      string            `json:"detail,omitempty"`
+	Children    []*ConditionTrace `json:"children,omitempty"`
+}
+
+// Evaluator is implemented by conditions that can be evaluated locally.
+type Evaluator interface {
+	Evaluate(ctx EvalContext) (bool, *ConditionTrace)
+}
+
+func conditionTrace(c Condition, result bool, format string, args ...interface{}) *ConditionTrace {
+	return &ConditionTrace{Condition: c.ConditionKey(), Result: result, Detail: fmt.Sprintf(format, args...)}
+}
+
+func unsupported(c Condition, format string, args ...interface{}) (bool, *ConditionTrace) {
+	ret := conditionTrace(c, false, format, args...)
+	ret.Unsupported = true
+	return false, ret
+}
+
+// Evaluate evaluates the condition. Disabled conditions pass, as they do in homeassistant, and conditions that cannot
+// be evaluated locally fail with an Unsupported trace.
+func (a AutomationCondition) Evaluate(ctx EvalContext) (bool, *ConditionTrace) {
+	var result bool
+	var trace *ConditionTrace
+	if a.Enabled != nil && !*a.Enabled {
+		result, trace = true, conditionTrace(a.Condition, true, "disabled")
+	} else if evaluator, ok := a.Condition.(Evaluator); ok {
+		result, trace = evaluator.Evaluate(ctx)
+	} else {
+		result, trace = unsupported(a.Condition, "%s conditions cannot be evaluated locally", a.ConditionKey())
+	}
+	trace.Alias = a.Alias
+	return result, trace
+}
+
+// Evaluate evaluates the conditions, which pass if all of them pass. Every condition is evaluated, so that the trace
+// explains each one.
+func (l ConditionList) Evaluate(ctx EvalContext) (bool, *ConditionTrace) {
+	return evaluateAll(&AndCondition{Conditions: l}, ctx)
+}
+
+func evaluateAll(c *AndCondition, ctx EvalContext) (bool, *ConditionTrace) {
+	ret := conditionTrace(c, true, "all of %d conditions must pass", len(c.Conditions))
+	unknown := false
+	for _, condition := range c.Conditions {
+		result, trace := condition.Evaluate(ctx)
+		ret.Children = append(ret.Children, trace)
+		if trace.Unsupported {
+			unknown = true
+		} else if !result {
+			ret.Result = false
+		}
+	}
+	if ret.Result && unknown {
+		ret.Result, ret.Unsupported = false, true
+	}
+	return ret.Result, ret
+}
+
+func (c *AndCondition) Evaluate(ctx EvalContext) (bool, *ConditionTrace) {
+	return evaluateAll(c, ctx)
+}
+
+func (c *OrCondition) Evaluate(ctx EvalContext) (bool, *ConditionTrace) {
+	ret := conditionTrace(c, false, "any of %d conditions must pass", len(c.Conditions))
+	unknown := false
+	for _, condition := range c.Conditions {
+		result, trace := condition.Evaluate(ctx)
+		ret.Children = append(ret.Children, trace)
+		if trace.Unsupported {
+			unknown = true
+		} else if result {
+			ret.Result = true
+		}
+	}
+	if !ret.Result && unknown {
+		ret.Unsupported = true
+	}
+	return ret.Result, ret
+}
+
+func (c *NotCondition) Evaluate(ctx EvalContext) (bool, *ConditionTrace) {
+	ret := conditionTrace(c, true, "none of %d conditions may pass", len(c.Conditions))
+	unknown := false
+	for _, condition := range c.Conditions {
+		result, trace := condition.Evaluate(ctx)
+		ret.Children = append(ret.Children, trace)
+		if trace.Unsupported {
+			unknown = true
+		} else if result {
+			ret.Result = false
+		}
+	}
+	if ret.Result && unknown {
+		ret.Result, ret.Unsupported = false, true
+	}
+	return ret.Result, ret
+}
+
+// entityValue returns the state of the given entity, or the given attribute of it if attribute is not empty, along
+// with the time it last changed.
+func entityValue(ctx EvalContext, entityId, attribute string) (interface{}, time.Time, error) {
+	state, ok := ctx.States[entityId]
+	if !ok {
+		return nil, time.Time{}, fmt.Errorf("%s does not exist", entityId)
+	}
+	if attribute == "" {
+		return state.State, state.LastChanged, nil
+	}
+	value, ok := state.Attributes[attribute]
+	if !ok {
+		return nil, time.Time{}, fmt.Errorf("%s has no attribute %q", entityId, attribute)
+	}
+	return value, state.LastUpdated, nil
+}
+
+func (c *StateCondition) Evaluate(ctx EvalContext) (bool, *ConditionTrace) {
+	if c.For.IsTemplate() || c.For.tagged() {
+		return unsupported(c, "templates and inputs cannot be evaluated locally")
+	}
+	for _, state := range c.State.Values {
+		if isYAMLTag(state.String()) {
+			return unsupported(c, "templates and inputs cannot be evaluated locally")
+		}
+	}
+
+	matchAny := c.Match == "any"
+	result := !matchAny
+	var details []string
+	for _, entityId := range c.EntityId.Values {
+		pass, detail := c.evaluateEntity(ctx, entityId)
+		details = append(details, detail)
+		if matchAny && pass {
+			result = true
+		} else if !matchAny && !pass {
+			result = false
+		}
+	}
+	return result, conditionTrace(c, result, "%s", strings.Join(details, "; "))
+}
+
+func (c *StateCondition) evaluateEntity(ctx EvalContext, entityId string) (bool, string) {
+	value, changed, err := entityValue(ctx, entityId, c.Attribute)
+	if err != nil {
+		return false, err.Error()
+	}
+
+	name := entityId
+	if c.Attribute != "" {
+		name = entityId + "." + c.Attribute
+	}
+
+	matches := false
+	for _, state := range c.State.Values {
+		if want, ok := state.Float(); ok {
+			got, err := toFloat(value)
+			matches = matches || err == nil && got == want
+		} else {
+			matches = matches || fmt.Sprint(value) == state.String()
+		}
+	}
+	if !matches {
+		if len(c.State.Values) == 1 {
+			return false, fmt.Sprintf("%s is %q, not %q", name, fmt.Sprint(value), c.State.String())
+		}
+		return false, fmt.Sprintf("%s is %q, not one of %s", name, fmt.Sprint(value), c.State.String())
+	}
+
+	if c.For.Duration > 0 {
+		if held := ctx.Now.Sub(changed); held < c.For.Duration {
+			return false, fmt.Sprintf("%s is %q, but only for %s of %s", name, fmt.Sprint(value),
+				held.Round(time.Second), c.For.Duration)
+		}
+	}
+	return true, fmt.Sprintf("%s is %q", name, fmt.Sprint(value))
+}
+
+func toFloat(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	}
+	return 0, fmt.Errorf("%v is %T, not a number", value, value)
+}
+
+func (c *NumericStateCondition) Evaluate(ctx EvalContext) (bool, *ConditionTrace) {
+	if c.ValueTemplate != "" {
+		return unsupported(c, "value_template cannot be evaluated locally")
+	}
+
+	above, err := numericBound(ctx, c.Above)
+	if err != nil {
+		return false, conditionTrace(c, false, "above: %v", err)
+	}
+	below, err := numericBound(ctx, c.Below)
+	if err != nil {
+		return false, conditionTrace(c, false, "below: %v", err)
+	}
+
+	result := true
+	var details []string
+	for _, entityId := range c.EntityId.Values {
+		value, _, err := entityValue(ctx, entityId, c.Attribute)
+		if err != nil {
+			result = false
+			details = append(details, err.Error())
+			continue
+		}
+		f, err := toFloat(value)
+		if err != nil {
+			result = false
+			details = append(details, fmt.Sprintf("%s is %q, which is not a number", entityId, fmt.Sprint(value)))
+			continue
+		}
+
+		switch {
+		case above != nil && !(f > *above):
+			result = false
+			details = append(details, fmt.Sprintf("%s is %v, not above %v", entityId, f, *above))
+		case below != nil && !(f < *below):
+			result = false
+			details = append(details, fmt.Sprintf("%s is %v, not below %v", entityId, f, *below))
+		default:
+			details = append(details, fmt.Sprintf("%s is %v", entityId, f))
+		}
+	}
+	return result, conditionTrace(c, result, "%s", strings.Join(details, "; "))
+}
+
+// numericBound returns the value of a numeric_state bound, which is either a number or the entity ID of an entity
+// whose state is a number; or nil if the bound is not set.
+func numericBound(ctx EvalContext, bound StringOrFloat) (*float64, error) {
+	if !bound.IsSet() {
+		return nil, nil
+	}
+	if f, ok := bound.Float(); ok {
+		return &f, nil
+	}
+	value, _, err := entityValue(ctx, bound.String(), "")
+	if err != nil {
+		return nil, err
+	}
+	f, err := toFloat(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", bound.String(), err)
+	}
+	return &f, nil
+}
+
+func (c *TimeCondition) Evaluate(ctx EvalContext) (bool, *ConditionTrace) {
+	now := ctx.Now
+	if len(c.Weekday.Values) > 0 {
+		today := strings.ToLower(now.Weekday().String()[:3])
+		found := false
+		for _, day := range c.Weekday.Values {
+			found = found || day == today
+		}
+		if !found {
+			return false, conditionTrace(c, false, "today is %s, not one of %s", today,
+				strings.Join(c.Weekday.Values, ", "))
+		}
+	}
+
+	after, err := timeOfDay(ctx, c.After, 0)
+	if err != nil {
+		return unsupported(c, "after: %v", err)
+	}
+	before, err := timeOfDay(ctx, c.Before, 24*time.Hour)
+	if err != nil {
+		return unsupported(c, "before: %v", err)
+	}
+
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	current := now.Sub(midnight)
+	var result bool
+	if after <= before {
+		result = after <= current && current < before
+	} else {
+		// the window crosses midnight
+		result = current >= after || current < before
+	}
+	return result, conditionTrace(c, result, "it is %s; window is %s to %s",
+		formatDuration(current.Truncate(time.Second)), formatDuration(after), formatDuration(before))
+}
+
+// timeOfDay returns the time since midnight given by value, which is HH:MM[:SS] or the entity ID of an
+// input_datetime or timestamp sensor. If value is empty, def is returned.
+func timeOfDay(ctx EvalContext, value string, def time.Duration) (time.Duration, error) {
+	if value == "" {
+		return def, nil
+	}
+	if isTemplate(value) || isYAMLTag(value) {
+		return 0, fmt.Errorf("%q cannot be evaluated locally", value)
+	}
+
+	if strings.Contains(value, ".") && (value[0] < '0' || value[0] > '9') {
+		state, ok := ctx.States[value]
+		if !ok {
+			return 0, fmt.Errorf("%s does not exist", value)
+		}
+		if t, err := time.Parse(time.RFC3339, state.State); err == nil {
+			t = t.In(ctx.Now.Location())
+			return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+				time.Duration(t.Second())*time.Second, nil
+		}
+		// input_datetime states are "HH:MM:SS", or "YYYY-MM-DD HH:MM:SS" if they have a date
+		fields := strings.Fields(state.State)
+		if len(fields) == 0 {
+			return 0, fmt.Errorf("%s has no state", value)
+		}
+		value = fields[len(fields)-1]
+	}
+
+	d, err := parseDuration(value)
+	if err != nil || d < 0 || d >= 24*time.Hour {
+		return 0, fmt.Errorf("%q is not a time of day", value)
+	}
+	return d, nil
+}
+
+func (c *ZoneCondition) Evaluate(ctx EvalContext) (bool, *ConditionTrace) {
+	result := true
+	var details []string
+	for _, entityId := range c.EntityId.Values {
+		zone, err := entityZone(ctx, entityId, c.Zone.Values)
+		if err != nil {
+			result = false
+			details = append(details, err.Error())
+		} else {
+			details = append(details, fmt.Sprintf("%s is in %s", entityId, zone))
+		}
+	}
+	return result, conditionTrace(c, result, "%s", strings.Join(details, "; "))
+}
+
+// entityZone returns the first of the given zones that the entity is in, as homeassistant decides: the distance
+// between the entity and the zone, less the entity's GPS accuracy, is less than the zone's radius.
+func entityZone(ctx EvalContext, entityId string, zones []string) (string, error) {
+	state, ok := ctx.States[entityId]
+	if !ok {
+		return "", fmt.Errorf("%s does not exist", entityId)
+	}
+	lat, latOk := state.Attributes["latitude"].(float64)
+	lon, lonOk := state.Attributes["longitude"].(float64)
+	if !latOk || !lonOk {
+		return "", fmt.Errorf("%s has no location", entityId)
+	}
+	accuracy, _ := state.Attributes["gps_accuracy"].(float64)
+
+	for _, zoneId := range zones {
+		zone, ok := ctx.States[zoneId]
+		if !ok {
+			return "", fmt.Errorf("%s does not exist", zoneId)
+		}
+		zoneLat, _ := zone.Attributes["latitude"].(float64)
+		zoneLon, _ := zone.Attributes["longitude"].(float64)
+		radius, _ := zone.Attributes["radius"].(float64)
+		if distance(lat, lon, zoneLat, zoneLon)-accuracy < radius {
+			return zoneId, nil
+		}
+	}
+	return "", fmt.Errorf("%s is not in %s", entityId, strings.Join(zones, " or "))
+}
+
+// distance returns the great-circle distance in meters between two points given in degrees.
+func distance(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadius = 6371008.8
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
+
+func (c *SunCondition) Evaluate(ctx EvalContext) (bool, *ConditionTrace) {
+	for _, offset := range []Duration{c.AfterOffset, c.BeforeOffset} {
+		if offset.IsTemplate() || offset.tagged() {
+			return unsupported(c, "templates and inputs cannot be evaluated locally")
+		}
+	}
+
+	sunrise, sunset := ctx.Sunrise, ctx.Sunset
+	if sunrise.IsZero() || sunset.IsZero() {
+		var ok bool
+		sunrise, sunset, ok = SunTimes(ctx.Now, ctx.Latitude, ctx.Longitude)
+		if !ok {
+			return false, conditionTrace(c, false, "the sun does not both rise and set today")
+		}
+	}
+	event := map[string]time.Time{"sunrise": sunrise, "sunset": sunset}
+
+	var passes []bool
+	var times []time.Time
+	var details []string
+	for _, bound := range []struct {
+		name   string
+		event  string
+		offset time.Duration
+	}{{"after", c.After, c.AfterOffset.Duration}, {"before", c.Before, c.BeforeOffset.Duration}} {
+		if bound.event == "" {
+			continue
+		}
+		at, ok := event[bound.event]
+		if !ok {
+			return false, conditionTrace(c, false, "%s: %q is not sunrise or sunset", bound.name, bound.event)
+		}
+		at = at.Add(bound.offset)
+		passes = append(passes, bound.name == "after" && !ctx.Now.Before(at) || bound.name == "before" && ctx.Now.Before(at))
+		times = append(times, at)
+		details = append(details, fmt.Sprintf("%s %s (%s)", bound.name, bound.event, at.Format("15:04:05")))
+	}
+
+	// like a time condition, a window whose start is later in the day than its end (e.g., after sunset and before
+	// sunrise) spans midnight, and so is met by either bound
+	join := " and "
+	result := true
+	for _, pass := range passes {
+		result = result && pass
+	}
+	if len(times) == 2 && times[0].After(times[1]) {
+		join = " or "
+		result = passes[0] || passes[1]
+	}
+	return result, conditionTrace(c, result, "it is %s; must be %s", ctx.Now.Format("15:04:05"),
+		strings.Join(details, join))
+}
+
+func (c *TriggerCondition) Evaluate(ctx EvalContext) (bool, *ConditionTrace) {
+	if ctx.TriggerId == "" {
+		return unsupported(c, "no trigger ID was given")
+	}
+	for _, id := range c.Id.Values {
+		if id == ctx.TriggerId {
+			return true, conditionTrace(c, true, "triggered by %q", id)
+		}
+	}
+	return false, conditionTrace(c, false, "triggered by %q, not %s", ctx.TriggerId, strings.Join(c.Id.Values, " or "))
+}
+
+var _ Evaluator = AutomationCondition{}
+var _ Evaluator = ConditionList{}
+var _ Evaluator = (*AndCondition)(nil)
+var _ Evaluator = (*OrCondition)(nil)
+var _ Evaluator = (*NotCondition)(nil)
+var _ Evaluator = (*StateCondition)(nil)
+var _ Evaluator = (*NumericStateCondition)(nil)
+var _ Evaluator = (*TimeCondition)(nil)
+var _ Evaluator = (*ZoneCondition)(nil)
+var _ Evaluator = (*SunCondition)(nil)
+var _ Evaluator = (*TriggerCondition)(nil)
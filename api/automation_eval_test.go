@@ -0,0 +1,145 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConditionEvaluate(t *testing.T) {
+	location, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	// a Wednesday
+	now := time.Date(2024, 3, 13, 22, 30, 0, 0, location)
+
+	ctx, err := NewEvalContext([]State{
+		{EntityId: "light.kitchen", State: "on", LastChanged: now.Add(-10 * time.Minute),
+			Attributes: map[string]interface{}{"brightness": float64(128)}},
+		{EntityId: "sensor.temperature", State: "21.5"},
+		{EntityId: "sensor.unavailable", State: "unavailable"},
+		{EntityId: "input_number.threshold", State: "20"},
+		{EntityId: "input_datetime.bedtime", State: "22:00:00"},
+		{EntityId: "zone.home", State: "0", Attributes: map[string]interface{}{
+			"latitude": 40.7128, "longitude": -74.0060, "radius": float64(100)}},
+		{EntityId: "person.alex", State: "home", Attributes: map[string]interface{}{
+			"latitude": 40.7130, "longitude": -74.0062, "gps_accuracy": float64(10)}},
+		{EntityId: "person.sam", State: "not_home", Attributes: map[string]interface{}{
+			"latitude": 40.8, "longitude": -74.1}},
+	}, &Config{TimeZone: "America/New_York", Latitude: 40.7128, Longitude: -74.0060}, now)
+	require.NoError(t, err)
+	ctx.TriggerId = "motion"
+
+	tests := []struct {
+		name        string
+		condition   string
+		want        bool
+		unsupported bool
+	}{
+		{"state", `{"condition": "state", "entity_id": "light.kitchen", "state": "on"}`, true, false},
+		{"state mismatch", `{"condition": "state", "entity_id": "light.kitchen", "state": "off"}`, false, false},
+		{"state for", `{"condition": "state", "entity_id": "light.kitchen", "state": "on", "for": "00:05:00"}`,
+			true, false},
+		{"state not for long enough",
+			`{"condition": "state", "entity_id": "light.kitchen", "state": "on", "for": {"minutes": 15}}`, false, false},
+		{"state attribute", `{"condition": "state", "entity_id": "light.kitchen", "attribute": "brightness", "state": 128}`,
+			true, false},
+		{"state list", `{"condition": "state", "entity_id": "light.kitchen", "state": ["off", "on"]}`, true, false},
+		{"state list mismatch", `{"condition": "state", "entity_id": "person.sam", "state": ["home", "work"]}`,
+			false, false},
+		{"state for input", `{"condition": "state", "entity_id": "light.kitchen", "state": "on", "for": "!input delay"}`,
+			false, true},
+		{"state missing entity", `{"condition": "state", "entity_id": "light.nope", "state": "on"}`, false, false},
+		{"state all", `{"condition": "state", "entity_id": ["light.kitchen", "sensor.temperature"], "state": "on"}`,
+			false, false},
+		{"state any",
+			`{"condition": "state", "entity_id": ["light.kitchen", "sensor.temperature"], "state": "on", "match": "any"}`,
+			true, false},
+		{"numeric above", `{"condition": "numeric_state", "entity_id": "sensor.temperature", "above": 20}`, true, false},
+		{"numeric above entity",
+			`{"condition": "numeric_state", "entity_id": "sensor.temperature", "above": "input_number.threshold"}`,
+			true, false},
+		{"numeric below", `{"condition": "numeric_state", "entity_id": "sensor.temperature", "below": 21.5}`,
+			false, false},
+		{"numeric unavailable", `{"condition": "numeric_state", "entity_id": "sensor.unavailable", "above": 0}`,
+			false, false},
+		{"numeric template",
+			`{"condition": "numeric_state", "entity_id": "sensor.temperature", "value_template": "{{ 1 }}", "above": 0}`,
+			false, true},
+		{"time window", `{"condition": "time", "after": "22:00", "before": "23:00"}`, true, false},
+		{"time outside window", `{"condition": "time", "after": "08:00", "before": "17:00:00"}`, false, false},
+		{"time across midnight", `{"condition": "time", "after": "input_datetime.bedtime", "before": "06:00"}`,
+			true, false},
+		{"time weekday", `{"condition": "time", "weekday": ["mon", "wed"]}`, true, false},
+		{"time wrong weekday", `{"condition": "time", "weekday": "sat"}`, false, false},
+		{"zone", `{"condition": "zone", "entity_id": "person.alex", "zone": "zone.home"}`, true, false},
+		{"zone away", `{"condition": "zone", "entity_id": ["person.alex", "person.sam"], "zone": "zone.home"}`,
+			false, false},
+		{"sun after sunset", `{"condition": "sun", "after": "sunset"}`, true, false},
+		{"sun before sunset", `{"condition": "sun", "before": "sunset", "before_offset": "05:00:00"}`, true, false},
+		{"sun after sunrise and before sunset", `{"condition": "sun", "after": "sunrise", "before": "sunset"}`,
+			false, false},
+		{"sun offset input", `{"condition": "sun", "after": "sunset", "after_offset": "!input offset"}`, false, true},
+		{"trigger", `{"condition": "trigger", "id": ["motion", "door"]}`, true, false},
+		{"trigger mismatch", `{"condition": "trigger", "id": "door"}`, false, false},
+		{"template", `"{{ true }}"`, false, true},
+		{"disabled", `{"condition": "state", "entity_id": "light.kitchen", "state": "off", "enabled": false}`,
+			true, false},
+		{"and", `{"condition": "and", "conditions": [` +
+			`{"condition": "state", "entity_id": "light.kitchen", "state": "on"},` +
+			`{"condition": "numeric_state", "entity_id": "sensor.temperature", "below": 20}]}`, false, false},
+		{"and unsupported", `{"condition": "and", "conditions": [` +
+			`{"condition": "state", "entity_id": "light.kitchen", "state": "on"}, "{{ true }}"]}`, false, true},
+		{"and decided despite unsupported", `{"condition": "and", "conditions": [` +
+			`{"condition": "state", "entity_id": "light.kitchen", "state": "off"}, "{{ true }}"]}`, false, false},
+		{"or", `{"condition": "or", "conditions": [` +
+			`"{{ true }}", {"condition": "state", "entity_id": "light.kitchen", "state": "on"}]}`, true, false},
+		{"not", `{"condition": "not", "conditions": [` +
+			`{"condition": "state", "entity_id": "light.kitchen", "state": "off"}]}`, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var condition AutomationCondition
+			require.NoError(t, json.Unmarshal([]byte(tt.condition), &condition))
+			got, trace := condition.Evaluate(ctx)
+			require.Equal(t, tt.want, got, trace.Detail)
+			require.Equal(t, got, trace.Result)
+			require.Equal(t, tt.unsupported, trace.Unsupported, trace.Detail)
+		})
+	}
+}
+
+func TestSunConditionNight(t *testing.T) {
+	day := time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC)
+	var condition AutomationCondition
+	require.NoError(t, json.Unmarshal([]byte(`{"condition": "sun", "after": "sunset", "before": "sunrise"}`),
+		&condition))
+
+	for _, tt := range []struct {
+		hour int
+		want bool
+	}{{23, true}, {3, true}, {12, false}} {
+		ctx := EvalContext{
+			Now:     day.Add(time.Duration(tt.hour) * time.Hour),
+			Sunrise: day.Add(5 * time.Hour),
+			Sunset:  day.Add(21 * time.Hour),
+		}
+		got, trace := condition.Evaluate(ctx)
+		require.Equal(t, tt.want, got, "%02d:00: %s", tt.hour, trace.Detail)
+		require.Contains(t, trace.Detail, "after sunset (21:00:00) or before sunrise (05:00:00)")
+	}
+}
+
+func TestSunTimes(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	require.NoError(t, err)
+
+	sunrise, sunset, ok := SunTimes(time.Date(2024, 6, 21, 12, 0, 0, 0, london), 51.5074, -0.1278)
+	require.True(t, ok)
+	require.WithinDuration(t, time.Date(2024, 6, 21, 4, 43, 0, 0, london), sunrise, 3*time.Minute)
+	require.WithinDuration(t, time.Date(2024, 6, 21, 21, 21, 0, 0, london), sunset, 3*time.Minute)
+
+	_, _, ok = SunTimes(time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC), 80, 0)
+	require.False(t, ok)
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// fieldsToMap converts the struct pointed to by obj into a map keyed by the fields' JSON names, as a JSON encoder
+// would. Fields tagged `omitempty` are left out when empty; unlike encoding/json, a struct is considered empty when it
+// is its zero value, so optional values like StringList and Duration may be omitted without resorting to pointers.
+func fieldsToMap(obj interface{}) map[string]interface{} {
+	val := reflect.Indirect(reflect.ValueOf(obj))
+	typ := val.Type()
+	ret := map[string]interface{}{}
+	for fieldIdx := 0; fieldIdx < typ.NumField(); fieldIdx++ {
+		field := typ.Field(fieldIdx)
+		if field.PkgPath != "" {
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("json"), ",")
+		if tag[0] == "-" {
+			continue
+		}
+		name := tag[0]
+		if name == "" {
+			name = field.Name
+		}
+		value := val.Field(fieldIdx)
+		if hasOption(tag[1:], "omitempty") && isEmptyValue(value) {
+			continue
+		}
+		ret[name] = value.Interface()
+	}
+	return ret
+}
+
+func hasOption(options []string, option string) bool {
+	for _, o := range options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	default:
+		return v.IsZero()
+	}
+}
+
+// convertInto stores the generically-decoded value into target, by way of JSON, so that target's type (or its
+// UnmarshalJSON method) decides what is acceptable.
+func convertInto(value interface{}, target reflect.Value) error {
+	jb, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(jb, target.Addr().Interface())
+}
+
+// fieldsFromMap is the inverse of fieldsToMap: it assigns each field of the struct pointed to by obj from the entry in
+// generic with the field's JSON name, converting as needed. kind names the struct in error messages.
+//
+// The keys consumed are returned, so that callers can preserve any that are left over. Null values, and empty values
+// for `omitempty` fields, are not consumed: fieldsToMap would leave them out, so they must be preserved to round-trip.
+func fieldsFromMap(kind string, generic map[string]interface{}, obj interface{}) (map[string]bool, error) {
+	val := reflect.ValueOf(obj).Elem()
+	typ := val.Type()
+	used := map[string]bool{}
+	for fieldIdx := 0; fieldIdx < typ.NumField(); fieldIdx++ {
+		field := typ.Field(fieldIdx)
+		if field.PkgPath != "" {
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("json"), ",")
+		if tag[0] == "-" {
+			continue
+		}
+		fieldName := tag[0]
+		if fieldName == "" {
+			fieldName = field.Name
+		}
+		valueToAssign, ok := generic[fieldName]
+		if !ok || valueToAssign == nil {
+			continue
+		}
+
+		targetField := val.Field(fieldIdx)
+		if targetField.Kind() == reflect.Interface || reflect.TypeOf(valueToAssign) == targetField.Type() {
+			targetField.Set(reflect.ValueOf(valueToAssign))
+		} else if err := convertInto(valueToAssign, targetField); err != nil {
+			// lists and maps decode generically, and custom types decode themselves, so this is only an error if
+			// the conversion fails
+			return nil, fmt.Errorf("%q expects %s for field %s, but input had %s: %w", kind, targetField.Type(),
+				fieldName, reflect.TypeOf(valueToAssign), err)
+		}
+
+		if hasOption(tag[1:], "omitempty") && isEmptyValue(targetField) {
+			continue
+		}
+		used[fieldName] = true
+	}
+	return used, nil
+}
+
+// extraFields returns the entries of generic whose keys are neither used nor listed in known, or nil if there are
+// none.
+func extraFields(generic map[string]interface{}, used map[string]bool, known ...string) map[string]interface{} {
+	var ret map[string]interface{}
+outer:
+	for k, v := range generic {
+		if used[k] {
+			continue
+		}
+		for _, kn := range known {
+			if k == kn {
+				continue outer
+			}
+		}
+		if ret == nil {
+			ret = map[string]interface{}{}
+		}
+		ret[k] = v
+	}
+	return ret
+}
+
+// mergeUsed returns the union of the given sets of used keys.
+func mergeUsed(sets ...map[string]bool) map[string]bool {
+	ret := map[string]bool{}
+	for _, set := range sets {
+		for k := range set {
+			ret[k] = true
+		}
+	}
+	return ret
+}
+
+// mergeFields returns a map containing the entries of each of maps, with later maps taking precedence.
+func mergeFields(maps ...map[string]interface{}) map[string]interface{} {
+	ret := map[string]interface{}{}
+	for _, m := range maps {
+		for k, v := range m {
+			ret[k] = v
+		}
+	}
+	return ret
+}
@@ -2,8 +2,15 @@ package api
 
 import (
 	"encoding/json"
-	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
 func TestConditionFromMap(t *testing.T) {
@@ -17,16 +24,36 @@ func TestConditionFromMap(t *testing.T) {
 			"state condition decode",
 			`{"condition": "state", "entity_id": "test-eid", "state": "test-state"}`,
 			&StateCondition{
-				EntityId: "test-eid",
-				State:    StringOrIntFromString("test-state"),
+				EntityId: StringListOf("test-eid"),
+				State:    StateListOf("test-state"),
 			},
 			false,
 		}, {
 			"state condition decode numeric",
 			`{"condition": "state", "entity_id": "test-eid", "state": 1}`,
 			&StateCondition{
-				EntityId: "test-eid",
-				State:    StringOrIntFromFloat64(1),
+				EntityId: StringListOf("test-eid"),
+				State:    StateList{Values: []StringOrFloat{StringOrIntFromFloat64(1)}},
+			},
+			false,
+		}, {
+			"state condition decode list",
+			`{"condition": "state", "entity_id": "person.alex", "state": ["home", "work", 1]}`,
+			&StateCondition{
+				EntityId: StringListOf("person.alex"),
+				State: StateList{
+					Values: []StringOrFloat{StringOrIntFromString("home"), StringOrIntFromString("work"),
+						StringOrIntFromFloat64(1)},
+					list: true,
+				},
+			},
+			false,
+		}, {
+			"state condition decode one-item list",
+			`{"condition": "state", "entity_id": "person.alex", "state": ["home"]}`,
+			&StateCondition{
+				EntityId: StringListOf("person.alex"),
+				State:    StateList{Values: []StringOrFloat{StringOrIntFromString("home")}, list: true},
 			},
 			false,
 		}, {
@@ -39,10 +66,10 @@ func TestConditionFromMap(t *testing.T) {
 			"simple logical",
 			`{"condition": "and", "conditions": [ {"condition": "state", "entity_id": "test-eid", "state": "test-state"} ] }`,
 			&AndCondition{
-				Conditions: []Condition{&StateCondition{
-					EntityId: "test-eid",
-					State:    StringOrIntFromString("test-state"),
-				}},
+				Conditions: ConditionList{{Condition: &StateCondition{
+					EntityId: StringListOf("test-eid"),
+					State:    StateListOf("test-state"),
+				}}},
 			},
 			false,
 		},
@@ -54,12 +81,15 @@ func TestConditionFromMap(t *testing.T) {
 				`]}` +
 				`]}`,
 			&OrCondition{
-				Conditions: []Condition{&AndCondition{
-					Conditions: []Condition{
-						&StateCondition{"test-a", StringOrIntFromString("hi")},
-						&StateCondition{"test-b", StringOrIntFromFloat64(99)},
+				Conditions: ConditionList{{Condition: &AndCondition{
+					Conditions: ConditionList{
+						{Condition: &StateCondition{EntityId: StringListOf("test-a"), State: StateListOf("hi")}},
+						{Condition: &StateCondition{
+							EntityId: StringListOf("test-b"),
+							State:    StateList{Values: []StringOrFloat{StringOrIntFromFloat64(99)}},
+						}},
 					},
-				}},
+				}}},
 			},
 			false,
 		},
@@ -89,3 +119,333 @@ func TestConditionFromMap(t *testing.T) {
 		})
 	}
 }
+
+func TestSaveAndDeleteAutomation(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Method == "POST" {
+			var body map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			require.Equal(t, "test alias", body["alias"])
+		}
+		_, _ = w.Write([]byte(`{"result": "ok"}`))
+	}))
+	defer server.Close()
+
+	c := &Client{Server: server.URL}
+	automation := &Automation{
+		Alias:   "test alias",
+		Trigger: []AutomationTrigger{{Trigger: &HassTrigger{Event: "start"}}},
+		Action:  ActionSequence{{Action: &SceneAction{Scene: "scene.test"}}},
+	}
+	require.NoError(t, automation.Validate())
+	require.NoError(t, c.SaveAutomation(automation))
+	require.NotEmpty(t, automation.Id)
+	require.NoError(t, c.DeleteAutomation(automation.Id))
+
+	require.Equal(t, []string{
+		"POST /api/config/automation/config/" + string(automation.Id),
+		"DELETE /api/config/automation/config/" + string(automation.Id),
+	}, requests)
+}
+
+func TestActionUnmarshal(t *testing.T) {
+	input := `[
+		{"action": "light.turn_on", "target": {"entity_id": "light.kitchen"}, "data": {"brightness": 128}},
+		{"service": "light.turn_off", "entity_id": ["light.a", "light.b"], "alias": "off"},
+		{"delay": {"hours": 0, "minutes": 5, "seconds": 0, "milliseconds": 0}},
+		{"delay": "00:01:30"},
+		{"condition": "state", "entity_id": "sun.sun", "state": "below_horizon"},
+		{"choose": [{"conditions": [{"condition": "state", "entity_id": "a", "state": "on"}],
+			"sequence": [{"event": "x"}]}], "default": [{"stop": "done"}]},
+		{"if": [{"condition": "template", "value_template": "{{ true }}"}], "then": {"scene": "scene.x"},
+			"else": [{"variables": {"a": 1}}]},
+		{"repeat": {"count": 3, "sequence": [{"delay": 1}]}},
+		{"repeat": {"until": "{{ false }}", "sequence": [{"wait_template": "{{ true }}", "timeout": "00:00:10"}]}},
+		{"parallel": [{"sequence": [{"event": "a"}]}, {"event": "b"}]},
+		{"wait_for_trigger": [{"platform": "state", "entity_id": "x", "to": "on"}], "continue_on_timeout": false},
+		{"device_id": "abc", "domain": "light", "entity_id": "light.x", "type": "turn_on"}
+	]`
+
+	var got ActionSequence
+	require.NoError(t, json.Unmarshal([]byte(input), &got))
+	require.Len(t, got, 12)
+
+	require.Equal(t, "light.turn_on", got[0].Action.(*ServiceAction).ServiceName())
+	require.Equal(t, []string{"light.a", "light.b"}, got[1].Action.(*ServiceAction).EntityId.Values)
+	require.Equal(t, "off", got[1].Alias)
+	require.Equal(t, 5*time.Minute, got[2].Action.(*DelayAction).Delay.Duration)
+	require.Equal(t, 90*time.Second, got[3].Action.(*DelayAction).Delay.Duration)
+	require.IsType(t, &StateCondition{}, got[4].Action.(*ConditionAction).Condition)
+	choose := got[5].Action.(*ChooseAction)
+	require.IsType(t, &EventAction{}, choose.Choose[0].Sequence[0].Action)
+	require.IsType(t, &StopAction{}, choose.Default[0].Action)
+	require.IsType(t, &SceneAction{}, got[6].Action.(*IfAction).Then[0].Action)
+	require.Equal(t, float64(3), got[7].Action.(*RepeatAction).Repeat.Count)
+	require.IsType(t, &TemplateCondition{}, got[8].Action.(*RepeatAction).Repeat.Until[0].Condition)
+	require.IsType(t, &SequenceAction{}, got[9].Action.(*ParallelAction).Parallel[0].Action)
+	require.IsType(t, &StateTrigger{}, got[10].Action.(*WaitForTriggerAction).WaitForTrigger[0].Trigger)
+
+	var unrecognized ActionSequence
+	require.Error(t, json.Unmarshal([]byte(`[{"bogus": true}]`), &unrecognized))
+}
+
+func TestTriggerUnmarshal(t *testing.T) {
+	input := `{
+		"alias": "modern",
+		"triggers": [
+			{"trigger": "state", "entity_id": ["light.a", "light.b"], "to": "on",
+				"for": {"hours": 0, "minutes": 1, "seconds": 30}, "id": "lights"},
+			{"platform": "time", "at": ["07:00:00", "input_datetime.wake"], "enabled": false},
+			{"trigger": "homeassistant", "event": "start", "variables": {"a": 1}},
+			{"trigger": "tag", "tag_id": "abc"},
+			{"trigger": "calendar", "event": "start", "entity_id": "calendar.x", "offset": "-0:05:00"},
+			{"trigger": "conversation", "command": ["hello", "hi"]},
+			{"trigger": "persistent_notification", "update_type": ["added"]},
+			{"trigger": "numeric_state", "entity_id": "sensor.t", "above": 20, "for": "00:00:10"},
+			{"trigger": "state", "entity_id": "light.a", "from": ["off", "unavailable"], "to": ["on"]}
+		],
+		"conditions": [{"condition": "trigger", "id": "lights"}],
+		"actions": [{"event": "x"}]
+	}`
+
+	var got Automation
+	require.NoError(t, json.Unmarshal([]byte(input), &got))
+	require.True(t, got.PluralKeys)
+	require.Len(t, got.Trigger, 9)
+
+	state := got.Trigger[0]
+	require.Equal(t, "trigger", state.PlatformKey)
+	require.Equal(t, "lights", state.Id)
+	require.Equal(t, []string{"light.a", "light.b"}, state.Trigger.(*StateTrigger).EntityId.Values)
+	require.Equal(t, 90*time.Second, state.Trigger.(*StateTrigger).For.Duration)
+
+	require.Equal(t, "platform", got.Trigger[1].PlatformKey)
+	require.False(t, *got.Trigger[1].Enabled)
+	require.Equal(t, []string{"07:00:00", "input_datetime.wake"}, got.Trigger[1].Trigger.(*TimeTrigger).At.Values)
+	require.Equal(t, "start", got.Trigger[2].Trigger.(*HassTrigger).Event)
+	require.Equal(t, float64(1), got.Trigger[2].Variables["a"])
+	require.IsType(t, &TagTrigger{}, got.Trigger[3].Trigger)
+	require.IsType(t, &CalendarTrigger{}, got.Trigger[4].Trigger)
+	require.Equal(t, []string{"hello", "hi"}, got.Trigger[5].Trigger.(*ConversationTrigger).Command.Values)
+	require.Equal(t, []string{"added"}, got.Trigger[6].Trigger.(*PersistentNotificationTrigger).UpdateType)
+	require.Equal(t, 10*time.Second, got.Trigger[7].Trigger.(*NumericStateTrigger).For.Duration)
+	require.Equal(t, "off, unavailable", got.Trigger[8].Trigger.(*StateTrigger).From.String())
+	require.Equal(t, "on", got.Trigger[8].Trigger.(*StateTrigger).To.String())
+	require.Equal(t, []string{"lights"}, got.Condition[0].Condition.(*TriggerCondition).Id.Values)
+
+	out, err := json.Marshal(got)
+	require.NoError(t, err)
+	var generic map[string]interface{}
+	require.NoError(t, json.Unmarshal(out, &generic))
+	require.Contains(t, generic, "triggers")
+	require.NotContains(t, generic, "trigger")
+	require.Equal(t, "state", generic["triggers"].([]interface{})[0].(map[string]interface{})["trigger"])
+	listed := generic["triggers"].([]interface{})[8].(map[string]interface{})
+	require.Equal(t, []interface{}{"off", "unavailable"}, listed["from"])
+	require.Equal(t, []interface{}{"on"}, listed["to"])
+}
+
+func TestDuration(t *testing.T) {
+	for input, want := range map[string]time.Duration{
+		`5`:                                  5 * time.Second,
+		`1.5`:                                1500 * time.Millisecond,
+		`"00:05"`:                            5 * time.Minute,
+		`"01:02:03"`:                         time.Hour + 2*time.Minute + 3*time.Second,
+		`"00:00:01.5"`:                       1500 * time.Millisecond,
+		`"-00:01:00"`:                        -time.Minute,
+		`{"minutes": 2, "milliseconds": 10}`: 2*time.Minute + 10*time.Millisecond,
+	} {
+		var d Duration
+		require.NoError(t, json.Unmarshal([]byte(input), &d), input)
+		require.Equal(t, want, d.Duration, input)
+
+		out, err := json.Marshal(d)
+		require.NoError(t, err)
+		require.JSONEq(t, input, string(out))
+	}
+
+	var d Duration
+	require.NoError(t, json.Unmarshal([]byte(`"{{ states('input_number.x') }}"`), &d))
+	require.True(t, d.IsTemplate())
+	require.Error(t, json.Unmarshal([]byte(`"soon"`), &d))
+
+	out, err := json.Marshal(DurationOf(90*time.Second + 250*time.Millisecond))
+	require.NoError(t, err)
+	require.Equal(t, `"00:01:30.25"`, string(out))
+}
+
+func TestUnknownFieldsPreserved(t *testing.T) {
+	input := `{
+		"trigger": [{"platform": "mqtt", "topic": "a/b", "qos": 1}],
+		"condition": [{"condition": "state", "entity_id": "sun.sun", "state": "above_horizon", "note": "kept"}],
+		"action": [{"action": "light.turn_on", "metadata": {}, "target": {"entity_id": "light.a"}}],
+		"max": 3
+	}`
+
+	var got Automation
+	require.NoError(t, json.Unmarshal([]byte(input), &got))
+	require.Equal(t, map[string]interface{}{"max": float64(3)}, got.Extra)
+	require.Equal(t, map[string]interface{}{"qos": float64(1)}, got.Trigger[0].Extra)
+	require.Equal(t, map[string]interface{}{"note": "kept"}, got.Condition[0].Extra)
+	require.Equal(t, "above_horizon", got.Condition[0].Condition.(*StateCondition).State.String())
+	require.Equal(t, map[string]interface{}{"metadata": map[string]interface{}{}}, got.Action[0].Extra)
+
+	out, err := json.Marshal(got.Trigger[0])
+	require.NoError(t, err)
+	require.JSONEq(t, `{"platform": "mqtt", "topic": "a/b", "qos": 1}`, string(out))
+}
+
+// TestAutomationRoundTrip checks that each automation in testdata/automations, which are kept in canonical form (as
+// json.MarshalIndent would write them), is encoded back to exactly the same bytes it was decoded from. See
+// TestAutomationRoundTrip_HomeAssistant for automations as homeassistant writes them.
+func TestAutomationRoundTrip(t *testing.T) {
+	files, err := filepath.Glob("testdata/automations/*.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			want, err := os.ReadFile(file)
+			require.NoError(t, err)
+
+			var automation Automation
+			require.NoError(t, json.Unmarshal(want, &automation))
+
+			got, err := json.MarshalIndent(automation, "", "  ")
+			require.NoError(t, err)
+			require.Equal(t, string(want), string(got)+"\n")
+		})
+	}
+}
+
+// TestAutomationRoundTrip_HomeAssistant checks that each automation in testdata/automations/homeassistant, which are
+// as homeassistant's config API returns them, survives decoding and encoding, as JSON and as YAML. Keys are not kept in
+// the order homeassistant writes them, so the result is compared as JSON values rather than bytes; everything else,
+// such as whether a value was a single item or a list, must be the same.
+func TestAutomationRoundTrip_HomeAssistant(t *testing.T) {
+	files, err := filepath.Glob("testdata/automations/homeassistant/*.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			want, err := os.ReadFile(file)
+			require.NoError(t, err)
+
+			var automation Automation
+			require.NoError(t, json.Unmarshal(want, &automation))
+			require.NoError(t, automation.Validate())
+
+			got, err := json.Marshal(automation)
+			require.NoError(t, err)
+			require.JSONEq(t, string(want), string(got))
+
+			yb, err := EncodeYAML(&automation)
+			require.NoError(t, err)
+			var decoded Automation
+			require.NoError(t, yaml.Unmarshal(yb, &decoded), string(yb))
+			got, err = json.Marshal(decoded)
+			require.NoError(t, err)
+			require.JSONEq(t, string(want), string(got), string(yb))
+		})
+	}
+}
+
+func TestAutomationMarshal_Incomplete(t *testing.T) {
+	_, err := json.Marshal(AutomationTrigger{})
+	require.Error(t, err)
+	_, err = json.Marshal(AutomationCondition{})
+	require.Error(t, err)
+	_, err = json.Marshal(AutomationAction{Action: &ConditionAction{}})
+	require.Error(t, err)
+	_, err = EncodeYAML(&Automation{Trigger: TriggerList{{}}})
+	require.Error(t, err)
+}
+
+func TestListAutomations(t *testing.T) {
+	c := fakeWebsocket(t, func(request map[string]interface{}) (interface{}, error) {
+		require.Equal(t, "get_states", request["type"])
+		return []interface{}{
+			map[string]interface{}{"entity_id": "automation.porch", "state": "on", "attributes": map[string]interface{}{
+				"id": "1600000000000", "friendly_name": "Porch", "mode": "single", "current": float64(1),
+				"last_triggered": "2024-01-02T03:04:05Z",
+			}},
+			map[string]interface{}{"entity_id": "automation.yaml_only", "state": "off",
+				"attributes": map[string]interface{}{"friendly_name": "YAML Only"}},
+			map[string]interface{}{"entity_id": "light.porch", "state": "on"},
+		}, nil
+	})
+
+	automations, err := c.ListAutomations()
+	require.NoError(t, err)
+	require.Equal(t, []AutomationListEntry{
+		{FriendlyName: "Porch", Id: "1600000000000", EntityId: "automation.porch", State: "on", Mode: "single",
+			Current: 1, LastTriggered: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{FriendlyName: "YAML Only", EntityId: "automation.yaml_only", State: "off"},
+	}, automations)
+
+	tests := []struct {
+		id      AutomationId
+		want    string
+		wantErr bool
+	}{
+		{"1600000000000", "automation.porch", false},
+		{"automation.anything", "automation.anything", false},
+		{"nonexistent", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.id), func(t *testing.T) {
+			entityId, err := c.AutomationEntityId(tt.id)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, entityId)
+		})
+	}
+}
+
+func TestAutomationServices(t *testing.T) {
+	var calls []string
+	var bodies []map[string]interface{}
+	c := fakeServer(t, func(request map[string]interface{}) (interface{}, error) {
+		return []interface{}{
+			map[string]interface{}{"entity_id": "automation.porch", "state": "on",
+				"attributes": map[string]interface{}{"id": "1600000000000"}},
+		}, nil
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		bodies = append(bodies, body)
+		_, _ = w.Write([]byte(`[]`))
+	})
+
+	tests := []struct {
+		name string
+		call func() error
+		path string
+		body map[string]interface{}
+	}{
+		{"trigger", func() error { return c.TriggerAutomation("1600000000000", true) },
+			"POST /api/services/automation/trigger",
+			map[string]interface{}{"entity_id": "automation.porch", "skip_condition": true}},
+		{"enable", func() error { return c.EnableAutomation("1600000000000") },
+			"POST /api/services/automation/turn_on", map[string]interface{}{"entity_id": "automation.porch"}},
+		{"disable", func() error { return c.DisableAutomation("automation.porch") },
+			"POST /api/services/automation/turn_off", map[string]interface{}{"entity_id": "automation.porch"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls, bodies = nil, nil
+			require.NoError(t, tt.call())
+			require.Equal(t, []string{tt.path}, calls)
+			require.Equal(t, []map[string]interface{}{tt.body}, bodies)
+		})
+	}
+
+	require.Error(t, c.EnableAutomation("nonexistent"))
+}
@@ -2,31 +2,87 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
-	"strings"
-	"time"
 )
 
+// AutomationTrigger is a single trigger of an automation. Trigger holds the platform-specific part of the trigger; the
+// remaining fields are accepted by every platform. Extra holds any keys not otherwise understood, so that they survive
+// being decoded and re-encoded.
 type AutomationTrigger struct {
 	Trigger
+	// Id identifies the trigger, so that conditions and actions can tell which trigger fired.
+	Id        string
+	Alias     string
+	Enabled   *bool
+	Variables map[string]interface{}
+	Extra     map[string]interface{}
+	// PlatformKey is the key that names the platform: `platform` (the default), or `trigger` as written by newer
+	// versions of homeassistant.
+	PlatformKey string
+	// single records that the trigger was written by itself, in place of a list.
+	single bool
 }
 
-func (a AutomationTrigger) MarshalJSON() ([]byte, error) {
-	return json.Marshal(triggerToMap(a.Trigger))
+// TriggerList is a list of triggers, any of which may fire. Like homeassistant, it accepts a single trigger in place
+// of a list, and is encoded the same way.
+type TriggerList []AutomationTrigger
+
+func (l *TriggerList) UnmarshalJSON(data []byte) error {
+	if isJSONList(data) {
+		var list []AutomationTrigger
+		if err := json.Unmarshal(data, &list); err != nil {
+			return err
+		}
+		*l = list
+		return nil
+	}
+
+	var single AutomationTrigger
+	if err := json.Unmarshal(data, &single); err != nil {
+		return err
+	}
+	single.single = true
+	*l = TriggerList{single}
+	return nil
 }
 
-func triggerToMap(t Trigger) map[string]interface{} {
-	ret := map[string]interface{}{
-		"platform": t.Platform(),
+func (l TriggerList) MarshalJSON() ([]byte, error) {
+	if len(l) == 1 && l[0].single {
+		return json.Marshal(l[0])
+	}
+	return json.Marshal([]AutomationTrigger(l))
+}
+
+var _ json.Marshaler = TriggerList{}
+var _ json.Unmarshaler = (*TriggerList)(nil)
+
+type triggerCommon struct {
+	Id        string                 `json:"id,omitempty"`
+	Alias     string                 `json:"alias,omitempty"`
+	Enabled   *bool                  `json:"enabled,omitempty"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+func (a AutomationTrigger) MarshalJSON() ([]byte, error) {
+	if a.Trigger == nil {
+		return nil, errors.New("trigger has no platform")
 	}
-	typ := reflect.TypeOf(t).Elem()
-	val := reflect.ValueOf(t).Elem()
-	for fieldIdx := 0; fieldIdx < typ.NumField(); fieldIdx++ {
-		name := strings.Split(typ.Field(fieldIdx).Tag.Get("json"), ",")[0]
-		ret[name] = val.Field(fieldIdx).Interface()
+	ret := mergeFields(
+		a.Extra,
+		triggerToMap(a.Trigger),
+		fieldsToMap(&triggerCommon{a.Id, a.Alias, a.Enabled, a.Variables}))
+	if a.PlatformKey != "" && a.PlatformKey != "platform" {
+		ret[a.PlatformKey] = ret["platform"]
+		delete(ret, "platform")
 	}
+	return json.Marshal(ret)
+}
 
+func triggerToMap(t Trigger) map[string]interface{} {
+	ret := fieldsToMap(t)
+	ret["platform"] = t.Platform()
 	return ret
 }
 
@@ -35,12 +91,36 @@ func (a *AutomationTrigger) UnmarshalJSON(data []byte) error {
 	if err := json.Unmarshal(data, &generic); err != nil {
 		return err
 	}
-	trigger, err := triggerFromMap(generic)
+
+	platformKey := "platform"
+	if _, ok := generic["platform"]; !ok {
+		if _, ok := generic["trigger"]; ok {
+			platformKey = "trigger"
+			generic["platform"] = generic["trigger"]
+			delete(generic, "trigger")
+		}
+	}
+
+	trigger, used, err := triggerFromMap(generic)
 	if err != nil {
 		return err
 	}
 
-	a.Trigger = trigger
+	var common triggerCommon
+	commonUsed, err := fieldsFromMap("trigger", generic, &common)
+	if err != nil {
+		return fmt.Errorf("trigger %q: %w", string(data), err)
+	}
+
+	*a = AutomationTrigger{
+		Trigger:     trigger,
+		Id:          common.Id,
+		Alias:       common.Alias,
+		Enabled:     common.Enabled,
+		Variables:   common.Variables,
+		Extra:       extraFields(generic, mergeUsed(used, commonUsed), "platform"),
+		PlatformKey: platformKey,
+	}
 	return nil
 }
 
@@ -51,10 +131,11 @@ type Trigger interface {
 	Platform() string
 }
 
-func triggerFromMap(generic map[string]interface{}) (Trigger, error) {
+// triggerFromMap decodes the platform-specific part of a trigger, returning it along with the keys it consumed.
+func triggerFromMap(generic map[string]interface{}) (Trigger, map[string]bool, error) {
 	platformName, ok := generic["platform"]
 	if !ok {
-		return nil, fmt.Errorf("trigger %v did not have `platform` key", generic)
+		return nil, nil, fmt.Errorf("trigger %v did not have `platform` or `trigger` key", generic)
 	}
 
 	var prototype Trigger
@@ -73,6 +154,10 @@ candidates:
 		(*TemplateTrigger)(nil),
 		(*EventTrigger)(nil),
 		(*DeviceTrigger)(nil),
+		(*TagTrigger)(nil),
+		(*CalendarTrigger)(nil),
+		(*ConversationTrigger)(nil),
+		(*PersistentNotificationTrigger)(nil),
 	} {
 		if platformName == candidate.Platform() {
 			prototype = candidate
@@ -80,85 +165,32 @@ candidates:
 		}
 	}
 	if prototype == nil {
-		return nil, fmt.Errorf("no match for trigger platform %q", platformName)
+		return nil, nil, fmt.Errorf("no match for trigger platform %q", platformName)
 	}
 
-	typ := reflect.TypeOf(prototype).Elem()
-	trigger := reflect.New(typ)
-
-	for fieldIdx := 0; fieldIdx < typ.NumField(); fieldIdx++ {
-		targetField := trigger.Elem().Field(fieldIdx)
-		fieldName := strings.Split(typ.Field(fieldIdx).Tag.Get("json"), ",")[0]
-		valueToAssign, ok := generic[fieldName]
-		if !ok {
-			continue
-		}
-		switch trigger.Elem().Field(fieldIdx).Interface().(type) {
-		case StringOrFloat:
-			switch v := valueToAssign.(type) {
-			case string:
-				targetField.Set(reflect.ValueOf(StringOrIntFromString(v)))
-			case float64:
-				targetField.Set(reflect.ValueOf(StringOrIntFromFloat64(v)))
-			default:
-				return nil, fmt.Errorf("%s attribute was %T, not string or int", fieldName, valueToAssign)
-			}
-		case time.Duration:
-			switch v := valueToAssign.(type) {
-			case string:
-				dur, err := time.ParseDuration(v)
-				if err != nil {
-					return nil, fmt.Errorf("%s attribute %q could not be parsed as duration: %w", fieldName, v, err)
-				}
-				targetField.Set(reflect.ValueOf(dur))
-			case float64:
-				targetField.Set(reflect.ValueOf(time.Duration(v) * time.Second))
-			case time.Duration:
-				targetField.Set(reflect.ValueOf(v))
-			default:
-				return nil, fmt.Errorf("%s attribute was expected to be string, float, or time.Duration, but "+
-					"was %T instead", fieldName, v)
-			}
-		case time.Time:
-			switch v := valueToAssign.(type) {
-			case time.Time:
-				targetField.Set(reflect.ValueOf(v))
-			case string:
-				t, err := time.Parse(time.RFC3339, v)
-				if err != nil {
-					return nil, fmt.Errorf("%s attribute %q could not be parsed as time: %w", fieldName, v, err)
-				}
-				targetField.Set(reflect.ValueOf(t))
-			default:
-				return nil, fmt.Errorf("%s attribute was expected to be RFC3339 string or time.Time, but "+
-					"was %T", fieldName, v)
-			}
-		case interface{}:
-			targetField.Set(reflect.ValueOf(valueToAssign))
-		default:
-			if reflect.TypeOf(valueToAssign) != targetField.Type() {
-				return nil, fmt.Errorf("%q expects %s for field %s, but input had %s", platformName,
-					reflect.TypeOf(targetField), fieldName, reflect.TypeOf(valueToAssign))
-			}
-			targetField.Set(reflect.ValueOf(valueToAssign))
-		}
+	trigger := reflect.New(reflect.TypeOf(prototype).Elem()).Interface()
+	used, err := fieldsFromMap(prototype.Platform(), generic, trigger)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return trigger.Interface().(Trigger), nil
+	return trigger.(Trigger), used, nil
 }
 
 type StateTrigger struct {
-	EntityId string        `json:"entity_id,omitempty"`
-	From     StringOrFloat `json:"from"`
-	To       StringOrFloat `json:"to"`
-	For      time.Duration `json:"for"`
+	EntityId  StringList `json:"entity_id,omitempty"`
+	Attribute string     `json:"attribute,omitempty"`
+	From      StateList  `json:"from,omitempty"`
+	To        StateList  `json:"to,omitempty"`
+	For       Duration   `json:"for,omitempty"`
 }
 
 func (*StateTrigger) Platform() string { return "state" }
 
 type MqttTrigger struct {
-	Topic   string `json:"topic"`
-	Payload string `json:"payload,omitempty"`
+	Topic         string `json:"topic"`
+	Payload       string `json:"payload,omitempty"`
+	ValueTemplate string `json:"value_template,omitempty"`
 }
 
 func (*MqttTrigger) Platform() string { return "mqtt" }
@@ -171,33 +203,37 @@ type GeoLocationTrigger struct {
 
 func (*GeoLocationTrigger) Platform() string { return "geo_location" }
 
+// HassTrigger fires when homeassistant starts or shuts down; Event is `start` or `shutdown`.
 type HassTrigger struct {
 	Event string `json:"event"`
 }
 
 func (*HassTrigger) Platform() string { return "homeassistant" }
 
+// NumericStateTrigger fires when the entity's state (or Attribute, or ValueTemplate) crosses into the bounds. Above
+// and Below may each be a number or the entity ID of a numeric entity.
 type NumericStateTrigger struct {
-	EntityId      string        `json:"entity_id"`
-	Above         float64       `json:"above"`
-	Below         float64       `json:"below"`
-	ValueTemplate string        `json:"value_template"`
-	For           time.Duration `json:"for"`
+	EntityId      StringList    `json:"entity_id"`
+	Attribute     string        `json:"attribute,omitempty"`
+	Above         StringOrFloat `json:"above,omitempty"`
+	Below         StringOrFloat `json:"below,omitempty"`
+	ValueTemplate string        `json:"value_template,omitempty"`
+	For           Duration      `json:"for,omitempty"`
 }
 
 func (*NumericStateTrigger) Platform() string { return "numeric_state" }
 
 type SunTrigger struct {
-	Offset float64 `json:"offset"`
-	Event  string  `json:"event"`
+	Offset Duration `json:"offset,omitempty"`
+	Event  string   `json:"event"`
 }
 
 func (*SunTrigger) Platform() string { return "sun" }
 
 type TimePatternTrigger struct {
-	Hours   StringOrFloat `json:"hours"`
-	Minutes StringOrFloat `json:"minutes"`
-	Seconds StringOrFloat `json:"seconds"`
+	Hours   StringOrFloat `json:"hours,omitempty"`
+	Minutes StringOrFloat `json:"minutes,omitempty"`
+	Seconds StringOrFloat `json:"seconds,omitempty"`
 }
 
 func (*TimePatternTrigger) Platform() string { return "time_pattern" }
@@ -209,16 +245,16 @@ type WebhookTrigger struct {
 func (*WebhookTrigger) Platform() string { return "webhook" }
 
 type ZoneTrigger struct {
-	EntityId string `json:"entity_id"`
-	Zone     string `json:"zone"`
-	Event    string `json:"event"`
+	EntityId StringList `json:"entity_id"`
+	Zone     string     `json:"zone"`
+	Event    string     `json:"event,omitempty"`
 }
 
 func (*ZoneTrigger) Platform() string { return "zone" }
 
 type TimeTrigger struct {
-	// A time like HH:MM:SS, 24-hour time.
-	At string `json:"at"`
+	// Times like HH:MM:SS, 24-hour time, or the entity IDs of input_datetime or timestamp sensor entities.
+	At StringList `json:"at"`
 }
 
 func (*TimeTrigger) Platform() string { return "time" }
@@ -230,8 +266,10 @@ type TemplateTrigger struct {
 func (*TemplateTrigger) Platform() string { return "template" }
 
 type EventTrigger struct {
-	EventType string      `json:"event_type"`
-	EventData interface{} `json:"event_data"`
+	EventType StringList             `json:"event_type"`
+	EventData map[string]interface{} `json:"event_data,omitempty"`
+	// Context limits the trigger to events fired by the given user_id, parent_id, or context_id.
+	Context map[string]interface{} `json:"context,omitempty"`
 }
 
 func (*EventTrigger) Platform() string { return "event" }
@@ -239,10 +277,44 @@ func (*EventTrigger) Platform() string { return "event" }
 type DeviceTrigger struct {
 	DeviceId string `json:"device_id"`
 	Domain   string `json:"domain"`
-	EntityId string `json:"entity_id"`
+	EntityId string `json:"entity_id,omitempty"`
 	Type     string `json:"type,omitempty"`
 	Subtype  string `json:"subtype,omitempty"`
 	Event    string `json:"event,omitempty"`
 }
 
 func (*DeviceTrigger) Platform() string { return "device" }
+
+// TagTrigger fires when an NFC tag or QR code is scanned.
+type TagTrigger struct {
+	TagId    string     `json:"tag_id"`
+	DeviceId StringList `json:"device_id,omitempty"`
+}
+
+func (*TagTrigger) Platform() string { return "tag" }
+
+// CalendarTrigger fires at the start or end of a calendar event; Event is `start` or `end`, and Offset is a signed
+// HH:MM:SS offset from that time.
+type CalendarTrigger struct {
+	Event    string `json:"event"`
+	EntityId string `json:"entity_id"`
+	Offset   string `json:"offset,omitempty"`
+}
+
+func (*CalendarTrigger) Platform() string { return "calendar" }
+
+// ConversationTrigger fires when a sentence matching one of Command is spoken or typed to the assist pipeline.
+type ConversationTrigger struct {
+	Command StringList `json:"command"`
+}
+
+func (*ConversationTrigger) Platform() string { return "conversation" }
+
+// PersistentNotificationTrigger fires when a persistent notification is added, removed, or updated. UpdateType lists
+// which of `added`, `removed`, `current`, and `updated` to fire on.
+type PersistentNotificationTrigger struct {
+	NotificationId string   `json:"notification_id,omitempty"`
+	UpdateType     []string `json:"update_type,omitempty"`
+}
+
+func (*PersistentNotificationTrigger) Platform() string { return "persistent_notification" }
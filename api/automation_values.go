@@ -0,0 +1,283 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Duration is a length of time as homeassistant accepts it in automation config: a number of seconds, an
+// "HH:MM[:SS[.FFF]]" string, or a map of units (days, hours, minutes, seconds, milliseconds). Templates are accepted in
+// place of any value; such durations cannot be known ahead of time, so IsTemplate is true and Duration is zero. The
+// same goes for a blueprint `!input`, except that IsTemplate is false.
+//
+// The form a Duration was decoded from is retained, and is re-used when encoding unless Duration has since changed.
+type Duration struct {
+	time.Duration
+	raw    interface{}
+	parsed time.Duration
+}
+
+// DurationOf returns a Duration of the given length.
+func DurationOf(d time.Duration) Duration {
+	return Duration{Duration: d}
+}
+
+// DurationFromTemplate returns a Duration whose length is given by the given template.
+func DurationFromTemplate(template string) Duration {
+	return Duration{raw: template}
+}
+
+// IsTemplate returns true if the length of the duration is determined by a template.
+func (d Duration) IsTemplate() bool {
+	switch raw := d.raw.(type) {
+	case string:
+		return isTemplate(raw)
+	case map[string]interface{}:
+		for _, v := range raw {
+			if s, ok := v.(string); ok && isTemplate(s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// tagged returns true if the duration is a tagged YAML scalar, such as a blueprint `!input`; like a template, its
+// length cannot be known ahead of time.
+func (d Duration) tagged() bool {
+	raw, ok := d.raw.(string)
+	return ok && isYAMLTag(raw)
+}
+
+func isTemplate(s string) bool {
+	return strings.Contains(s, "{{") || strings.Contains(s, "{%")
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	parsed, err := parseDuration(raw)
+	if err != nil {
+		return err
+	}
+
+	*d = Duration{Duration: parsed, raw: raw, parsed: parsed}
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	if d.raw != nil && d.Duration == d.parsed {
+		return json.Marshal(d.raw)
+	}
+	return json.Marshal(formatDuration(d.Duration))
+}
+
+var _ json.Marshaler = Duration{}
+var _ json.Unmarshaler = (*Duration)(nil)
+
+func parseDuration(raw interface{}) (time.Duration, error) {
+	switch v := raw.(type) {
+	case float64:
+		return time.Duration(v * float64(time.Second)), nil
+	case string:
+		if isTemplate(v) || isYAMLTag(v) {
+			return 0, nil
+		}
+		if seconds, err := strconv.ParseFloat(v, 64); err == nil {
+			return time.Duration(seconds * float64(time.Second)), nil
+		}
+
+		negative := strings.HasPrefix(v, "-")
+		parts := strings.Split(strings.TrimPrefix(v, "-"), ":")
+		if len(parts) < 2 || len(parts) > 3 {
+			return 0, fmt.Errorf("duration %q is not HH:MM or HH:MM:SS", v)
+		}
+		var ret time.Duration
+		for i, unit := range []time.Duration{time.Hour, time.Minute, time.Second}[:len(parts)] {
+			n, err := strconv.ParseFloat(parts[i], 64)
+			if err != nil || (i < 2 && n != math.Trunc(n)) {
+				return 0, fmt.Errorf("duration %q is not HH:MM or HH:MM:SS", v)
+			}
+			ret += time.Duration(n * float64(unit))
+		}
+		if negative {
+			ret = -ret
+		}
+		return ret, nil
+	case map[string]interface{}:
+		units := map[string]time.Duration{
+			"days":         24 * time.Hour,
+			"hours":        time.Hour,
+			"minutes":      time.Minute,
+			"seconds":      time.Second,
+			"milliseconds": time.Millisecond,
+		}
+		var ret time.Duration
+		for k, value := range v {
+			unit, ok := units[k]
+			if !ok {
+				return 0, fmt.Errorf("duration has unknown unit %q", k)
+			}
+			var n float64
+			switch value := value.(type) {
+			case float64:
+				n = value
+			case string:
+				if isTemplate(value) || isYAMLTag(value) {
+					continue
+				}
+				var err error
+				n, err = strconv.ParseFloat(value, 64)
+				if err != nil {
+					return 0, fmt.Errorf("duration %s %q is not a number", k, value)
+				}
+			default:
+				return 0, fmt.Errorf("duration %s was %T, not a number", k, value)
+			}
+			ret += time.Duration(n * float64(unit))
+		}
+		return ret, nil
+	default:
+		return 0, fmt.Errorf("duration was %T, not number, string, or map", raw)
+	}
+}
+
+// formatDuration renders d in HH:MM:SS form, with fractional seconds if needed.
+func formatDuration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+	h := d / time.Hour
+	m := (d % time.Hour) / time.Minute
+	s := d % time.Minute
+	if s%time.Second == 0 {
+		return fmt.Sprintf("%s%02d:%02d:%02d", sign, h, m, s/time.Second)
+	}
+	frac := strings.TrimRight(fmt.Sprintf("%09d", s%time.Second), "0")
+	return fmt.Sprintf("%s%02d:%02d:%02d.%s", sign, h, m, s/time.Second, frac)
+}
+
+// StringList is a value homeassistant accepts as either a single string or a list of strings, such as entity_id. The
+// form it was decoded from is retained for encoding.
+type StringList struct {
+	Values []string
+	list   bool
+}
+
+// StringListOf returns a StringList of the given values. It is encoded as a single string if exactly one value is
+// given, and as a list otherwise.
+func StringListOf(values ...string) StringList {
+	return StringList{Values: values}
+}
+
+func (s *StringList) UnmarshalJSON(data []byte) error {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	switch v := raw.(type) {
+	case string:
+		*s = StringList{Values: []string{v}}
+	case []interface{}:
+		ret := StringList{Values: make([]string, 0, len(v)), list: true}
+		for i, item := range v {
+			str, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("list entry %d was %T, not string", i, item)
+			}
+			ret.Values = append(ret.Values, str)
+		}
+		*s = ret
+	default:
+		return fmt.Errorf("expected string or list of strings, but got %T", raw)
+	}
+	return nil
+}
+
+func (s StringList) MarshalJSON() ([]byte, error) {
+	if len(s.Values) == 1 && !s.list {
+		return json.Marshal(s.Values[0])
+	}
+	if s.Values == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(s.Values)
+}
+
+var _ json.Marshaler = StringList{}
+var _ json.Unmarshaler = (*StringList)(nil)
+
+// StateList is a value homeassistant accepts as either a single state or a list of states, each a string or a number,
+// such as a state trigger's `to` or a state condition's `state`. The form it was decoded from is retained for encoding.
+type StateList struct {
+	Values []StringOrFloat
+	list   bool
+}
+
+// StateListOf returns a StateList of the given states. It is encoded as a single value if exactly one state is given,
+// and as a list otherwise.
+func StateListOf(values ...string) StateList {
+	ret := StateList{}
+	for _, v := range values {
+		ret.Values = append(ret.Values, StringOrIntFromString(v))
+	}
+	return ret
+}
+
+// IsSet returns true if at least one state was given.
+func (s StateList) IsSet() bool {
+	return len(s.Values) > 0
+}
+
+// String returns the states, separated by commas.
+func (s StateList) String() string {
+	var ret []string
+	for _, v := range s.Values {
+		ret = append(ret, v.String())
+	}
+	return strings.Join(ret, ", ")
+}
+
+func (s *StateList) UnmarshalJSON(data []byte) error {
+	if !isJSONList(data) {
+		var single StringOrFloat
+		if err := json.Unmarshal(data, &single); err != nil {
+			return err
+		}
+		*s = StateList{Values: []StringOrFloat{single}}
+		return nil
+	}
+
+	var list []StringOrFloat
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	if list == nil {
+		list = []StringOrFloat{}
+	}
+	*s = StateList{Values: list, list: true}
+	return nil
+}
+
+func (s StateList) MarshalJSON() ([]byte, error) {
+	if len(s.Values) == 1 && !s.list {
+		return json.Marshal(s.Values[0])
+	}
+	if s.Values == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(s.Values)
+}
+
+var _ json.Marshaler = StateList{}
+var _ json.Unmarshaler = (*StateList)(nil)
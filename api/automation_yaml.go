@@ -0,0 +1,409 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The automation types are modeled on their JSON representation; YAML is supported by converting to and from the
+// same generic values, with the keys of each mapping laid out the way the homeassistant editor shows them.
+//
+// homeassistant's YAML loader has a few local tags, like `!input motion_sensor` in blueprints or `!secret api_key`.
+// These have no JSON equivalent, so a tagged scalar is carried through the model as a string of the same form (tag,
+// space, value), and written back out as a tagged scalar.
+
+// yamlTags are the local tags understood by homeassistant's YAML loader.
+var yamlTags = []string{
+	"!input",
+	"!secret",
+	"!env_var",
+	"!include",
+	"!include_dir_list",
+	"!include_dir_named",
+	"!include_dir_merge_list",
+	"!include_dir_merge_named",
+}
+
+// splitYAMLTag returns the tag and value of a string that stands in for a tagged YAML scalar, or ok = false if s is an
+// ordinary string.
+func splitYAMLTag(s string) (tag string, value string, ok bool) {
+	for _, t := range yamlTags {
+		if s == t {
+			return t, "", true
+		}
+		if strings.HasPrefix(s, t+" ") {
+			return t, s[len(t)+1:], true
+		}
+	}
+	return "", "", false
+}
+
+// isYAMLTag returns true if s stands in for a tagged YAML scalar; like a template, its value cannot be known ahead of
+// time.
+func isYAMLTag(s string) bool {
+	_, _, ok := splitYAMLTag(s)
+	return ok
+}
+
+// EncodeYAML encodes v as YAML with two-space indentation, as homeassistant writes it.
+func EncodeYAML(v interface{}) ([]byte, error) {
+	node, err := yamlNode(v)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeYAML decodes data to the values encoding/json would decode into an interface{}, keeping tags as EncodeYAML
+// expects them.
+func DecodeYAML(data []byte) (interface{}, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	var ret interface{}
+	if err := unmarshalYAMLViaJSON(&node, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// unmarshalYAMLViaJSON decodes node into obj by converting it to generic values and decoding those as JSON.
+func unmarshalYAMLViaJSON(node *yaml.Node, obj interface{}) error {
+	generic, err := yamlToGeneric(node)
+	if err != nil {
+		return err
+	}
+	jb, err := json.Marshal(generic)
+	if err != nil {
+		return fmt.Errorf("converting YAML to JSON: %w", err)
+	}
+	return json.Unmarshal(jb, obj)
+}
+
+// yamlToGeneric converts node to the values encoding/json would decode into an interface{}, with mapping keys as
+// strings and tagged scalars as strings.
+func yamlToGeneric(node *yaml.Node) (interface{}, error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return yamlToGeneric(node.Content[0])
+	case yaml.AliasNode:
+		return yamlToGeneric(node.Alias)
+	case yaml.SequenceNode:
+		ret := make([]interface{}, 0, len(node.Content))
+		for _, item := range node.Content {
+			v, err := yamlToGeneric(item)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, v)
+		}
+		return ret, nil
+	case yaml.MappingNode:
+		ret := map[string]interface{}{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			v, err := yamlToGeneric(value)
+			if err != nil {
+				return nil, err
+			}
+			if key.Tag == "!!merge" {
+				merged, ok := v.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("line %d: merge key must refer to a mapping", key.Line)
+				}
+				for mk, mv := range merged {
+					if _, ok := ret[mk]; !ok {
+						ret[mk] = mv
+					}
+				}
+				continue
+			}
+			if key.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("line %d: mapping keys must be scalars", key.Line)
+			}
+			ret[key.Value] = v
+		}
+		return ret, nil
+	case yaml.ScalarNode:
+		if strings.HasPrefix(node.Tag, "!") && !strings.HasPrefix(node.Tag, "!!") {
+			if node.Value == "" {
+				return node.Tag, nil
+			}
+			return node.Tag + " " + node.Value, nil
+		}
+		var ret interface{}
+		if err := node.Decode(&ret); err != nil {
+			return nil, err
+		}
+		return ret, nil
+	}
+	return nil, fmt.Errorf("line %d: unsupported YAML node", node.Line)
+}
+
+// yamlNode converts v to a YAML node. Values with their own MarshalYAML are used as-is; those with only MarshalJSON
+// are converted via their JSON representation; structs become mappings with keys in field order, as fieldsToMap would
+// name them; and maps become mappings with sorted keys.
+func yamlNode(v interface{}) (*yaml.Node, error) {
+	if v == nil {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	}
+
+	switch value := v.(type) {
+	case *yaml.Node:
+		return value, nil
+	case yaml.Marshaler:
+		marshaled, err := value.MarshalYAML()
+		if err != nil {
+			return nil, err
+		}
+		return yamlNode(marshaled)
+	case json.Marshaler:
+		jb, err := value.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		var generic interface{}
+		if err := json.Unmarshal(jb, &generic); err != nil {
+			return nil, err
+		}
+		return yamlNode(generic)
+	case string:
+		if tag, tagValue, ok := splitYAMLTag(value); ok {
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: tagValue}, nil
+		}
+	}
+
+	val := reflect.ValueOf(v)
+	switch val.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if val.IsNil() {
+			return yamlNode(nil)
+		}
+		return yamlNode(val.Elem().Interface())
+	case reflect.Struct:
+		return yamlMapping(fieldsToMap(v), fieldNames(v))
+	case reflect.Slice, reflect.Array:
+		if val.Kind() == reflect.Slice && val.IsNil() {
+			return &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}, nil
+		}
+		ret := &yaml.Node{Kind: yaml.SequenceNode}
+		for i := 0; i < val.Len(); i++ {
+			item, err := yamlNode(val.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			ret.Content = append(ret.Content, item)
+		}
+		return ret, nil
+	case reflect.Map:
+		generic := map[string]interface{}{}
+		iter := val.MapRange()
+		for iter.Next() {
+			generic[fmt.Sprint(iter.Key().Interface())] = iter.Value().Interface()
+		}
+		return yamlMapping(generic)
+	}
+
+	ret := &yaml.Node{}
+	if err := ret.Encode(v); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// yamlMapping converts m to a YAML mapping. Keys named in each of order come first, in that order; the remainder
+// follow in sorted order. An empty mapping is written in flow style, i.e. `{}`.
+func yamlMapping(m map[string]interface{}, order ...[]string) (*yaml.Node, error) {
+	var keys []string
+	seen := map[string]bool{}
+	for _, o := range order {
+		for _, k := range o {
+			if _, ok := m[k]; ok && !seen[k] {
+				keys = append(keys, k)
+				seen[k] = true
+			}
+		}
+	}
+	var rest []string
+	for k := range m {
+		if !seen[k] {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	keys = append(keys, rest...)
+
+	ret := &yaml.Node{Kind: yaml.MappingNode}
+	if len(keys) == 0 {
+		ret.Style = yaml.FlowStyle
+	}
+	for _, k := range keys {
+		value, err := yamlNode(m[k])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+		ret.Content = append(ret.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k}, value)
+	}
+	return ret, nil
+}
+
+// fieldNames returns the JSON names of the fields of the struct pointed to by obj, in the order they are declared.
+func fieldNames(obj interface{}) []string {
+	typ := reflect.Indirect(reflect.ValueOf(obj)).Type()
+	var ret []string
+	for fieldIdx := 0; fieldIdx < typ.NumField(); fieldIdx++ {
+		field := typ.Field(fieldIdx)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		ret = append(ret, name)
+	}
+	return ret
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys(m map[string]interface{}) []string {
+	ret := make([]string, 0, len(m))
+	for k := range m {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func (a Automation) MarshalYAML() (interface{}, error) {
+	return yamlMapping(a.toMap(),
+		[]string{"id", "alias", "description", "use_blueprint"},
+		[]string{"trigger", "triggers", "condition", "conditions", "action", "actions"},
+		[]string{"mode", "max", "max_exceeded"})
+}
+
+func (a *Automation) UnmarshalYAML(node *yaml.Node) error {
+	return unmarshalYAMLViaJSON(node, a)
+}
+
+func (a AutomationTrigger) MarshalYAML() (interface{}, error) {
+	if a.Trigger == nil {
+		return nil, errors.New("trigger has no platform")
+	}
+	platformKey := a.PlatformKey
+	if platformKey == "" {
+		platformKey = "platform"
+	}
+
+	m := mergeFields(a.Extra, fieldsToMap(a.Trigger), fieldsToMap(&triggerCommon{a.Id, a.Alias, a.Enabled, a.Variables}))
+	m[platformKey] = a.Trigger.Platform()
+	return yamlMapping(m, []string{platformKey}, fieldNames(a.Trigger), sortedKeys(a.Extra), fieldNames(&triggerCommon{}))
+}
+
+func (a *AutomationTrigger) UnmarshalYAML(node *yaml.Node) error {
+	return unmarshalYAMLViaJSON(node, a)
+}
+
+func (a AutomationCondition) MarshalYAML() (interface{}, error) {
+	if template, ok := a.Condition.(*TemplateCondition); ok && a.shorthand {
+		return template.ValueTemplate, nil
+	}
+	if a.Condition == nil {
+		return nil, errors.New("condition has no kind")
+	}
+
+	return yamlMapping(
+		mergeFields(a.Extra, conditionToMap(a.Condition), fieldsToMap(&conditionCommon{a.Alias, a.Enabled})),
+		[]string{"condition"}, fieldNames(a.Condition), sortedKeys(a.Extra), fieldNames(&conditionCommon{}))
+}
+
+func (a *AutomationCondition) UnmarshalYAML(node *yaml.Node) error {
+	return unmarshalYAMLViaJSON(node, a)
+}
+
+func (a AutomationAction) MarshalYAML() (interface{}, error) {
+	var kind map[string]interface{}
+	var order []string
+	switch action := a.Action.(type) {
+	case nil:
+	case *ConditionAction:
+		if action.Condition == nil {
+			return nil, errors.New("condition action has no condition")
+		}
+		kind = conditionToMap(action.Condition)
+		order = append([]string{"condition"}, fieldNames(action.Condition)...)
+	default:
+		kind = fieldsToMap(action)
+		order = fieldNames(action)
+	}
+
+	common := &actionCommon{a.Alias, a.Enabled, a.ContinueOnError}
+	return yamlMapping(mergeFields(a.Extra, kind, fieldsToMap(common)),
+		[]string{"alias"}, order, sortedKeys(a.Extra), fieldNames(common))
+}
+
+func (a *AutomationAction) UnmarshalYAML(node *yaml.Node) error {
+	return unmarshalYAMLViaJSON(node, a)
+}
+
+func (l TriggerList) MarshalYAML() (interface{}, error) {
+	if len(l) == 1 && l[0].single {
+		return l[0], nil
+	}
+	return []AutomationTrigger(l), nil
+}
+
+func (l ConditionList) MarshalYAML() (interface{}, error) {
+	if len(l) == 1 && l[0].single {
+		return l[0], nil
+	}
+	return []AutomationCondition(l), nil
+}
+
+func (s ActionSequence) MarshalYAML() (interface{}, error) {
+	if len(s) == 1 && s[0].single {
+		return s[0], nil
+	}
+	return []AutomationAction(s), nil
+}
+
+func (r RepeatLoop) MarshalYAML() (interface{}, error) {
+	return yamlMapping(fieldsToMap(&r), fieldNames(&r))
+}
+
+var _ yaml.Marshaler = Automation{}
+var _ yaml.Unmarshaler = (*Automation)(nil)
+var _ yaml.Marshaler = AutomationTrigger{}
+var _ yaml.Unmarshaler = (*AutomationTrigger)(nil)
+var _ yaml.Marshaler = AutomationCondition{}
+var _ yaml.Unmarshaler = (*AutomationCondition)(nil)
+var _ yaml.Marshaler = AutomationAction{}
+var _ yaml.Unmarshaler = (*AutomationAction)(nil)
+var _ yaml.Marshaler = TriggerList{}
+var _ yaml.Marshaler = ConditionList{}
+var _ yaml.Marshaler = ActionSequence{}
+var _ yaml.Marshaler = RepeatLoop{}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// TestAutomationYAMLRoundTrip checks that each automation in testdata/automations survives conversion to YAML and
+// back unchanged.
+func TestAutomationYAMLRoundTrip(t *testing.T) {
+	files, err := filepath.Glob("testdata/automations/*.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			want, err := os.ReadFile(file)
+			require.NoError(t, err)
+
+			var automation Automation
+			require.NoError(t, json.Unmarshal(want, &automation))
+
+			yb, err := EncodeYAML(&automation)
+			require.NoError(t, err)
+
+			var decoded Automation
+			require.NoError(t, yaml.Unmarshal(yb, &decoded), string(yb))
+
+			got, err := json.MarshalIndent(decoded, "", "  ")
+			require.NoError(t, err)
+			require.Equal(t, string(want), string(got)+"\n", string(yb))
+		})
+	}
+}
+
+func TestAutomationYAMLLayout(t *testing.T) {
+	input := `id: "1700000000000"
+alias: Motion light
+description: Turn on a light when motion is detected
+triggers:
+  - trigger: state
+    entity_id: !input motion_entity
+    from: "off"
+    to: "on"
+    for: !input delay
+    id: motion
+conditions:
+  - condition: numeric_state
+    entity_id: sensor.illuminance
+    below: 20
+  - '{{ is_state(''input_boolean.guest_mode'', ''off'') }}'
+actions:
+  - alias: Light on
+    action: light.turn_on
+    data:
+      brightness_pct: 80
+    target:
+      entity_id: light.hallway
+    metadata: {}
+  - action: notify.notify
+    data:
+      api_key: !secret notify_key
+      message: |-
+        Motion in the hallway
+        at {{ now() }}
+mode: restart
+`
+
+	var automation Automation
+	require.NoError(t, yaml.Unmarshal([]byte(input), &automation))
+
+	state := automation.Trigger[0].Trigger.(*StateTrigger)
+	require.Equal(t, []string{"!input motion_entity"}, state.EntityId.Values)
+	require.Zero(t, state.For.Duration)
+	require.Equal(t, "!secret notify_key", automation.Action[1].Action.(*ServiceAction).Data["api_key"])
+
+	got, err := EncodeYAML(&automation)
+	require.NoError(t, err)
+	require.Equal(t, input, string(got))
+}
@@ -0,0 +1,339 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UseBlueprint refers to the blueprint an automation (or script) was created from, and the inputs it was given.
+type UseBlueprint struct {
+	Path  string                 `json:"path"`
+	Input map[string]interface{} `json:"input,omitempty"`
+}
+
+// MarshalJSON writes the keys in sorted order, like the rest of an automation, rather than in field order.
+func (u UseBlueprint) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fieldsToMap(&u))
+}
+
+func (u UseBlueprint) MarshalYAML() (interface{}, error) {
+	return yamlMapping(fieldsToMap(&u), fieldNames(&u))
+}
+
+var _ json.Marshaler = UseBlueprint{}
+var _ yaml.Marshaler = UseBlueprint{}
+
+// Blueprint is a blueprint file. Only the `blueprint` section is decoded; the rest of the file is the automation or
+// script the blueprint describes, with `!input` tags (see EncodeYAML) marking where inputs are substituted.
+type Blueprint struct {
+	Metadata BlueprintMetadata `json:"blueprint"`
+}
+
+// BlueprintMetadata describes a blueprint and the inputs it accepts.
+type BlueprintMetadata struct {
+	Name          string                     `json:"name"`
+	Description   string                     `json:"description,omitempty"`
+	Domain        string                     `json:"domain"`
+	SourceUrl     string                     `json:"source_url,omitempty"`
+	Author        string                     `json:"author,omitempty"`
+	Homeassistant *BlueprintRequirements     `json:"homeassistant,omitempty"`
+	Input         map[string]*BlueprintInput `json:"input,omitempty"`
+}
+
+type BlueprintRequirements struct {
+	MinVersion string `json:"min_version,omitempty"`
+}
+
+// BlueprintInput describes a single input, or a section grouping several inputs. An input with no default is required.
+// Selector, if set, describes what values the input accepts.
+type BlueprintInput struct {
+	Name        string      `json:"name,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Default     interface{} `json:"default,omitempty"`
+	HasDefault  bool        `json:"-"`
+	Selector    *Selector   `json:"selector,omitempty"`
+
+	// The remaining fields are set only for sections.
+	Icon      string                     `json:"icon,omitempty"`
+	Collapsed bool                       `json:"collapsed,omitempty"`
+	Input     map[string]*BlueprintInput `json:"input,omitempty"`
+}
+
+func (b *BlueprintInput) UnmarshalJSON(data []byte) error {
+	// an input with nothing but a name may be given as null
+	if string(data) == "null" {
+		*b = BlueprintInput{}
+		return nil
+	}
+
+	type blueprintInput BlueprintInput
+	var ret blueprintInput
+	if err := json.Unmarshal(data, &ret); err != nil {
+		return err
+	}
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return err
+	}
+	_, ret.HasDefault = keys["default"]
+	*b = BlueprintInput(ret)
+	return nil
+}
+
+func (b BlueprintInput) MarshalJSON() ([]byte, error) {
+	type blueprintInput BlueprintInput
+	m := fieldsToMap((*blueprintInput)(&b))
+	if b.HasDefault {
+		m["default"] = b.Default
+	}
+	return json.Marshal(m)
+}
+
+// IsSection returns true if the input is a section grouping other inputs, rather than an input itself.
+func (b *BlueprintInput) IsSection() bool {
+	return b != nil && b.Input != nil
+}
+
+// Inputs returns every input of the blueprint by name, including those within sections.
+func (m *BlueprintMetadata) Inputs() map[string]*BlueprintInput {
+	ret := map[string]*BlueprintInput{}
+	var flatten func(inputs map[string]*BlueprintInput)
+	flatten = func(inputs map[string]*BlueprintInput) {
+		for name, input := range inputs {
+			switch {
+			case input == nil:
+				ret[name] = &BlueprintInput{}
+			case input.IsSection():
+				flatten(input.Input)
+			default:
+				ret[name] = input
+			}
+		}
+	}
+	flatten(m.Input)
+	return ret
+}
+
+// ParseBlueprint decodes a blueprint file.
+func ParseBlueprint(data []byte) (*Blueprint, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	if len(node.Content) == 0 {
+		return nil, errors.New("blueprint is empty")
+	}
+
+	var ret Blueprint
+	if err := unmarshalYAMLViaJSON(node.Content[0], &ret); err != nil {
+		return nil, err
+	}
+	if ret.Metadata.Domain == "" {
+		return nil, errors.New("blueprint has no domain; is this a blueprint?")
+	}
+	return &ret, nil
+}
+
+// Instantiate validates inputs against the blueprint's inputs, and returns an automation that uses the blueprint at
+// path (relative to the blueprint directory of the blueprint's domain, as returned by ListBlueprints) with them. The
+// automation is given the blueprint's name as its alias.
+func (m *BlueprintMetadata) Instantiate(path string, inputs map[string]interface{}) (*Automation, error) {
+	if m.Domain != "automation" {
+		return nil, fmt.Errorf("blueprint is for %ss, not automations", m.Domain)
+	}
+	if err := m.ValidateInputs(inputs); err != nil {
+		return nil, err
+	}
+
+	return &Automation{
+		Alias:        m.Name,
+		Description:  m.Description,
+		UseBlueprint: &UseBlueprint{Path: path, Input: inputs},
+	}, nil
+}
+
+// ValidateInputs checks that every required input is given, that no unknown inputs are given, and that each value is
+// acceptable to its input's selector. Values that are templates or tags (e.g., `!secret`) are not checked.
+func (m *BlueprintMetadata) ValidateInputs(inputs map[string]interface{}) error {
+	var problems []string
+	known := m.Inputs()
+
+	var names []string
+	for name := range known {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := inputs[name]; !ok && !known[name].HasDefault {
+			problems = append(problems, fmt.Sprintf("%s: required input not given", name))
+		}
+	}
+	for _, name := range sortedKeys(inputs) {
+		input, ok := known[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: no such input", name))
+			continue
+		}
+		if err := input.validate(inputs[name]); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid blueprint inputs: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (b *BlueprintInput) validate(value interface{}) error {
+	if b.Selector == nil {
+		return nil
+	}
+	return b.Selector.Validate(value)
+}
+
+type BlueprintListMessage struct {
+	Domain string `json:"domain"`
+}
+
+func (BlueprintListMessage) Type() string { return "blueprint/list" }
+
+type BlueprintImportMessage struct {
+	Url string `json:"url"`
+}
+
+func (BlueprintImportMessage) Type() string { return "blueprint/import" }
+
+type BlueprintSaveMessage struct {
+	Domain        string `json:"domain"`
+	Path          string `json:"path"`
+	Yaml          string `json:"yaml"`
+	SourceUrl     string `json:"source_url,omitempty"`
+	AllowOverride bool   `json:"allow_override,omitempty"`
+}
+
+func (BlueprintSaveMessage) Type() string { return "blueprint/save" }
+
+type BlueprintDeleteMessage struct {
+	Domain string `json:"domain"`
+	Path   string `json:"path"`
+}
+
+func (BlueprintDeleteMessage) Type() string { return "blueprint/delete" }
+
+// BlueprintListEntry is a single blueprint as returned by ListBlueprints. Error is set instead of Metadata if the
+// blueprint could not be loaded.
+type BlueprintListEntry struct {
+	Path     string             `json:"path"`
+	Metadata *BlueprintMetadata `json:"metadata,omitempty"`
+	Error    string             `json:"error,omitempty"`
+}
+
+// BlueprintImport is the result of ImportBlueprint: a blueprint that has been retrieved, but not yet saved.
+type BlueprintImport struct {
+	SuggestedFilename string    `json:"suggested_filename"`
+	RawData           string    `json:"raw_data"`
+	Blueprint         Blueprint `json:"-"`
+	ValidationErrors  []string  `json:"validation_errors,omitempty"`
+	Exists            bool      `json:"exists,omitempty"`
+}
+
+func (b *BlueprintImport) UnmarshalJSON(data []byte) error {
+	type blueprintImport BlueprintImport
+	var ret struct {
+		blueprintImport
+		Blueprint struct {
+			Metadata BlueprintMetadata `json:"metadata"`
+		} `json:"blueprint"`
+	}
+	if err := json.Unmarshal(data, &ret); err != nil {
+		return err
+	}
+	*b = BlueprintImport(ret.blueprintImport)
+	b.Blueprint.Metadata = ret.Blueprint.Metadata
+	return nil
+}
+
+// ListBlueprints lists the blueprints of the given domain (`automation` or `script`), sorted by path.
+func (c *Client) ListBlueprints(domain string) ([]BlueprintListEntry, error) {
+	retI, err := c.RawWebsocketRequestAs(BlueprintListMessage{domain}, map[string]BlueprintListEntry{})
+	if err != nil {
+		return nil, fmt.Errorf("listing %s blueprints: %w", domain, err)
+	}
+
+	entries, ok := retI.(map[string]BlueprintListEntry)
+	if !ok {
+		return nil, fmt.Errorf("received %T instead of map[string]BlueprintListEntry", retI)
+	}
+
+	var ret []BlueprintListEntry
+	for path, entry := range entries {
+		entry.Path = path
+		ret = append(ret, entry)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Path < ret[j].Path })
+	return ret, nil
+}
+
+// GetBlueprint retrieves the blueprint of the given domain at the given path.
+func (c *Client) GetBlueprint(domain, path string) (*BlueprintMetadata, error) {
+	entries, err := c.ListBlueprints(domain)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.Path != path {
+			continue
+		}
+		if entry.Metadata == nil {
+			return nil, fmt.Errorf("blueprint %q could not be loaded: %s", path, entry.Error)
+		}
+		return entry.Metadata, nil
+	}
+	return nil, fmt.Errorf("no %s blueprint %q", domain, path)
+}
+
+// ImportBlueprint has the server retrieve the blueprint at the given URL. The blueprint is not saved until it is
+// passed to SaveBlueprint.
+func (c *Client) ImportBlueprint(url string) (*BlueprintImport, error) {
+	retI, err := c.RawWebsocketRequestAs(BlueprintImportMessage{url}, (*BlueprintImport)(nil))
+	if err != nil {
+		return nil, fmt.Errorf("importing blueprint from %q: %w", url, err)
+	}
+
+	ret, ok := retI.(*BlueprintImport)
+	if !ok {
+		return nil, fmt.Errorf("received %T instead of *BlueprintImport", retI)
+	}
+	return ret, nil
+}
+
+// SaveBlueprint saves the given blueprint file at path (relative to the blueprint directory of the domain). If
+// allowOverride is false, an existing blueprint at path is an error.
+func (c *Client) SaveBlueprint(domain, path string, data []byte, sourceUrl string, allowOverride bool) error {
+	_, err := c.RawWebsocketRequest(BlueprintSaveMessage{
+		Domain:        domain,
+		Path:          path,
+		Yaml:          string(data),
+		SourceUrl:     sourceUrl,
+		AllowOverride: allowOverride,
+	})
+	if err != nil {
+		return fmt.Errorf("saving %s blueprint %q: %w", domain, path, err)
+	}
+	return nil
+}
+
+// DeleteBlueprint deletes the blueprint of the given domain at the given path. homeassistant refuses to delete
+// blueprints that are in use.
+func (c *Client) DeleteBlueprint(domain, path string) error {
+	if _, err := c.RawWebsocketRequest(BlueprintDeleteMessage{domain, path}); err != nil {
+		return fmt.Errorf("deleting %s blueprint %q: %w", domain, path, err)
+	}
+	return nil
+}
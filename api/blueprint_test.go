@@ -0,0 +1,134 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const motionLightBlueprint = `blueprint:
+  name: Motion-activated Light
+  description: Turn on a light when motion is detected.
+  domain: automation
+  source_url: https://github.com/home-assistant/core/blob/dev/homeassistant/components/automation/blueprints/motion_light.yaml
+  input:
+    motion_entity:
+      name: Motion Sensor
+      selector:
+        entity:
+          domain: binary_sensor
+          device_class: motion
+    light_target:
+      name: Light
+      selector:
+        target:
+          entity:
+            domain: light
+    timing:
+      name: Timing
+      input:
+        no_motion_wait:
+          name: Wait time
+          default: 120
+          selector:
+            number:
+              min: 0
+              max: 3600
+        mode:
+          default: restart
+          selector:
+            select:
+              options: [restart, single]
+    notes:
+
+mode: !input mode
+triggers:
+  - trigger: state
+    entity_id: !input motion_entity
+    from: "off"
+    to: "on"
+actions:
+  - action: light.turn_on
+    target: !input light_target
+`
+
+func TestParseBlueprint(t *testing.T) {
+	blueprint, err := ParseBlueprint([]byte(motionLightBlueprint))
+	require.NoError(t, err)
+
+	metadata := blueprint.Metadata
+	require.Equal(t, "Motion-activated Light", metadata.Name)
+	require.Equal(t, "automation", metadata.Domain)
+	require.True(t, metadata.Input["timing"].IsSection())
+
+	inputs := metadata.Inputs()
+	require.Len(t, inputs, 5)
+	require.False(t, inputs["motion_entity"].HasDefault)
+	require.True(t, inputs["no_motion_wait"].HasDefault)
+	require.Equal(t, float64(120), inputs["no_motion_wait"].Default)
+	require.NotNil(t, inputs["notes"])
+
+	_, err = ParseBlueprint([]byte("alias: not a blueprint\n"))
+	require.Error(t, err)
+}
+
+func TestBlueprintInstantiate(t *testing.T) {
+	blueprint, err := ParseBlueprint([]byte(motionLightBlueprint))
+	require.NoError(t, err)
+	metadata := blueprint.Metadata
+
+	automation, err := metadata.Instantiate("homeassistant/motion_light.yaml", map[string]interface{}{
+		"motion_entity": "binary_sensor.hall_motion",
+		"light_target":  map[string]interface{}{"entity_id": "light.hall"},
+		"notes":         "anything",
+	})
+	require.NoError(t, err)
+	require.NoError(t, automation.Validate())
+
+	automationJson, err := json.Marshal(automation)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"alias": "Motion-activated Light",
+		"description": "Turn on a light when motion is detected.",
+		"use_blueprint": {
+			"path": "homeassistant/motion_light.yaml",
+			"input": {
+				"motion_entity": "binary_sensor.hall_motion",
+				"light_target": {"entity_id": "light.hall"},
+				"notes": "anything"
+			}
+		}
+	}`, string(automationJson))
+
+	tests := []struct {
+		name   string
+		inputs map[string]interface{}
+		want   string
+	}{
+		{"missing", map[string]interface{}{"light_target": nil, "notes": nil},
+			"invalid blueprint inputs: motion_entity: required input not given"},
+		{"unknown", map[string]interface{}{"motion_entity": "binary_sensor.x", "light_target": nil, "notes": nil,
+			"bogus": 1}, "invalid blueprint inputs: bogus: no such input"},
+		{"wrong domain", map[string]interface{}{"motion_entity": "light.x", "light_target": nil, "notes": nil},
+			"invalid blueprint inputs: motion_entity: light.x is not in domain binary_sensor"},
+		{"out of range", map[string]interface{}{"motion_entity": "binary_sensor.x", "light_target": nil,
+			"notes": nil, "no_motion_wait": float64(7200)},
+			"invalid blueprint inputs: no_motion_wait: 7200 is more than the maximum, 3600"},
+		{"not an option", map[string]interface{}{"motion_entity": "binary_sensor.x", "light_target": nil,
+			"notes": nil, "mode": "queued"},
+			"invalid blueprint inputs: mode: queued is not one of restart, single"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := metadata.Instantiate("motion_light.yaml", tt.inputs)
+			require.EqualError(t, err, tt.want)
+		})
+	}
+
+	// templates and tags cannot be checked
+	require.NoError(t, metadata.ValidateInputs(map[string]interface{}{
+		"motion_entity": "{{ 'binary_sensor.x' }}", "light_target": nil, "notes": nil,
+		"no_motion_wait": "!secret wait",
+	}))
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -133,12 +134,18 @@ type ConfigFlow struct {
 	Description string          `json:"description,omitempty"`
 	Result      json.RawMessage `json:"result,omitempty"`
 	Title       string          `json:"title,omitempty"`
+	// Reason says why a flow of Type `abort` was aborted, e.g. `already_configured`.
+	Reason string `json:"reason,omitempty"`
+	// MenuOptions are the step IDs a flow of Type `menu` offers; the chosen one is submitted as `next_step_id`.
+	MenuOptions interface{} `json:"menu_options,omitempty"`
 }
 
 type ConfigFlowDataSchema struct {
 	Name     string `json:"name"`
 	Required bool   `json:"required"`
 	Type     string `json:"type"`
+	// Selector is the field's selector, for fields that have one in place of a Type, e.g. `{"number": {"min": 1}}`.
+	Selector map[string]interface{} `json:"selector,omitempty"`
 }
 
 // GetFlow returns the in-progress-but-not-started flow with the given ID.
@@ -170,14 +177,44 @@ func (c *Client) GetOptionsFlow(id ConfigFlowId) (*ConfigFlow, error) {
 //
 // If anything goes wrong, result will be the zero value and err will be non-nil.
 func (c *Client) SetFlow(id ConfigFlowId, payload map[string]interface{}) (result string, err error) {
-	obj, err := process(c.Post("config/config_entries/flow/"+string(id), payload))
+	flow, err := c.SubmitFlow(id, payload)
+	if err != nil {
+		return "", err
+	}
+
+	if len(flow.Result) == 0 {
+		return "", errors.New("flow Result was empty")
+	}
+
+	var s string
+	if err := json.Unmarshal(flow.Result, &s); err == nil {
+		return s, nil
+	}
+
+	return string(flow.Result), nil
+}
+
+// SubmitFlow submits the given input to the current step of the given config flow, and returns the flow as it is
+// afterward: at its next step, or done, with Type `create_entry` or `abort`. If homeassistant rejects the input, the
+// errors it gives are returned as an error.
+func (c *Client) SubmitFlow(id ConfigFlowId, input map[string]interface{}) (*ConfigFlow, error) {
+	return c.submitFlow("config/config_entries/flow/"+string(id), input)
+}
+
+// SubmitOptionsFlow is SubmitFlow, for options flows.
+func (c *Client) SubmitOptionsFlow(id ConfigFlowId, input map[string]interface{}) (*ConfigFlow, error) {
+	return c.submitFlow("config/config_entries/options/flow/"+string(id), input)
+}
+
+func (c *Client) submitFlow(path string, input map[string]interface{}) (*ConfigFlow, error) {
+	obj, err := process(c.Post(path, input))
 	flowI, err := convert(obj, (*ConfigFlow)(nil), err)
 	if err != nil {
-		return "", fmt.Errorf("POSTing set-flow: %v", err)
+		return nil, fmt.Errorf("POSTing set-flow: %w", err)
 	}
 	flow, ok := flowI.(*ConfigFlow)
 	if !ok {
-		return "", fmt.Errorf("response object was %T, not *ConfigFlow", flowI)
+		return nil, fmt.Errorf("response object was %T, not *ConfigFlow", flowI)
 	}
 
 	var errs []string
@@ -185,19 +222,41 @@ func (c *Client) SetFlow(id ConfigFlowId, payload map[string]interface{}) (resul
 		errs = append(errs, k+": "+flowErr)
 	}
 	if len(errs) > 0 {
-		return "", fmt.Errorf("server responsed with error: %s", strings.Join(errs, "; "))
+		sort.Strings(errs)
+		return nil, fmt.Errorf("server responsed with error: %s", strings.Join(errs, "; "))
 	}
 
-	if len(flow.Result) == 0 {
-		return "", errors.New("flow Result was empty")
+	return flow, nil
+}
+
+// AbortFlow discards the given config flow, as the UI does when its dialog is closed before the flow is done.
+func (c *Client) AbortFlow(id ConfigFlowId) error {
+	if _, err := process(c.Delete("config/config_entries/flow/"+string(id), nil)); err != nil {
+		return fmt.Errorf("aborting flow %q: %w", id, err)
 	}
+	return nil
+}
 
-	var s string
-	if err := json.Unmarshal(flow.Result, &s); err == nil {
-		return s, nil
+// AbortOptionsFlow is AbortFlow, for options flows.
+func (c *Client) AbortOptionsFlow(id ConfigFlowId) error {
+	if _, err := process(c.Delete("config/config_entries/options/flow/"+string(id), nil)); err != nil {
+		return fmt.Errorf("aborting options flow %q: %w", id, err)
 	}
+	return nil
+}
 
-	return string(flow.Result), nil
+// EntryId returns the ID of the entry a finished config flow created. Older versions of homeassistant give the ID as
+// the flow's result; newer ones give the whole entry.
+func (f *ConfigFlow) EntryId() EntryId {
+	var id string
+	if err := json.Unmarshal(f.Result, &id); err == nil {
+		return EntryId(id)
+	}
+	var entry ConfigEntry
+	if err := json.Unmarshal(f.Result, &entry); err == nil {
+		return entry.EntryId
+	}
+	return ""
 }
 
 func (c *Client) StartFlow(handler string) (*ConfigFlow, error) {
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
 )
 
@@ -18,13 +19,28 @@ func init() {
 	RegisterMessageType(EntityListMessage{})
 }
 
+// Entity is an entity's entry in the entity registry. Name, Icon, and DeviceClass are set only if the user has
+// overridden them; OriginalName, OriginalIcon, and OriginalDeviceClass are those the integration provides. Options
+// holds per-domain options, e.g. `{"sensor": {"unit_of_measurement": "°F"}}`. Id is the registry's ID for the entry,
+// which unlike EntityId does not change when the entity is renamed.
 type Entity struct {
-	ConfigEntryId string `json:"config_entry_id,omitempty"`
-	DeviceId      string `json:"device_id,omitempty"`
-	DisabledBy    string `json:"disabled_by,omitempty"`
-	EntityId      string `json:"entity_id,omitempty"`
-	Platform      string `json:"platform,omitempty"`
-	Name          string `json:"name,omitempty"`
+	ConfigEntryId       string                            `json:"config_entry_id,omitempty"`
+	DeviceId            string                            `json:"device_id,omitempty"`
+	DisabledBy          string                            `json:"disabled_by,omitempty"`
+	EntityId            string                            `json:"entity_id,omitempty"`
+	Platform            string                            `json:"platform,omitempty"`
+	Name                string                            `json:"name,omitempty"`
+	Icon                string                            `json:"icon,omitempty"`
+	AreaId              string                            `json:"area_id,omitempty"`
+	HiddenBy            string                            `json:"hidden_by,omitempty"`
+	DeviceClass         string                            `json:"device_class,omitempty"`
+	OriginalName        string                            `json:"original_name,omitempty"`
+	OriginalIcon        string                            `json:"original_icon,omitempty"`
+	OriginalDeviceClass string                            `json:"original_device_class,omitempty"`
+	UniqueId            string                            `json:"unique_id,omitempty"`
+	Id                  string                            `json:"id,omitempty"`
+	Labels              []string                          `json:"labels,omitempty"`
+	Options             map[string]map[string]interface{} `json:"options,omitempty"`
 }
 
 func (c *Client) GetEntity(id string) (*Entity, error) {
@@ -68,3 +84,78 @@ func (c *Client) SetEntityName(id string, name string) error {
 	_, err := c.RawWebsocketRequest(EntityRename{EntityId: id, Name: name})
 	return err
 }
+
+// EntityUpdate changes an entity's registry entry. Nil fields are left as they are, and fields set to the empty
+// string are cleared. DisabledBy and HiddenBy may only be set to `user`, or cleared. If OptionsDomain is set, the
+// entity's options for that domain are replaced with Options.
+type EntityUpdate struct {
+	EntityId      string
+	Name          *string
+	Icon          *string
+	AreaId        *string
+	DisabledBy    *string
+	HiddenBy      *string
+	DeviceClass   *string
+	NewEntityId   string
+	Labels        *[]string
+	OptionsDomain string
+	Options       map[string]interface{}
+}
+
+func (EntityUpdate) Type() string { return "config/entity_registry/update" }
+
+func (u EntityUpdate) MarshalJSON() ([]byte, error) {
+	ret := map[string]interface{}{"entity_id": u.EntityId}
+	for key, value := range map[string]*string{
+		"name":         u.Name,
+		"icon":         u.Icon,
+		"area_id":      u.AreaId,
+		"disabled_by":  u.DisabledBy,
+		"hidden_by":    u.HiddenBy,
+		"device_class": u.DeviceClass,
+	} {
+		if value == nil {
+			continue
+		}
+		if *value == "" {
+			ret[key] = nil
+		} else {
+			ret[key] = *value
+		}
+	}
+	if u.NewEntityId != "" && u.NewEntityId != u.EntityId {
+		ret["new_entity_id"] = u.NewEntityId
+	}
+	if u.Labels != nil {
+		ret["labels"] = *u.Labels
+		if *u.Labels == nil {
+			ret["labels"] = []string{}
+		}
+	}
+	if u.OptionsDomain != "" {
+		ret["options_domain"] = u.OptionsDomain
+		ret["options"] = u.Options
+	}
+	return json.Marshal(ret)
+}
+
+var _ json.Marshaler = EntityUpdate{}
+
+type entityUpdateResult struct {
+	EntityEntry *Entity `json:"entity_entry"`
+}
+
+// UpdateEntity applies the given changes to an entity's registry entry, and returns the updated entry.
+func (c *Client) UpdateEntity(update EntityUpdate) (*Entity, error) {
+	retI, err := c.RawWebsocketRequestAs(update, (*entityUpdateResult)(nil))
+	if err != nil {
+		return nil, fmt.Errorf("updating entity %q: %w", update.EntityId, err)
+	}
+
+	ret, ok := retI.(*entityUpdateResult)
+	if !ok || ret.EntityEntry == nil {
+		return nil, fmt.Errorf("server sent %T, not an entity entry", retI)
+	}
+
+	return ret.EntityEntry, nil
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntityUpdate_MarshalJSON(t *testing.T) {
+	name, none := "Kitchen", ""
+	labels := []string(nil)
+
+	body, err := json.Marshal(EntityUpdate{
+		EntityId:      "sensor.kitchen",
+		Name:          &name,
+		Icon:          &none,
+		NewEntityId:   "sensor.kitchen_temperature",
+		Labels:        &labels,
+		OptionsDomain: "sensor",
+		Options:       map[string]interface{}{"unit_of_measurement": "°F"},
+	})
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"entity_id": "sensor.kitchen",
+		"name": "Kitchen",
+		"icon": null,
+		"new_entity_id": "sensor.kitchen_temperature",
+		"labels": [],
+		"options_domain": "sensor",
+		"options": {"unit_of_measurement": "°F"}
+	}`, string(body))
+
+	body, err = json.Marshal(EntityUpdate{EntityId: "sensor.kitchen", NewEntityId: "sensor.kitchen"})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"entity_id": "sensor.kitchen"}`, string(body))
+}
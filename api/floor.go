@@ -0,0 +1,105 @@
+package api
+
+import (
+	"fmt"
+)
+
+// Floor is an entry in the floor registry. Areas are assigned to floors by FloorId. Level orders the floors, with 0
+// for the ground floor; it is nil if not set.
+type Floor struct {
+	FloorId string   `json:"floor_id"`
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases,omitempty"`
+	Icon    string   `json:"icon,omitempty"`
+	Level   *int     `json:"level,omitempty"`
+}
+
+type FloorListMessage struct{}
+
+func (FloorListMessage) Type() string { return "config/floor_registry/list" }
+
+// floorFields are the fields of a floor that can be set when creating or updating it. Empty fields are sent as null,
+// which clears them.
+type floorFields struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases"`
+	Icon    *string  `json:"icon"`
+	Level   *int     `json:"level"`
+}
+
+func newFloorFields(floor Floor) floorFields {
+	return floorFields{
+		Name:    floor.Name,
+		Aliases: emptyIfNil(floor.Aliases),
+		Icon:    nullable(floor.Icon),
+		Level:   floor.Level,
+	}
+}
+
+type FloorCreateMessage struct {
+	floorFields
+}
+
+func (FloorCreateMessage) Type() string { return "config/floor_registry/create" }
+
+type FloorUpdateMessage struct {
+	FloorId string `json:"floor_id"`
+	floorFields
+}
+
+func (FloorUpdateMessage) Type() string { return "config/floor_registry/update" }
+
+type FloorDeleteMessage struct {
+	FloorId string `json:"floor_id"`
+}
+
+func (FloorDeleteMessage) Type() string { return "config/floor_registry/delete" }
+
+func (c *Client) ListFloors() ([]Floor, error) {
+	retI, err := c.RawWebsocketRequestAs(FloorListMessage{}, []Floor{})
+	if err != nil {
+		return nil, fmt.Errorf("listing floors: %w", err)
+	}
+	return retI.([]Floor), nil
+}
+
+// GetFloor returns the floor with the given ID. If there is none, the error satisfies IsNotFound.
+func (c *Client) GetFloor(id string) (*Floor, error) {
+	floors, err := c.ListFloors()
+	if err != nil {
+		return nil, err
+	}
+	for _, floor := range floors {
+		if floor.FloorId == id {
+			return &floor, nil
+		}
+	}
+	return nil, notFound("floor", id)
+}
+
+// CreateFloor creates a floor with the fields of the given one, other than FloorId, which homeassistant derives from
+// the name. It returns the floor as created.
+func (c *Client) CreateFloor(floor Floor) (*Floor, error) {
+	retI, err := c.RawWebsocketRequestAs(FloorCreateMessage{newFloorFields(floor)}, (*Floor)(nil))
+	if err != nil {
+		return nil, fmt.Errorf("creating floor %q: %w", floor.Name, err)
+	}
+	return retI.(*Floor), nil
+}
+
+// UpdateFloor sets all the fields of the floor with the given one's FloorId to those of the given one, and returns
+// the updated floor.
+func (c *Client) UpdateFloor(floor Floor) (*Floor, error) {
+	retI, err := c.RawWebsocketRequestAs(FloorUpdateMessage{floor.FloorId, newFloorFields(floor)}, (*Floor)(nil))
+	if err != nil {
+		return nil, fmt.Errorf("updating floor %q: %w", floor.FloorId, err)
+	}
+	return retI.(*Floor), nil
+}
+
+func (c *Client) DeleteFloor(id string) error {
+	if _, err := c.RawWebsocketRequest(FloorDeleteMessage{id}); err != nil {
+		return fmt.Errorf("deleting floor %q: %w", id, err)
+	}
+	return nil
+}
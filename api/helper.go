@@ -0,0 +1,103 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// HelperDomains are the domains of the helpers that can be created through the UI (or the API), as opposed to only in
+// configuration.yaml.
+var HelperDomains = []string{"counter", "input_boolean", "input_datetime", "input_number", "input_select",
+	"input_text", "timer"}
+
+// Helper is the configuration of a helper, as homeassistant stores it. Every helper has an `id` and a `name`; the rest
+// depend on its domain, e.g. `min` and `max` for an input_number.
+type Helper map[string]interface{}
+
+func (h Helper) Id() string {
+	ret, _ := h["id"].(string)
+	return ret
+}
+
+func (h Helper) Name() string {
+	ret, _ := h["name"].(string)
+	return ret
+}
+
+// EntityId returns the ID of the helper's entity, assuming it has not been renamed.
+func (h Helper) EntityId(domain string) string {
+	return domain + "." + h.Id()
+}
+
+// HelperMessage is a request to one of the helper collections, e.g. `input_number/create`. Fields are the request's
+// fields, which for updates and deletes include the helper's ID as `<domain>_id`.
+type HelperMessage struct {
+	Domain string
+	Action string
+	Fields map[string]interface{}
+}
+
+func (m HelperMessage) Type() string { return m.Domain + "/" + m.Action }
+
+func (m HelperMessage) MarshalJSON() ([]byte, error) {
+	if m.Fields == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(m.Fields)
+}
+
+var _ json.Marshaler = HelperMessage{}
+
+func (c *Client) ListHelpers(domain string) ([]Helper, error) {
+	retI, err := c.RawWebsocketRequestAs(HelperMessage{Domain: domain, Action: "list"}, []Helper{})
+	if err != nil {
+		return nil, fmt.Errorf("listing %s helpers: %w", domain, err)
+	}
+	return retI.([]Helper), nil
+}
+
+// GetHelper returns the helper in the given domain with the given ID. If there is none, the error satisfies IsNotFound.
+func (c *Client) GetHelper(domain, id string) (Helper, error) {
+	helpers, err := c.ListHelpers(domain)
+	if err != nil {
+		return nil, err
+	}
+	for _, helper := range helpers {
+		if helper.Id() == id {
+			return helper, nil
+		}
+	}
+	return nil, notFound(domain, id)
+}
+
+// CreateHelper creates a helper in the given domain with the given fields, which must include `name`, and returns it as
+// created. homeassistant derives its ID from the name.
+func (c *Client) CreateHelper(domain string, fields map[string]interface{}) (Helper, error) {
+	retI, err := c.RawWebsocketRequestAs(HelperMessage{domain, "create", fields}, Helper{})
+	if err != nil {
+		return nil, fmt.Errorf("creating %s helper: %w", domain, err)
+	}
+	return retI.(Helper), nil
+}
+
+// UpdateHelper replaces the configuration of the given helper with the given fields, which must include `name`, and
+// returns it as updated. Fields that are not given go back to their defaults.
+func (c *Client) UpdateHelper(domain, id string, fields map[string]interface{}) (Helper, error) {
+	request := map[string]interface{}{domain + "_id": id}
+	for k, v := range fields {
+		request[k] = v
+	}
+	retI, err := c.RawWebsocketRequestAs(HelperMessage{domain, "update", request}, Helper{})
+	if err != nil {
+		return nil, fmt.Errorf("updating %s helper %q: %w", domain, id, err)
+	}
+	return retI.(Helper), nil
+}
+
+func (c *Client) DeleteHelper(domain, id string) error {
+	request := map[string]interface{}{domain + "_id": id}
+	if _, err := c.RawWebsocketRequest(HelperMessage{domain, "delete", request}); err != nil {
+		return fmt.Errorf("deleting %s helper %q: %w", domain, id, err)
+	}
+	return nil
+}
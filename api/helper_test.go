@@ -0,0 +1,40 @@
+package api
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHelpers(t *testing.T) {
+	var requests []map[string]interface{}
+	c := fakeWebsocket(t, func(request map[string]interface{}) (interface{}, error) {
+		requests = append(requests, request)
+		switch request["type"] {
+		case "input_number/list":
+			return []interface{}{map[string]interface{}{"id": "target", "name": "Target", "min": 10, "max": 30}}, nil
+		case "input_number/update":
+			return map[string]interface{}{"id": request["input_number_id"], "name": request["name"]}, nil
+		case "input_number/delete":
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unexpected request %v", request["type"])
+	})
+
+	helper, err := c.GetHelper("input_number", "target")
+	require.NoError(t, err)
+	require.Equal(t, "Target", helper.Name())
+	require.Equal(t, "input_number.target", helper.EntityId("input_number"))
+	require.Equal(t, float64(30), helper["max"])
+
+	_, err = c.GetHelper("input_number", "other")
+	require.True(t, IsNotFound(err))
+
+	helper, err = c.UpdateHelper("input_number", "target", map[string]interface{}{"name": "Target Temperature"})
+	require.NoError(t, err)
+	require.Equal(t, Helper{"id": "target", "name": "Target Temperature"}, helper)
+
+	require.NoError(t, c.DeleteHelper("input_number", "target"))
+	require.Equal(t, "target", requests[len(requests)-1]["input_number_id"])
+}
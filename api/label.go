@@ -0,0 +1,105 @@
+package api
+
+import (
+	"fmt"
+)
+
+// Label is an entry in the label registry. Areas, devices, entities, automations, and scripts can be labeled by
+// LabelId. Color is either a color name from the homeassistant theme, e.g. `indigo`, or an `#rrggbb` value.
+type Label struct {
+	LabelId     string `json:"label_id"`
+	Name        string `json:"name"`
+	Color       string `json:"color,omitempty"`
+	Icon        string `json:"icon,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+type LabelListMessage struct{}
+
+func (LabelListMessage) Type() string { return "config/label_registry/list" }
+
+// labelFields are the fields of a label that can be set when creating or updating it. Empty fields are sent as null,
+// which clears them.
+type labelFields struct {
+	Name        string  `json:"name"`
+	Color       *string `json:"color"`
+	Icon        *string `json:"icon"`
+	Description *string `json:"description"`
+}
+
+func newLabelFields(label Label) labelFields {
+	return labelFields{
+		Name:        label.Name,
+		Color:       nullable(label.Color),
+		Icon:        nullable(label.Icon),
+		Description: nullable(label.Description),
+	}
+}
+
+type LabelCreateMessage struct {
+	labelFields
+}
+
+func (LabelCreateMessage) Type() string { return "config/label_registry/create" }
+
+type LabelUpdateMessage struct {
+	LabelId string `json:"label_id"`
+	labelFields
+}
+
+func (LabelUpdateMessage) Type() string { return "config/label_registry/update" }
+
+type LabelDeleteMessage struct {
+	LabelId string `json:"label_id"`
+}
+
+func (LabelDeleteMessage) Type() string { return "config/label_registry/delete" }
+
+func (c *Client) ListLabels() ([]Label, error) {
+	retI, err := c.RawWebsocketRequestAs(LabelListMessage{}, []Label{})
+	if err != nil {
+		return nil, fmt.Errorf("listing labels: %w", err)
+	}
+	return retI.([]Label), nil
+}
+
+// GetLabel returns the label with the given ID. If there is none, the error satisfies IsNotFound.
+func (c *Client) GetLabel(id string) (*Label, error) {
+	labels, err := c.ListLabels()
+	if err != nil {
+		return nil, err
+	}
+	for _, label := range labels {
+		if label.LabelId == id {
+			return &label, nil
+		}
+	}
+	return nil, notFound("label", id)
+}
+
+// CreateLabel creates a label with the fields of the given one, other than LabelId, which homeassistant derives from
+// the name. It returns the label as created.
+func (c *Client) CreateLabel(label Label) (*Label, error) {
+	retI, err := c.RawWebsocketRequestAs(LabelCreateMessage{newLabelFields(label)}, (*Label)(nil))
+	if err != nil {
+		return nil, fmt.Errorf("creating label %q: %w", label.Name, err)
+	}
+	return retI.(*Label), nil
+}
+
+// UpdateLabel sets all the fields of the label with the given one's LabelId to those of the given one, and returns
+// the updated label.
+func (c *Client) UpdateLabel(label Label) (*Label, error) {
+	retI, err := c.RawWebsocketRequestAs(LabelUpdateMessage{label.LabelId, newLabelFields(label)}, (*Label)(nil))
+	if err != nil {
+		return nil, fmt.Errorf("updating label %q: %w", label.LabelId, err)
+	}
+	return retI.(*Label), nil
+}
+
+func (c *Client) DeleteLabel(id string) error {
+	if _, err := c.RawWebsocketRequest(LabelDeleteMessage{id}); err != nil {
+		return fmt.Errorf("deleting label %q: %w", id, err)
+	}
+	return nil
+}
@@ -47,7 +47,7 @@ func process(resultInterface interface{}, err error) (interface{}, error) {
 	}
 
 	if !result.Success {
-		return nil, fmt.Errorf("result was not successful: %+v", result.Error)
+		return nil, fmt.Errorf("result was not successful: %w", result.Error)
 	}
 
 	return result.Result, nil
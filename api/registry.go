@@ -0,0 +1,25 @@
+package api
+
+// The area, floor, and label registries, and zones, share a shape: a list, and create, update, and delete requests
+// that take the whole of the fields that can be set. These helpers are shared by their clients.
+
+// nullable returns nil for the empty string, so that it is sent as null, which clears the field.
+func nullable(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// emptyIfNil returns an empty list in place of nil, so that it is sent as [], which clears the field.
+func emptyIfNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
+
+// notFound returns an error that satisfies IsNotFound, for things that are looked up by listing them all.
+func notFound(kind, id string) error {
+	return ResultError{Code: "not_found", Message: kind + " " + id + " not found"}
+}
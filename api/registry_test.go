@@ -0,0 +1,55 @@
+package api
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAreaRegistry(t *testing.T) {
+	var requests []map[string]interface{}
+	c := fakeWebsocket(t, func(request map[string]interface{}) (interface{}, error) {
+		requests = append(requests, request)
+		switch request["type"] {
+		case "config/area_registry/list":
+			return []interface{}{map[string]interface{}{"area_id": "kitchen", "name": "Kitchen", "floor_id": nil}}, nil
+		case "config/area_registry/create":
+			return map[string]interface{}{"area_id": "living_room", "name": request["name"]}, nil
+		}
+		return nil, fmt.Errorf("unexpected request %v", request["type"])
+	})
+
+	area, err := c.GetArea("kitchen")
+	require.NoError(t, err)
+	require.Equal(t, &Area{AreaId: "kitchen", Name: "Kitchen"}, area)
+
+	_, err = c.GetArea("garage")
+	require.True(t, IsNotFound(err))
+
+	area, err = c.CreateArea(Area{Name: "Living Room", Icon: "mdi:sofa"})
+	require.NoError(t, err)
+	require.Equal(t, "living_room", area.AreaId)
+
+	// empty fields are sent as null or [], to clear them
+	create := requests[len(requests)-1]
+	require.Equal(t, "mdi:sofa", create["icon"])
+	require.Contains(t, create, "floor_id")
+	require.Nil(t, create["floor_id"])
+	require.Equal(t, []interface{}{}, create["aliases"])
+}
+
+func TestZones(t *testing.T) {
+	var update map[string]interface{}
+	c := fakeWebsocket(t, func(request map[string]interface{}) (interface{}, error) {
+		update = request
+		return map[string]interface{}{"id": request["zone_id"], "name": request["name"],
+			"latitude": request["latitude"], "longitude": request["longitude"], "radius": 100, "passive": false}, nil
+	})
+
+	zone, err := c.UpdateZone(Zone{Id: "work", Name: "Work", Latitude: 52.37, Longitude: 4.89})
+	require.NoError(t, err)
+	require.Equal(t, "zone/update", update["type"])
+	require.NotContains(t, update, "radius")
+	require.Equal(t, &Zone{Id: "work", Name: "Work", Latitude: 52.37, Longitude: 4.89, Radius: 100}, zone)
+}
@@ -1,5 +1,10 @@
 package api
 
+import (
+	"errors"
+	"strings"
+)
+
 type ResultMessage struct {
 	Id      int
 	Success bool
@@ -7,11 +12,26 @@ type ResultMessage struct {
 	Error   ResultError `json:"error,omitempty"`
 }
 
+// ResultError describes why a request failed. For websocket requests, Code is homeassistant's error code, e.g.
+// `not_found`; for REST requests, it is the HTTP status, e.g. `404 Not Found`.
 type ResultError struct {
 	Code    string
 	Message string
 }
 
+func (e ResultError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// IsNotFound returns true if err is, or wraps, a ResultError saying that the requested thing does not exist.
+func IsNotFound(err error) bool {
+	var resultErr ResultError
+	if !errors.As(err, &resultErr) {
+		return false
+	}
+	return resultErr.Code == "not_found" || strings.HasPrefix(resultErr.Code, "404")
+}
+
 func (ResultMessage) Type() string { return "result" }
 
 func init() { RegisterMessageType(ResultMessage{}) }
@@ -0,0 +1,274 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type SceneId string
+
+// SceneListEntry describes a scene as seen through its state. Id is the scene's config ID, and is empty for scenes
+// that cannot be edited via the config API (e.g., those defined outside scenes.yaml). State is the time the scene was
+// last activated.
+type SceneListEntry struct {
+	FriendlyName string   `json:"friendly_name"`
+	Id           SceneId  `json:"id"`
+	EntityId     string   `json:"entity_id"`
+	State        string   `json:"state"`
+	Entities     []string `json:"entities"`
+}
+
+// Scene is the configuration of a single scene: the states each of its entities should be put in when it is
+// activated. Keys not otherwise understood (e.g., the UI's `metadata`) are kept in Extra.
+type Scene struct {
+	Id       SceneId                 `json:"id,omitempty"`
+	Name     string                  `json:"name,omitempty"`
+	Icon     string                  `json:"icon,omitempty"`
+	Entities map[string]*SceneEntity `json:"entities,omitempty"`
+	Extra    map[string]interface{}  `json:"-"`
+}
+
+// SceneEntity is the target state and attributes of one entity in a scene. It may be written either as a mapping of
+// `state` and attributes, or (if there are no attributes) as just the state.
+type SceneEntity struct {
+	State      string
+	Attributes map[string]interface{}
+	shorthand  bool
+}
+
+func (s *SceneEntity) UnmarshalJSON(data []byte) error {
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return err
+	}
+
+	var ret SceneEntity
+	switch g := generic.(type) {
+	case map[string]interface{}:
+		state, ok := sceneState(g["state"])
+		if !ok {
+			return fmt.Errorf("scene entity state should be a string, not %v", g["state"])
+		}
+		ret.State = state
+		for k, v := range g {
+			if k == "state" {
+				continue
+			}
+			if ret.Attributes == nil {
+				ret.Attributes = map[string]interface{}{}
+			}
+			ret.Attributes[k] = v
+		}
+	default:
+		state, ok := sceneState(g)
+		if !ok {
+			return fmt.Errorf("scene entity state should be a string, not %v", g)
+		}
+		ret.State = state
+		ret.shorthand = true
+	}
+
+	*s = ret
+	return nil
+}
+
+// sceneState converts a state as homeassistant does: YAML turns `on` into a boolean, so booleans are converted back.
+func sceneState(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case nil:
+		return "", true
+	case string:
+		return v, true
+	case bool:
+		if v {
+			return "on", true
+		}
+		return "off", true
+	}
+	return "", false
+}
+
+func (s SceneEntity) MarshalJSON() ([]byte, error) {
+	if s.shorthand && len(s.Attributes) == 0 {
+		return json.Marshal(s.State)
+	}
+	m := mergeFields(s.Attributes)
+	if s.State != "" {
+		m["state"] = s.State
+	}
+	return json.Marshal(m)
+}
+
+func (s SceneEntity) MarshalYAML() (interface{}, error) {
+	if s.shorthand && len(s.Attributes) == 0 {
+		return s.State, nil
+	}
+	m := mergeFields(s.Attributes)
+	if s.State != "" {
+		m["state"] = s.State
+	}
+	return yamlMapping(m, []string{"state"})
+}
+
+func (s *SceneEntity) UnmarshalYAML(node *yaml.Node) error {
+	return unmarshalYAMLViaJSON(node, s)
+}
+
+func (s *Scene) UnmarshalJSON(data []byte) error {
+	var generic map[string]interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return err
+	}
+
+	var ret Scene
+	used, err := fieldsFromMap("scene", generic, &ret)
+	if err != nil {
+		return err
+	}
+	ret.Extra = extraFields(generic, used)
+
+	*s = ret
+	return nil
+}
+
+func (s Scene) MarshalJSON() ([]byte, error) {
+	return json.Marshal(mergeFields(s.Extra, fieldsToMap(&s)))
+}
+
+func (s Scene) MarshalYAML() (interface{}, error) {
+	return yamlMapping(mergeFields(s.Extra, fieldsToMap(&s)), []string{"id", "name", "icon", "entities"})
+}
+
+func (s *Scene) UnmarshalYAML(node *yaml.Node) error {
+	return unmarshalYAMLViaJSON(node, s)
+}
+
+var _ json.Unmarshaler = (*Scene)(nil)
+var _ json.Marshaler = Scene{}
+
+// Validate performs basic checks for problems that would cause homeassistant to reject the scene on save.
+func (s *Scene) Validate() error {
+	if s.Name == "" {
+		return errors.New("scene has no name")
+	}
+	if len(s.Entities) == 0 {
+		return errors.New("scene has no entities")
+	}
+	return nil
+}
+
+// SceneFromStates builds a scene that restores each of the given states, including all of their attributes.
+func SceneFromStates(name string, states ...State) *Scene {
+	ret := &Scene{Name: name, Entities: map[string]*SceneEntity{}}
+	for _, state := range states {
+		ret.Entities[state.EntityId] = &SceneEntity{State: state.State, Attributes: mergeFields(state.Attributes)}
+	}
+	return ret
+}
+
+func (c *Client) GetScene(id SceneId) (*Scene, error) {
+	retI, err := c.RawRESTGetAs("config/scene/config/"+string(id), nil, (*Scene)(nil))
+	if err != nil {
+		return nil, err
+	}
+
+	return retI.(*Scene), nil
+}
+
+// SaveScene creates or updates the given scene. If the scene has no Id, one is generated the same way the
+// homeassistant UI does (milliseconds since the epoch) and stored in s.Id before saving.
+func (c *Client) SaveScene(s *Scene) error {
+	if s.Id == "" {
+		s.Id = SceneId(strconv.FormatInt(time.Now().UnixMilli(), 10))
+	}
+
+	_, err := process(c.Post("config/scene/config/"+string(s.Id), s))
+	if err != nil {
+		return fmt.Errorf("saving scene %q: %w", s.Id, err)
+	}
+	return nil
+}
+
+// DeleteScene deletes the scene with the given ID.
+func (c *Client) DeleteScene(id SceneId) error {
+	_, err := process(c.Delete("config/scene/config/"+string(id), nil))
+	if err != nil {
+		return fmt.Errorf("deleting scene %q: %w", id, err)
+	}
+	return nil
+}
+
+func (c *Client) ListScenes() ([]SceneListEntry, error) {
+	states, err := c.ListStates()
+	if err != nil {
+		return nil, fmt.Errorf("scenes are discovered via states, but could not get states: %w", err)
+	}
+	return ScenesFromStates(states), nil
+}
+
+// ScenesFromStates returns the scenes among the given states, as ListScenes does.
+func ScenesFromStates(states []State) []SceneListEntry {
+	var ret []SceneListEntry
+	for _, state := range states {
+		if !strings.HasPrefix(state.EntityId, "scene.") {
+			continue
+		}
+
+		entry := SceneListEntry{
+			EntityId: state.EntityId,
+			State:    state.State,
+		}
+		if id, ok := state.Attributes["id"].(string); ok {
+			entry.Id = SceneId(id)
+		}
+		entry.FriendlyName, _ = state.Attributes["friendly_name"].(string)
+		if ids, ok := state.Attributes["entity_id"].([]interface{}); ok {
+			for _, id := range ids {
+				if id, ok := id.(string); ok {
+					entry.Entities = append(entry.Entities, id)
+				}
+			}
+		}
+
+		ret = append(ret, entry)
+	}
+
+	return ret
+}
+
+// SceneEntityId returns the entity ID of the scene with the given config ID. As a convenience, if id already looks
+// like a scene entity ID, it is returned unchanged.
+func (c *Client) SceneEntityId(id SceneId) (string, error) {
+	if strings.HasPrefix(string(id), "scene.") {
+		return string(id), nil
+	}
+
+	scenes, err := c.ListScenes()
+	if err != nil {
+		return "", err
+	}
+	for _, scene := range scenes {
+		if scene.Id == id {
+			return scene.EntityId, nil
+		}
+	}
+	return "", fmt.Errorf("no scene with id %q", id)
+}
+
+// ActivateScene activates the scene with the given config or entity ID.
+func (c *Client) ActivateScene(id SceneId) error {
+	entityId, err := c.SceneEntityId(id)
+	if err != nil {
+		return err
+	}
+	if _, err := process(c.Post("services/scene/turn_on", map[string]interface{}{"entity_id": entityId})); err != nil {
+		return fmt.Errorf("activating scene %q: %w", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ScriptId is a script's config ID, which is also the object ID of its entity; i.e., script `wind_down` is entity
+// `script.wind_down`.
+type ScriptId string
+
+// ScriptListEntry describes a script as seen through its state.
+type ScriptListEntry struct {
+	FriendlyName  string    `json:"friendly_name"`
+	Id            ScriptId  `json:"id"`
+	EntityId      string    `json:"entity_id"`
+	State         string    `json:"state"`
+	Mode          string    `json:"mode"`
+	Current       int       `json:"current"`
+	LastTriggered time.Time `json:"last_triggered"`
+}
+
+// Script is the configuration of a single script. Like Automation, keys not otherwise understood (e.g., `variables` or
+// `trace`) are kept in Extra so that they survive being decoded and re-encoded. Id is not part of the configuration;
+// it is set by GetScript and used by SaveScript.
+type Script struct {
+	Alias        string                  `json:"alias,omitempty"`
+	Description  string                  `json:"description,omitempty"`
+	Icon         string                  `json:"icon,omitempty"`
+	Fields       map[string]*ScriptField `json:"fields,omitempty"`
+	Sequence     ActionSequence          `json:"sequence,omitempty"`
+	Mode         string                  `json:"mode,omitempty"`
+	Max          int                     `json:"max,omitempty"`
+	UseBlueprint *UseBlueprint           `json:"use_blueprint,omitempty"`
+	Id           ScriptId                `json:"-"`
+	Extra        map[string]interface{}  `json:"-"`
+}
+
+// ScriptField describes a variable that may be passed to a script when it is run.
+type ScriptField struct {
+	Name        string      `json:"name,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Required    bool        `json:"required,omitempty"`
+	Advanced    bool        `json:"advanced,omitempty"`
+	Example     interface{} `json:"example,omitempty"`
+	Default     interface{} `json:"default,omitempty"`
+	Selector    *Selector   `json:"selector,omitempty"`
+}
+
+func (s *Script) UnmarshalJSON(data []byte) error {
+	var generic map[string]interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return err
+	}
+
+	var ret Script
+	used, err := fieldsFromMap("script", generic, &ret)
+	if err != nil {
+		return err
+	}
+	ret.Id = s.Id
+	ret.Extra = extraFields(generic, used)
+
+	*s = ret
+	return nil
+}
+
+func (s Script) MarshalJSON() ([]byte, error) {
+	return json.Marshal(mergeFields(s.Extra, fieldsToMap(&s)))
+}
+
+func (s Script) MarshalYAML() (interface{}, error) {
+	return yamlMapping(mergeFields(s.Extra, fieldsToMap(&s)),
+		[]string{"alias", "description", "icon", "use_blueprint", "fields", "variables", "sequence"},
+		[]string{"mode", "max", "max_exceeded"})
+}
+
+func (s *Script) UnmarshalYAML(node *yaml.Node) error {
+	return unmarshalYAMLViaJSON(node, s)
+}
+
+var _ json.Unmarshaler = (*Script)(nil)
+var _ json.Marshaler = Script{}
+
+// Validate performs basic checks for problems that would cause homeassistant to reject the script on save.
+func (s *Script) Validate() error {
+	if s.UseBlueprint != nil {
+		if s.UseBlueprint.Path == "" {
+			return errors.New("script uses a blueprint, but gives no path")
+		}
+		return nil
+	}
+	if len(s.Sequence) == 0 {
+		return errors.New("script has no sequence")
+	}
+	return nil
+}
+
+func (c *Client) GetScript(id ScriptId) (*Script, error) {
+	retI, err := c.RawRESTGetAs("config/script/config/"+string(id), nil, (*Script)(nil))
+	if err != nil {
+		return nil, err
+	}
+
+	ret := retI.(*Script)
+	ret.Id = id
+	return ret, nil
+}
+
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+// SaveScript creates or updates the given script. If the script has no Id, one is made from its alias the same way
+// the homeassistant UI does (e.g., `Wind Down` becomes `wind_down`) and stored in s.Id before saving.
+func (c *Client) SaveScript(s *Script) error {
+	if s.Id == "" {
+		s.Id = ScriptId(strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(s.Alias), "_"), "_"))
+		if s.Id == "" {
+			return errors.New("script has neither an id nor an alias to make one from")
+		}
+	}
+
+	_, err := process(c.Post("config/script/config/"+string(s.Id), s))
+	if err != nil {
+		return fmt.Errorf("saving script %q: %w", s.Id, err)
+	}
+	return nil
+}
+
+// DeleteScript deletes the script with the given ID.
+func (c *Client) DeleteScript(id ScriptId) error {
+	_, err := process(c.Delete("config/script/config/"+string(id), nil))
+	if err != nil {
+		return fmt.Errorf("deleting script %q: %w", id, err)
+	}
+	return nil
+}
+
+func (c *Client) ListScripts() ([]ScriptListEntry, error) {
+	states, err := c.ListStates()
+	if err != nil {
+		return nil, fmt.Errorf("scripts are discovered via states, but could not get states: %w", err)
+	}
+	return ScriptsFromStates(states)
+}
+
+// ScriptsFromStates returns the scripts among the given states, as ListScripts does.
+func ScriptsFromStates(states []State) ([]ScriptListEntry, error) {
+	var ret []ScriptListEntry
+	for _, state := range states {
+		if !strings.HasPrefix(state.EntityId, "script.") {
+			continue
+		}
+
+		entry := ScriptListEntry{
+			Id:       ScriptId(strings.TrimPrefix(state.EntityId, "script.")),
+			EntityId: state.EntityId,
+			State:    state.State,
+		}
+		entry.FriendlyName, _ = state.Attributes["friendly_name"].(string)
+		entry.Mode, _ = state.Attributes["mode"].(string)
+		if current, ok := state.Attributes["current"].(float64); ok {
+			entry.Current = int(current)
+		}
+		if lt, _ := state.Attributes["last_triggered"].(string); lt != "" {
+			var err error
+			entry.LastTriggered, err = time.Parse(time.RFC3339, lt)
+			if err != nil {
+				return nil, fmt.Errorf("could not parse last_triggered time %q: %w", lt, err)
+			}
+		}
+
+		ret = append(ret, entry)
+	}
+
+	return ret, nil
+}
+
+// RunScript starts the script with the given ID, passing it the given variables, and returns without waiting for it
+// to finish.
+func (c *Client) RunScript(id ScriptId, variables map[string]interface{}) error {
+	data := map[string]interface{}{"entity_id": "script." + string(id)}
+	if len(variables) > 0 {
+		data["variables"] = variables
+	}
+	if _, err := process(c.Post("services/script/turn_on", data)); err != nil {
+		return fmt.Errorf("running script %q: %w", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestScriptRoundTrip(t *testing.T) {
+	input := `{
+  "alias": "Wind down",
+  "fields": {
+    "brightness": {
+      "name": "Brightness",
+      "required": true,
+      "default": 20,
+      "selector": {
+        "number": {
+          "max": 100,
+          "min": 0
+        }
+      }
+    }
+  },
+  "max": 3,
+  "mode": "queued",
+  "sequence": [
+    {
+      "action": "light.turn_on",
+      "data": {
+        "brightness_pct": "{{ brightness }}"
+      },
+      "target": {
+        "area_id": "bedroom"
+      }
+    },
+    {
+      "delay": "00:10:00"
+    }
+  ],
+  "variables": {
+    "x": 1
+  }
+}`
+
+	var script Script
+	require.NoError(t, json.Unmarshal([]byte(input), &script))
+	require.Len(t, script.Sequence, 2)
+	require.Equal(t, 3, script.Max)
+	require.True(t, script.Fields["brightness"].Required)
+	require.Equal(t, map[string]interface{}{"variables": map[string]interface{}{"x": float64(1)}}, script.Extra)
+	require.NoError(t, script.Validate())
+
+	got, err := json.MarshalIndent(script, "", "  ")
+	require.NoError(t, err)
+	require.Equal(t, input, string(got))
+
+	yb, err := EncodeYAML(script)
+	require.NoError(t, err)
+	require.Equal(t, `alias: Wind down
+fields:
+  brightness:
+    name: Brightness
+    required: true
+    default: 20
+    selector:
+      number:
+        max: 100
+        min: 0
+variables:
+  x: 1
+sequence:
+  - action: light.turn_on
+    data:
+      brightness_pct: '{{ brightness }}'
+    target:
+      area_id: bedroom
+  - delay: "00:10:00"
+mode: queued
+max: 3
+`, string(yb))
+
+	var fromYAML Script
+	require.NoError(t, yaml.Unmarshal(yb, &fromYAML))
+	got, err = json.MarshalIndent(fromYAML, "", "  ")
+	require.NoError(t, err)
+	require.Equal(t, input, string(got))
+}
+
+func TestSceneRoundTrip(t *testing.T) {
+	var scene Scene
+	require.NoError(t, yaml.Unmarshal([]byte(`
+id: "1700000000000"
+name: Evening
+entities:
+  light.lamp: on
+  light.kitchen:
+    state: on
+    brightness: 128
+  media_player.tv: "off"
+metadata:
+  light.lamp:
+    entity_only: true
+`), &scene))
+
+	require.Equal(t, "on", scene.Entities["light.lamp"].State)
+	require.Equal(t, "on", scene.Entities["light.kitchen"].State)
+	require.Equal(t, float64(128), scene.Entities["light.kitchen"].Attributes["brightness"])
+	require.Contains(t, scene.Extra, "metadata")
+	require.NoError(t, scene.Validate())
+
+	got, err := json.Marshal(scene)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"id": "1700000000000",
+		"name": "Evening",
+		"entities": {
+			"light.lamp": "on",
+			"light.kitchen": {"state": "on", "brightness": 128},
+			"media_player.tv": "off"
+		},
+		"metadata": {"light.lamp": {"entity_only": true}}
+	}`, string(got))
+
+	snapshot := SceneFromStates("Now",
+		State{EntityId: "light.lamp", State: "on", Attributes: map[string]interface{}{"brightness": float64(50)}})
+	got, err = json.Marshal(snapshot)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"name": "Now", "entities": {"light.lamp": {"state": "on", "brightness": 50}}}`, string(got))
+}
+
+func TestSaveScriptAndScene(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		_, _ = w.Write([]byte(`{"result": "ok"}`))
+	}))
+	defer server.Close()
+
+	c := &Client{Server: server.URL}
+	script := &Script{Alias: "Wind Down!", Sequence: ActionSequence{{Action: &SceneAction{Scene: "scene.x"}}}}
+	require.NoError(t, c.SaveScript(script))
+	require.Equal(t, ScriptId("wind_down"), script.Id)
+	require.NoError(t, c.DeleteScript(script.Id))
+	require.Error(t, c.SaveScript(&Script{}))
+
+	scene := &Scene{Name: "x", Entities: map[string]*SceneEntity{"light.x": {State: "on"}}}
+	require.NoError(t, c.SaveScene(scene))
+	require.NotEmpty(t, scene.Id)
+
+	require.Equal(t, []string{
+		"POST /api/config/script/config/wind_down",
+		"DELETE /api/config/script/config/wind_down",
+		"POST /api/config/scene/config/" + string(scene.Id),
+	}, requests)
+}
@@ -0,0 +1,346 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Selector describes the kind of value a service field or blueprint input accepts, as homeassistant's UI uses to choose
+// an input for it. It is written as a mapping with a single key, the selector's kind, whose value configures it; e.g.,
+// `{"number": {"min": 0, "max": 100}}`. The configuration of number, select, and entity selectors is decoded; for
+// every kind, Config holds the configuration as given.
+type Selector struct {
+	Kind   string
+	Config map[string]interface{}
+
+	Number *NumberSelector
+	Select *SelectSelector
+	Entity *EntitySelector
+}
+
+type NumberSelector struct {
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+	// Step is the increment the UI uses between values, or zero if it has none. It is only a hint; homeassistant accepts
+	// values between the steps.
+	Step              float64 `json:"-"`
+	Mode              string  `json:"mode,omitempty"`
+	UnitOfMeasurement string  `json:"unit_of_measurement,omitempty"`
+}
+
+type SelectSelector struct {
+	Options     []SelectOption `json:"options"`
+	Multiple    bool           `json:"multiple,omitempty"`
+	CustomValue bool           `json:"custom_value,omitempty"`
+}
+
+// SelectOption is one option of a select selector. Options may be given as plain strings, in which case Label is the
+// same as Value.
+type SelectOption struct {
+	Value string `json:"value"`
+	Label string `json:"label"`
+}
+
+// EntitySelector restricts the entities that may be selected. Domains holds the domains allowed by either `domain` or
+// any of the `filter` entries; it is empty if any domain is allowed.
+type EntitySelector struct {
+	Domains  []string `json:"-"`
+	Multiple bool     `json:"multiple,omitempty"`
+}
+
+func (s *Selector) UnmarshalJSON(data []byte) error {
+	var generic map[string]interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return err
+	}
+	if len(generic) != 1 {
+		return fmt.Errorf("selector should have exactly one kind, but has %d", len(generic))
+	}
+
+	var ret Selector
+	for kind, config := range generic {
+		ret.Kind = kind
+		ret.Config, _ = config.(map[string]interface{})
+		if ret.Config == nil {
+			ret.Config = map[string]interface{}{}
+		}
+	}
+
+	switch ret.Kind {
+	case "number", "color_temp":
+		ret.Number = &NumberSelector{}
+		if err := convertInto(ret.Config, reflect.ValueOf(ret.Number).Elem()); err != nil {
+			return fmt.Errorf("%s selector: %w", ret.Kind, err)
+		}
+		// step may also be `any`
+		ret.Number.Step, _ = ret.Config["step"].(float64)
+	case "select":
+		multiple, _ := ret.Config["multiple"].(bool)
+		custom, _ := ret.Config["custom_value"].(bool)
+		ret.Select = &SelectSelector{Multiple: multiple, CustomValue: custom}
+		options, _ := ret.Config["options"].([]interface{})
+		for _, option := range options {
+			switch o := option.(type) {
+			case string:
+				ret.Select.Options = append(ret.Select.Options, SelectOption{o, o})
+			case map[string]interface{}:
+				value, _ := o["value"].(string)
+				label, _ := o["label"].(string)
+				ret.Select.Options = append(ret.Select.Options, SelectOption{value, label})
+			}
+		}
+	case "entity":
+		multiple, _ := ret.Config["multiple"].(bool)
+		ret.Entity = &EntitySelector{Multiple: multiple, Domains: domains(ret.Config["domain"])}
+		filters, _ := ret.Config["filter"].([]interface{})
+		if filter, ok := ret.Config["filter"].(map[string]interface{}); ok {
+			filters = append(filters, filter)
+		}
+		for _, filterI := range filters {
+			if filter, ok := filterI.(map[string]interface{}); ok {
+				ret.Entity.Domains = append(ret.Entity.Domains, domains(filter["domain"])...)
+			}
+		}
+	}
+	*s = ret
+	return nil
+}
+
+func (s Selector) MarshalJSON() ([]byte, error) {
+	config := s.Config
+	if config == nil {
+		config = map[string]interface{}{}
+	}
+	return json.Marshal(map[string]interface{}{s.Kind: config})
+}
+
+var _ json.Unmarshaler = (*Selector)(nil)
+var _ json.Marshaler = Selector{}
+
+// domains returns v as a list of domains, whether it is a single domain or a list of them.
+func domains(v interface{}) []string {
+	switch d := v.(type) {
+	case string:
+		return []string{d}
+	case []interface{}:
+		var ret []string
+		for _, di := range d {
+			if ds, ok := di.(string); ok {
+				ret = append(ret, ds)
+			}
+		}
+		return ret
+	}
+	return nil
+}
+
+// Multiple returns true if the selector accepts a list of values.
+func (s *Selector) Multiple() bool {
+	multiple, _ := s.Config["multiple"].(bool)
+	return multiple
+}
+
+// Validate checks that value is acceptable to the selector. Values that are templates or tags (e.g., `!secret`) cannot
+// be checked, and are accepted. Kinds of selector not described here accept any value.
+func (s *Selector) Validate(value interface{}) error {
+	if str, ok := value.(string); ok && (isTemplate(str) || isYAMLTag(str)) {
+		return nil
+	}
+
+	switch s.Kind {
+	case "entity", "device", "area", "floor", "label":
+		return s.each(value, func(v interface{}) error {
+			id, ok := v.(string)
+			if !ok {
+				return fmt.Errorf("expected %s ID, not %v", s.Kind, v)
+			}
+			if s.Kind == "entity" {
+				return s.Entity.validate(id)
+			}
+			return nil
+		})
+	case "number", "color_temp":
+		return s.Number.validate(value)
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("expected true or false, not %v", value)
+		}
+	case "text", "template", "icon", "theme", "conversation_agent":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("expected a string, not %v", value)
+		}
+	case "select":
+		return s.each(value, s.Select.validate)
+	case "color_rgb":
+		rgb, ok := value.([]interface{})
+		if !ok || len(rgb) != 3 {
+			return fmt.Errorf("expected [red, green, blue], not %v", value)
+		}
+		for _, c := range rgb {
+			if f, ok := c.(float64); !ok || f < 0 || f > 255 || f != math.Trunc(f) {
+				return fmt.Errorf("expected [red, green, blue] with each between 0 and 255, not %v", value)
+			}
+		}
+	case "duration":
+		if _, err := parseDuration(value); err != nil {
+			return err
+		}
+	case "time":
+		str, _ := value.(string)
+		if !validClock(str) {
+			return fmt.Errorf("expected a time as HH:MM or HH:MM:SS, not %v", value)
+		}
+	case "date":
+		str, _ := value.(string)
+		if _, err := time.Parse("2006-01-02", str); err != nil {
+			return fmt.Errorf("expected a date as YYYY-MM-DD, not %v", value)
+		}
+	case "datetime":
+		str, _ := value.(string)
+		if _, err := time.Parse("2006-01-02 15:04:05", str); err != nil {
+			return fmt.Errorf("expected a date and time as YYYY-MM-DD HH:MM:SS, not %v", value)
+		}
+	case "object":
+		// any value is an object
+	}
+	return nil
+}
+
+// each calls validate for value, or for each value if the selector accepts multiple values.
+func (s *Selector) each(value interface{}, validate func(v interface{}) error) error {
+	list, isList := value.([]interface{})
+	if !s.Multiple() {
+		if isList {
+			return errors.New("expected a single value, not a list")
+		}
+		return validate(value)
+	}
+	if !isList {
+		list = []interface{}{value}
+	}
+	for _, v := range list {
+		if err := validate(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *EntitySelector) validate(entityId string) error {
+	if !strings.Contains(entityId, ".") {
+		return fmt.Errorf("expected an entity ID, not %v", entityId)
+	}
+	if !e.Allows(entityId) {
+		return fmt.Errorf("%s is not in domain %s", entityId, strings.Join(e.Domains, " or "))
+	}
+	return nil
+}
+
+// Allows returns true if the entity with the given ID is in one of the selector's domains, or if the selector allows
+// any domain.
+func (e *EntitySelector) Allows(entityId string) bool {
+	if e == nil || len(e.Domains) == 0 {
+		return true
+	}
+	domain := strings.SplitN(entityId, ".", 2)[0]
+	for _, d := range e.Domains {
+		if d == domain {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *NumberSelector) validate(value interface{}) error {
+	var f float64
+	switch v := value.(type) {
+	case float64:
+		f = v
+	case int:
+		f = float64(v)
+	default:
+		return fmt.Errorf("expected a number, not %v", value)
+	}
+	if n == nil {
+		return nil
+	}
+	if n.Min != nil && f < *n.Min {
+		return fmt.Errorf("%v is less than the minimum, %v", f, *n.Min)
+	}
+	if n.Max != nil && f > *n.Max {
+		return fmt.Errorf("%v is more than the maximum, %v", f, *n.Max)
+	}
+	return nil
+}
+
+func (s *SelectSelector) validate(value interface{}) error {
+	if s == nil || s.CustomValue {
+		return nil
+	}
+
+	var options []string
+	for _, option := range s.Options {
+		options = append(options, option.Value)
+	}
+	sort.Strings(options)
+
+	str, _ := value.(string)
+	i := sort.SearchStrings(options, str)
+	if i == len(options) || options[i] != str {
+		return fmt.Errorf("%v is not one of %s", value, strings.Join(options, ", "))
+	}
+	return nil
+}
+
+// validClock returns true if s is a time of day as HH:MM or HH:MM:SS.
+func validClock(s string) bool {
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if _, err := time.Parse(layout, s); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Coerce converts a value given on the command line to the type the selector expects: numbers for number selectors,
+// booleans for boolean selectors, and lists (split on commas) for selectors that accept multiple values or colors.
+// Object selectors, and values that cannot be converted, are parsed as YAML.
+func (s *Selector) Coerce(value string) (interface{}, error) {
+	switch s.Kind {
+	case "number", "color_temp":
+		return strconv.ParseFloat(value, 64)
+	case "boolean":
+		return strconv.ParseBool(value)
+	case "color_rgb":
+		var ret []interface{}
+		for _, part := range strings.Split(strings.Trim(value, "[]"), ",") {
+			c, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil {
+				return nil, fmt.Errorf("expected red,green,blue: %w", err)
+			}
+			ret = append(ret, c)
+		}
+		return ret, nil
+	case "object", "duration":
+		return DecodeYAML([]byte(value))
+	}
+
+	if s.Multiple() && !strings.HasPrefix(value, "[") {
+		var ret []interface{}
+		for _, part := range strings.Split(value, ",") {
+			ret = append(ret, part)
+		}
+		return ret, nil
+	}
+	if strings.HasPrefix(value, "[") || strings.HasPrefix(value, "{") {
+		return DecodeYAML([]byte(value))
+	}
+	return value, nil
+}
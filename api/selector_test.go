@@ -0,0 +1,69 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelector(t *testing.T) {
+	tests := []struct {
+		selector string
+		value    interface{}
+		wantErr  bool
+	}{
+		{`{"number": {"min": 0, "max": 10, "step": 0.5}}`, 2.5, false},
+		{`{"number": {"min": 0, "max": 10, "step": 0.5}}`, 2.25, false},
+		{`{"number": {"min": 0, "max": 10, "step": 0.5}}`, 10.5, true},
+		{`{"number": {"min": 0, "max": 10, "step": "any"}}`, 2.25, false},
+		{`{"number": {}}`, "5", true},
+		{`{"boolean": {}}`, true, false},
+		{`{"boolean": {}}`, "yes", true},
+		{`{"select": {"options": ["a", "b"], "multiple": true}}`, []interface{}{"a", "b"}, false},
+		{`{"select": {"options": ["a", "b"], "multiple": true}}`, []interface{}{"a", "c"}, true},
+		{`{"select": {"options": ["a", "b"], "custom_value": true}}`, "c", false},
+		{`{"entity": {"domain": ["light", "switch"], "multiple": true}}`, "switch.fan", false},
+		{`{"entity": {"domain": "light"}}`, "switch.fan", true},
+		{`{"duration": {}}`, map[string]interface{}{"minutes": float64(5)}, false},
+		{`{"duration": {}}`, "00:05:00", false},
+		{`{"time": {}}`, "07:30", false},
+		{`{"time": {}}`, "7:30pm", true},
+		{`{"date": {}}`, "2024-02-30", true},
+		{`{"object": {}}`, map[string]interface{}{"a": "b"}, false},
+		{`{"some_future_kind": {}}`, "anything", false},
+	}
+	for _, tt := range tests {
+		var s Selector
+		require.NoError(t, json.Unmarshal([]byte(tt.selector), &s), tt.selector)
+		err := s.Validate(tt.value)
+		if tt.wantErr {
+			require.Error(t, err, "%s %v", tt.selector, tt.value)
+		} else {
+			require.NoError(t, err, "%s %v", tt.selector, tt.value)
+		}
+	}
+}
+
+func TestSelector_Coerce(t *testing.T) {
+	tests := []struct {
+		selector string
+		value    string
+		want     interface{}
+	}{
+		{`{"number": {}}`, "2.5", 2.5},
+		{`{"boolean": {}}`, "true", true},
+		{`{"color_rgb": {}}`, "255,0,10", []interface{}{float64(255), float64(0), float64(10)}},
+		{`{"entity": {"multiple": true}}`, "light.a,light.b", []interface{}{"light.a", "light.b"}},
+		{`{"entity": {}}`, "light.a", "light.a"},
+		{`{"object": {}}`, "{a: 1}", map[string]interface{}{"a": float64(1)}},
+		{`{"duration": {}}`, "{minutes: 5}", map[string]interface{}{"minutes": float64(5)}},
+	}
+	for _, tt := range tests {
+		var s Selector
+		require.NoError(t, json.Unmarshal([]byte(tt.selector), &s), tt.selector)
+		got, err := s.Coerce(tt.value)
+		require.NoError(t, err, "%s %q", tt.selector, tt.value)
+		require.Equal(t, tt.want, got, "%s %q", tt.selector, tt.value)
+	}
+}
@@ -5,8 +5,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"reflect"
+	"sort"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 )
@@ -22,15 +23,35 @@ type Service struct {
 	Name        string                   `json:"name"`
 	Description string                   `json:"description"`
 	Fields      map[string]*ServiceField `json:"fields"`
+	// Target, if set, describes the entities the service may target, in the same form as an entity selector's filter.
+	Target   map[string]interface{} `json:"target,omitempty"`
+	Response *ServiceResponse       `json:"response,omitempty"`
 }
 
+// ServiceResponse is set for services that can return response data. If Optional is false, the service must be called
+// with CallOptions.ReturnResponse set.
+type ServiceResponse struct {
+	Optional bool `json:"optional"`
+}
+
+// ServiceField describes one field of a service. Name is the field's key, and Label the name homeassistant shows for
+// it. Type is inferred from the field's selector if it has one, and otherwise from its values, example, or default; it
+// is empty if none of these says. Fields of a section are held in Fields; ListServices flattens them into the service's
+// fields, since sections affect only how the UI presents them.
 type ServiceField struct {
-	Name        string           `json:"name"`
-	Description string           `json:"description"`
-	Type        ServiceFieldType `json:"type"`
-	Example     interface{}      `json:"example"`
-	Values      []interface{}    `json:"values"`
-	Default     interface{}      `json:"default"`
+	Name        string                   `json:"name"`
+	Label       string                   `json:"label"`
+	Description string                   `json:"description"`
+	Type        ServiceFieldType         `json:"type"`
+	Example     interface{}              `json:"example"`
+	Values      []interface{}            `json:"values"`
+	Default     interface{}              `json:"default"`
+	Required    bool                     `json:"required"`
+	Advanced    bool                     `json:"advanced"`
+	Selector    *Selector                `json:"selector"`
+	Filter      map[string]interface{}   `json:"filter"`
+	Fields      map[string]*ServiceField `json:"fields"`
+	Collapsed   bool                     `json:"collapsed"`
 }
 
 type ServiceFieldType string
@@ -46,108 +67,104 @@ func (s *ServiceField) UnmarshalJSON(data []byte) error {
 	dec := json.NewDecoder(bytes.NewBuffer(data))
 	dec.UseNumber()
 
-	const (
-		stateInit = iota
-		stateKey
-		stateDescription
-		stateExample
-		stateDefault
-		stateEnd
-	)
-
-	state := 0
-loop:
-	for {
-		t, err := dec.Token()
-		if err == io.EOF {
-			break
+	var generic map[string]json.RawMessage
+	if err := dec.Decode(&generic); err != nil {
+		return fmt.Errorf("decoding service field %q: %w", string(data), err)
+	}
+
+	var ret ServiceField
+	for key, raw := range generic {
+		var err error
+		switch key {
+		case "name":
+			err = json.Unmarshal(raw, &ret.Label)
+		case "description":
+			err = json.Unmarshal(raw, &ret.Description)
+		case "example", "exampl" /* for fuck's sake, homeassistant */ :
+			ret.Example, err = ret.inferType(raw)
+		case "default":
+			ret.Default, err = ret.inferType(raw)
+		case "values":
+			err = json.Unmarshal(raw, &ret.Values)
+		case "required":
+			err = json.Unmarshal(raw, &ret.Required)
+		case "advanced":
+			err = json.Unmarshal(raw, &ret.Advanced)
+		case "selector":
+			err = json.Unmarshal(raw, &ret.Selector)
+		case "filter":
+			err = json.Unmarshal(raw, &ret.Filter)
+		case "fields":
+			err = json.Unmarshal(raw, &ret.Fields)
+		case "collapsed":
+			err = json.Unmarshal(raw, &ret.Collapsed)
+		default:
+			// homeassistant adds keys from time to time; none of them matter for calling the service
+			logrus.Tracef("ignoring service field key %q", key)
 		}
 		if err != nil {
-			return fmt.Errorf("decoding service field %q: %w", string(data), err)
+			return fmt.Errorf("decoding service field %q: key %q: %w", string(data), key, err)
 		}
-		switch state {
-		case stateInit:
-			delim, ok := t.(json.Delim)
-			if !ok || delim != '{' {
-				return fmt.Errorf("decoding service field %q: expected { but "+
-					"got %v", string(data), t)
-			}
-			state = stateKey
-		case stateKey:
-			key, ok := t.(string)
-			if !ok {
-				return fmt.Errorf("decoding service field %q: expected "+
-					"string but got %v", string(data), t)
-			}
-			if key == "description" {
-				state = stateDescription
-			} else if key == "example" || key == "exampl" /* for fuck's sake, homeassistant */ {
-				state = stateExample
-			} else if key == "values" {
-				s.Type = Values
-				if err := dec.Decode(&(s.Values)); err != nil {
-					return fmt.Errorf("decoding service field %q: expected list "+
-						"of strings but: %w", string(data), err)
+	}
+
+	// values and the selector say more about a field than an example does, so they win
+	if ret.Values != nil {
+		ret.Type = Values
+	}
+	if ret.Selector != nil {
+		switch ret.Selector.Kind {
+		case "number":
+			ret.Type = Number
+		case "boolean":
+			ret.Type = Boolean
+		case "text":
+			ret.Type = String
+		case "select":
+			if !ret.Selector.Multiple() && !ret.Selector.Select.CustomValue {
+				ret.Type = Values
+				ret.Values = nil
+				for _, option := range ret.Selector.Select.Options {
+					ret.Values = append(ret.Values, option.Value)
 				}
-			} else if key == "default" {
-				state = stateDefault
-			} else {
-				return fmt.Errorf("unexpected key value %q in service field "+
-					"%q", key, string(data))
 			}
-		case stateDescription:
-			desc, ok := t.(string)
-			if !ok {
-				return fmt.Errorf("decoding service field %q: expected "+
-					"string for description value, but got %T %v", string(data), t, t)
-			}
-			s.Description = desc
-			state = stateKey
-		case stateExample:
-			switch v := t.(type) {
-			case string:
-				s.Type = String
-				s.Example = v
-			case json.Number:
-				s.Type = Number
-				s.Example, _ = v.Float64()
-			case bool:
-				s.Type = Boolean
-				s.Example = v
-			default:
-				return fmt.Errorf("decoding service field %q: unepected example type %T", string(data), t)
-			}
-			state = stateKey
-		case stateDefault:
-			switch v := t.(type) {
-			case string:
-				s.Type = String
-				s.Default = v
-			case json.Number:
-				s.Type = Number
-				s.Default, _ = v.Float64()
-			case bool:
-				s.Type = Boolean
-				s.Default = v
-			default:
-				return fmt.Errorf("decoding service field %q: unepected example type %T", string(data), t)
-			}
-			state = stateKey
-		case stateEnd:
-			delim, ok := t.(json.Delim)
-			if !ok || delim != '}' {
-				return fmt.Errorf("decoding service field %q: expected } but got %c", string(data), delim)
-			}
-			break loop
-		}
-		if !dec.More() {
-			state = stateEnd
+		default:
+			ret.Type = ""
 		}
 	}
 
+	*s = ret
 	return nil
 }
 
+// inferType decodes an example or default value, and sets the field's type to match it. Lists and objects are decoded
+// but say nothing about the type. A null value is no value at all.
+func (s *ServiceField) inferType(raw json.RawMessage) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewBuffer(raw))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	switch vv := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		s.Type = String
+		return vv, nil
+	case json.Number:
+		s.Type = Number
+		return vv.Float64()
+	case bool:
+		s.Type = Boolean
+		return vv, nil
+	case []interface{}, map[string]interface{}:
+		var ret interface{}
+		return ret, json.Unmarshal(raw, &ret)
+	}
+	return nil, fmt.Errorf("unexpected type %T", v)
+}
+
 //var _ json.Marshaler = (*ServiceField)(nil)
 var _ json.Unmarshaler = (*ServiceField)(nil)
 
@@ -161,20 +178,48 @@ func (c *Client) ListServices() ([]Service, error) {
 		return nil, err
 	}
 
+	return servicesFromDomains(c, servicesI.([]domain)), nil
+}
+
+// ParseServices parses a list of services in the form returned by homeassistant's `/api/services` endpoint, e.g. as
+// saved by `ghastly raw services`. The returned services have no client, so they can be inspected but not called.
+func ParseServices(data []byte) ([]Service, error) {
+	var domains []domain
+	if err := json.Unmarshal(data, &domains); err != nil {
+		return nil, fmt.Errorf("parsing services: %w", err)
+	}
+	return servicesFromDomains(nil, domains), nil
+}
+
+func servicesFromDomains(c *Client, domains []domain) []Service {
 	var ret []Service
-	for _, domain := range servicesI.([]domain) {
+	for _, domain := range domains {
 		for name, svc := range domain.Services {
 			svc.client = c
 			svc.Domain = domain.Domain
 			svc.Name = name
-			for name, f := range svc.Fields {
-				f.Name = name
-			}
+			svc.Fields = flattenFields(svc.Fields)
 			ret = append(ret, svc)
 		}
 	}
+	return ret
+}
 
-	return ret, nil
+// flattenFields returns fields with each section replaced by the fields it contains, and with each field's Name set
+// to its key.
+func flattenFields(fields map[string]*ServiceField) map[string]*ServiceField {
+	ret := map[string]*ServiceField{}
+	for name, f := range fields {
+		if f.Fields != nil {
+			for name, sf := range flattenFields(f.Fields) {
+				ret[name] = sf
+			}
+			continue
+		}
+		f.Name = name
+		ret[name] = f
+	}
+	return ret
 }
 
 var ServiceNotFound = errors.New("not found")
@@ -198,26 +243,46 @@ func (c *Client) GetService(domain, service string) (*Service, error) {
 	return nil, fmt.Errorf("service %q in domain %q %w", service, domain, ServiceNotFound)
 }
 
-func (s *Service) Call(data map[string]interface{}) ([]State, error) {
+// validate checks that each entry of data is a field of the service, acceptable to the field's selector or else of the
+// field's type, and that each required field is given.
+func (s *Service) validate(data map[string]interface{}) error {
+	var missing []string
+	for name, field := range s.Fields {
+		if _, ok := data[name]; field.Required && !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("service %s.%s requires field(s) %s", s.Domain, s.Name, strings.Join(missing, ", "))
+	}
+
 	for f, v := range data {
 		logrus.Tracef("validating %s: %v", f, v)
 		field, ok := s.Fields[f]
 		if !ok {
-			return nil, fmt.Errorf("service %s.%s does not have field %q", s.Domain, s.Name, f)
+			return fmt.Errorf("service %s.%s does not have field %q", s.Domain, s.Name, f)
+		}
+
+		if field.Selector != nil {
+			if err := field.Selector.Validate(v); err != nil {
+				return fmt.Errorf("service %s.%s field %s: %w", s.Domain, s.Name, f, err)
+			}
+			continue
 		}
 
 		switch field.Type {
 		case String:
 			_, ok := v.(string)
 			if !ok {
-				return nil, fmt.Errorf("service %s.%s expects field %s to be %s, "+
+				return fmt.Errorf("service %s.%s expects field %s to be %s, "+
 					"but %T was provided", s.Domain, s.Name, f, field.Type, v)
 			}
 		case Number:
 			_, fOk := v.(float64)
 			_, iOk := v.(int)
 			if !fOk && !iOk {
-				return nil, fmt.Errorf("service %s.%s expects field %s to be int "+
+				return fmt.Errorf("service %s.%s expects field %s to be int "+
 					"or float64, but %T was provided", s.Domain, s.Name, f, v)
 			}
 		case Values:
@@ -230,16 +295,23 @@ func (s *Service) Call(data map[string]interface{}) ([]State, error) {
 			}
 			if !found {
 				vs, _ := json.Marshal(field.Values)
-				return nil, fmt.Errorf("service %s.%s field %s only allows "+
+				return fmt.Errorf("service %s.%s field %s only allows "+
 					"values %s; %q was not found", s.Domain, s.Name, f, vs, v)
 			}
 		case Boolean:
 			if _, ok := v.(bool); !ok {
-				return nil, fmt.Errorf("service %s.%s expects field %s to be %s, "+
+				return fmt.Errorf("service %s.%s expects field %s to be %s, "+
 					"but %T was provided", s.Domain, s.Name, f, field.Type, v)
 			}
 		}
 	}
+	return nil
+}
+
+func (s *Service) Call(data map[string]interface{}) ([]State, error) {
+	if err := s.validate(data); err != nil {
+		return nil, err
+	}
 
 	res, err := s.client.Post(fmt.Sprintf("/api/services/%s/%s", s.Domain,
 		s.Name), data)
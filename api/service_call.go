@@ -0,0 +1,111 @@
+package api
+
+import (
+	"fmt"
+)
+
+// Target selects what a service acts on. Each kind of ID expands to the entities it contains; e.g., an area ID selects
+// the entities in that area, and those of the devices in that area.
+type Target struct {
+	EntityId []string `json:"entity_id,omitempty"`
+	DeviceId []string `json:"device_id,omitempty"`
+	AreaId   []string `json:"area_id,omitempty"`
+	FloorId  []string `json:"floor_id,omitempty"`
+	LabelId  []string `json:"label_id,omitempty"`
+}
+
+// IsEmpty returns true if the target selects nothing.
+func (t *Target) IsEmpty() bool {
+	return t == nil ||
+		len(t.EntityId)+len(t.DeviceId)+len(t.AreaId)+len(t.FloorId)+len(t.LabelId) == 0
+}
+
+// CallOptions describes a service call. If ReturnResponse is true, the service's response data is requested; some
+// services (e.g., `weather.get_forecasts`) fail unless it is, and others fail if it is. If ChangedStates is true, the
+// states changed by the call are found afterward, which costs a second request that retrieves every state.
+type CallOptions struct {
+	Target         *Target
+	Data           map[string]interface{}
+	ReturnResponse bool
+	ChangedStates  bool
+}
+
+// Float returns a pointer to v, for setting optional numeric fields of service data.
+func Float(v float64) *float64 {
+	return &v
+}
+
+// Bool returns a pointer to v, for setting optional boolean fields of service data.
+func Bool(v bool) *bool {
+	return &v
+}
+
+// CallResult is the outcome of a service call. ChangedStates is set only if it was requested, and holds the states
+// changed by the call itself (i.e., those whose context is the call's context) as of when the call returned; changes
+// an integration makes later, such as after polling a device, are not included. Response is set only if it was
+// requested.
+type CallResult struct {
+	Context       TraceContext           `json:"context"`
+	ChangedStates []State                `json:"changed_states"`
+	Response      map[string]interface{} `json:"response,omitempty"`
+}
+
+type CallServiceMessage struct {
+	Domain         string                 `json:"domain"`
+	Service        string                 `json:"service"`
+	ServiceData    map[string]interface{} `json:"service_data,omitempty"`
+	Target         *Target                `json:"target,omitempty"`
+	ReturnResponse bool                   `json:"return_response,omitempty"`
+}
+
+func (CallServiceMessage) Type() string { return "call_service" }
+
+// CallService calls the given service via the websocket API, without checking opts against the service's fields; see
+// Service.CallWithOptions.
+func (c *Client) CallService(domain, service string, opts CallOptions) (*CallResult, error) {
+	msg := CallServiceMessage{
+		Domain:         domain,
+		Service:        service,
+		ServiceData:    opts.Data,
+		ReturnResponse: opts.ReturnResponse,
+	}
+	if !opts.Target.IsEmpty() {
+		msg.Target = opts.Target
+	}
+
+	retI, err := c.RawWebsocketRequestAs(msg, (*CallResult)(nil))
+	if err != nil {
+		return nil, fmt.Errorf("calling %s.%s: %w", domain, service, err)
+	}
+	ret, ok := retI.(*CallResult)
+	if !ok {
+		return nil, fmt.Errorf("received %T instead of *CallResult", retI)
+	}
+
+	if !opts.ChangedStates {
+		return ret, nil
+	}
+
+	// the websocket API does not report changed states, but states changed by the call carry its context
+	states, err := c.ListStates()
+	if err != nil {
+		return nil, fmt.Errorf("%s.%s was called, but changed states could not be retrieved: %w", domain, service,
+			err)
+	}
+	ret.ChangedStates = []State{}
+	for _, state := range states {
+		if ret.Context.Id != "" && state.Context.Id == ret.Context.Id {
+			ret.ChangedStates = append(ret.ChangedStates, state)
+		}
+	}
+
+	return ret, nil
+}
+
+// CallWithOptions checks opts.Data against the service's fields, and then calls the service as CallService does.
+func (s *Service) CallWithOptions(opts CallOptions) (*CallResult, error) {
+	if err := s.validate(opts.Data); err != nil {
+		return nil, err
+	}
+	return s.client.CallService(s.Domain, s.Name, opts)
+}
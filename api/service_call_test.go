@@ -0,0 +1,64 @@
+package api
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallService(t *testing.T) {
+	var calls []map[string]interface{}
+	getStates := 0
+	c := fakeWebsocket(t, func(request map[string]interface{}) (interface{}, error) {
+		switch request["type"] {
+		case "call_service":
+			calls = append(calls, request)
+			ret := map[string]interface{}{"context": map[string]interface{}{"id": "ctx1"}}
+			if request["return_response"] == true {
+				ret["response"] = map[string]interface{}{"weather.home": map[string]interface{}{"forecast": []interface{}{}}}
+			}
+			return ret, nil
+		case "get_states":
+			getStates++
+			return []interface{}{
+				map[string]interface{}{"entity_id": "light.kitchen", "state": "on",
+					"context": map[string]interface{}{"id": "ctx1"}},
+				map[string]interface{}{"entity_id": "light.hall", "state": "on",
+					"context": map[string]interface{}{"id": "ctx0"}},
+			}, nil
+		}
+		return nil, fmt.Errorf("unexpected request %v", request["type"])
+	})
+
+	result, err := c.CallService("light", "turn_on", CallOptions{
+		Target:        &Target{AreaId: []string{"kitchen"}},
+		Data:          map[string]interface{}{"brightness_pct": float64(50)},
+		ChangedStates: true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "ctx1", result.Context.Id)
+	require.Len(t, result.ChangedStates, 1)
+	require.Equal(t, "light.kitchen", result.ChangedStates[0].EntityId)
+	require.Nil(t, result.Response)
+
+	result, err = c.CallService("weather", "get_forecasts", CallOptions{
+		Target:         &Target{},
+		ReturnResponse: true,
+	})
+	require.NoError(t, err)
+	require.Contains(t, result.Response, "weather.home")
+	require.Nil(t, result.ChangedStates)
+	require.Equal(t, 1, getStates, "states are only retrieved on request")
+
+	require.Len(t, calls, 2)
+	require.Equal(t, map[string]interface{}{"area_id": []interface{}{"kitchen"}}, calls[0]["target"])
+	require.Equal(t, map[string]interface{}{"brightness_pct": float64(50)}, calls[0]["service_data"])
+	require.NotContains(t, calls[0], "return_response")
+	require.NotContains(t, calls[1], "target")
+	require.Equal(t, true, calls[1]["return_response"])
+
+	svc := &Service{client: c, Domain: "light", Name: "turn_on", Fields: map[string]*ServiceField{}}
+	_, err = svc.CallWithOptions(CallOptions{Data: map[string]interface{}{"bogus": 1}})
+	require.Error(t, err)
+}
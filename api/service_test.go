@@ -1,8 +1,10 @@
 package api
 
 import (
-	"github.com/stretchr/testify/require"
+	"encoding/json"
 	"testing"
+
+	"github.com/stretchr/testify/require"
 )
 
 func TestServiceField_UnmarshalJSON(t *testing.T) {
@@ -15,7 +17,9 @@ func TestServiceField_UnmarshalJSON(t *testing.T) {
 		{"string", `{ "description": "New value of axillary heater.", "example": "test" }`, false},
 		{"number", `{ "description": "New value of axillary heater.", "example": 1 }`, false},
 		{"values", `{ "description": "If the light should flash.", "values": [ "short", "long" ]}`, false},
-		{"type mismatch", `{ "description": "New value of axillary heater.", "example": null }`, true},
+		{"null example", `{ "description": "New value of axillary heater.", "example": null }`, false},
+		{"null default", `{ "description": "New value of axillary heater.", "default": null }`, false},
+		{"malformed example", `{ "description": "New value of axillary heater.", "example": tru }`, true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -29,3 +33,116 @@ func TestServiceField_UnmarshalJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestServiceField_UnmarshalJSON_Null(t *testing.T) {
+	s := &ServiceField{}
+	require.NoError(t, s.UnmarshalJSON([]byte(`{"example": null, "default": null}`)))
+	require.Nil(t, s.Example)
+	require.Nil(t, s.Default)
+	require.Equal(t, ServiceField{}.Type, s.Type)
+}
+
+func TestListServices_Schema(t *testing.T) {
+	// as homeassistant's get_services reports them
+	light := `{
+		"name": "Turn on",
+		"description": "Turns on one or more lights and adjusts their properties, even when they are turned on already.",
+		"fields": {
+			"transition": {
+				"filter": {"supported_features": [32]},
+				"selector": {"number": {"min": 0, "max": 300, "unit_of_measurement": "seconds"}},
+				"name": "Transition",
+				"description": "Duration it takes to get to next state."
+			},
+			"brightness_pct": {
+				"filter": {"attribute": {"supported_color_modes": ["brightness", "color_temp", "hs", "xy", "rgb"]}},
+				"selector": {"number": {"min": 0, "max": 100, "unit_of_measurement": "%"}},
+				"name": "Brightness",
+				"description": "Number indicating the percentage of full brightness."
+			},
+			"flash": {
+				"filter": {"supported_features": [8]},
+				"selector": {"select": {"options": [{"label": "Long", "value": "long"}, {"label": "Short", "value": "short"}]}},
+				"name": "Flash",
+				"description": "Tell light to flash, can be either value short or long."
+			},
+			"advanced_fields": {
+				"collapsed": true,
+				"fields": {
+					"rgb_color": {
+						"filter": {"attribute": {"supported_color_modes": ["hs", "xy", "rgb", "rgbw", "rgbww"]}},
+						"example": "[255, 100, 100]",
+						"selector": {"color_rgb": {}},
+						"name": "Color",
+						"description": "The color in RGB format."
+					},
+					"profile": {"advanced": true, "example": "relax", "selector": {"text": {}}, "name": "Profile"}
+				}
+			}
+		},
+		"target": {"entity": [{"domain": ["light"]}]}
+	}`
+	join := `{
+		"name": "Join",
+		"description": "Groups media players together for synchronous playback.",
+		"fields": {
+			"group_members": {
+				"required": true,
+				"example": "- media_player.multiroom_player2\n- media_player.multiroom_player3\n",
+				"selector": {"entity": {"multiple": true, "domain": "media_player"}},
+				"name": "Group members"
+			}
+		},
+		"target": {"entity": [{"domain": ["media_player"], "supported_features": [524288]}]}
+	}`
+
+	var svc, joinSvc Service
+	require.NoError(t, json.Unmarshal([]byte(light), &svc))
+	svc.Fields = flattenFields(svc.Fields)
+	svc.Domain, svc.Name = "light", "turn_on"
+	require.NoError(t, json.Unmarshal([]byte(join), &joinSvc))
+	joinSvc.Fields = flattenFields(joinSvc.Fields)
+	joinSvc.Domain, joinSvc.Name = "media_player", "join"
+
+	require.Equal(t, []interface{}{map[string]interface{}{"domain": []interface{}{"light"}}}, svc.Target["entity"])
+	require.Len(t, svc.Fields, 5)
+	require.Equal(t, "transition", svc.Fields["transition"].Name)
+	require.Equal(t, "Transition", svc.Fields["transition"].Label)
+	require.True(t, svc.Fields["profile"].Advanced)
+	require.Equal(t, Number, svc.Fields["transition"].Type)
+	require.Equal(t, ServiceFieldType(Values), svc.Fields["flash"].Type)
+	require.Equal(t, []interface{}{"long", "short"}, svc.Fields["flash"].Values)
+	require.True(t, joinSvc.Fields["group_members"].Required)
+	require.Equal(t, []string{"media_player"}, joinSvc.Fields["group_members"].Selector.Entity.Domains)
+
+	tests := []struct {
+		name    string
+		svc     *Service
+		data    map[string]interface{}
+		wantErr bool
+	}{
+		{"ok", &svc, map[string]interface{}{"transition": float64(5), "brightness_pct": float64(33)}, false},
+		{"no fields", &svc, map[string]interface{}{}, false},
+		{"over max", &svc, map[string]interface{}{"transition": float64(500)}, true},
+		{"bad option", &svc, map[string]interface{}{"flash": "medium"}, true},
+		{"unknown field", &svc, map[string]interface{}{"entity_id": "light.kitchen"}, true},
+		{"rgb", &svc, map[string]interface{}{"rgb_color": []interface{}{float64(255), float64(0), float64(0)}}, false},
+		{"bad rgb", &svc, map[string]interface{}{"rgb_color": []interface{}{float64(256), float64(0), float64(0)}},
+			true},
+		{"members", &joinSvc, map[string]interface{}{
+			"group_members": []interface{}{"media_player.kitchen", "media_player.den"}}, false},
+		{"missing required", &joinSvc, map[string]interface{}{}, true},
+		{"wrong domain", &joinSvc, map[string]interface{}{"group_members": "light.kitchen"}, true},
+		{"template", &joinSvc, map[string]interface{}{"group_members": "{{ states.media_player | first }}"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.svc.validate(tt.data)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
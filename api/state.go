@@ -37,3 +37,18 @@ func (c *Client) ListStates() ([]State, error) {
 
 	return ret, nil
 }
+
+// GetState returns the current state of the entity with the given ID.
+func (c *Client) GetState(entityId string) (*State, error) {
+	retI, err := c.RawRESTGetAs("states/"+entityId, nil, (*State)(nil))
+	if err != nil {
+		return nil, fmt.Errorf("getting state of %q: %w", entityId, err)
+	}
+
+	ret, ok := retI.(*State)
+	if !ok {
+		return nil, fmt.Errorf("received %T instead of *State", retI)
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"math"
+	"time"
+)
+
+// SunTimes returns the times of sunrise and sunset on the calendar day of date (in date's location) at the given
+// latitude and longitude, in degrees north and east. ok is false if the sun does not rise or does not set that day.
+//
+// The calculation is the sunrise equation (https://en.wikipedia.org/wiki/Sunrise_equation), which is accurate to within
+// a minute or so; homeassistant's own calculation is more precise, so conditions very close to sunrise or sunset may
+// evaluate differently.
+func SunTimes(date time.Time, latitude, longitude float64) (sunrise, sunset time.Time, ok bool) {
+	const j2000 = 2451545.0
+	const unixEpochJulian = 2440587.5
+	rad := math.Pi / 180
+
+	y, m, d := date.Date()
+	noon := time.Date(y, m, d, 12, 0, 0, 0, time.UTC)
+	n := math.Round(float64(noon.Unix())/86400 + unixEpochJulian - j2000)
+
+	meanSolarTime := n - longitude/360
+	anomaly := math.Mod(357.5291+0.98560028*meanSolarTime, 360)
+	center := 1.9148*math.Sin(anomaly*rad) + 0.0200*math.Sin(2*anomaly*rad) + 0.0003*math.Sin(3*anomaly*rad)
+	eclipticLongitude := math.Mod(anomaly+center+180+102.9372, 360)
+	transit := j2000 + meanSolarTime + 0.0053*math.Sin(anomaly*rad) - 0.0069*math.Sin(2*eclipticLongitude*rad)
+
+	sinDeclination := math.Sin(eclipticLongitude*rad) * math.Sin(23.4397*rad)
+	cosDeclination := math.Cos(math.Asin(sinDeclination))
+	cosHourAngle := (math.Sin(-0.833*rad) - math.Sin(latitude*rad)*sinDeclination) /
+		(math.Cos(latitude*rad) * cosDeclination)
+	if cosHourAngle < -1 || cosHourAngle > 1 {
+		return time.Time{}, time.Time{}, false
+	}
+	hourAngle := math.Acos(cosHourAngle) / rad
+
+	julianToTime := func(j float64) time.Time {
+		return time.Unix(0, int64((j-unixEpochJulian)*86400*float64(time.Second))).In(date.Location())
+	}
+	return julianToTime(transit - hourAngle/360), julianToTime(transit + hourAngle/360), true
+}
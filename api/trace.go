@@ -0,0 +1,129 @@
+package api
+
+import (
+	"fmt"
+	"time"
+)
+
+// TraceSummary describes a single recorded run of an automation or script, as returned by ListTraces.
+type TraceSummary struct {
+	Domain          string         `json:"domain"`
+	ItemId          string         `json:"item_id"`
+	RunId           string         `json:"run_id"`
+	LastStep        string         `json:"last_step"`
+	State           string         `json:"state"`
+	ScriptExecution string         `json:"script_execution"`
+	Timestamp       TraceTimestamp `json:"timestamp"`
+	Context         TraceContext   `json:"context"`
+	Error           string         `json:"error,omitempty"`
+	// Trigger is a human-readable description of what triggered an automation run, e.g. "state of light.kitchen".
+	Trigger string `json:"trigger,omitempty"`
+}
+
+type TraceTimestamp struct {
+	Start time.Time `json:"start"`
+	// Finish is nil while the run is still in progress.
+	Finish *time.Time `json:"finish"`
+}
+
+type TraceContext struct {
+	Id       string  `json:"id"`
+	ParentId *string `json:"parent_id"`
+	UserId   *string `json:"user_id"`
+}
+
+// Trace is the full record of a single run, as returned by GetTrace.
+type Trace struct {
+	TraceSummary
+	// Steps maps each path (e.g. `trigger/0`, `condition/1`, `action/0/choose/0/sequence/2`) to the records of each
+	// time that step was executed, since steps within a loop can run more than once.
+	Steps           map[string][]TraceStep `json:"trace"`
+	Config          map[string]interface{} `json:"config"`
+	BlueprintInputs map[string]interface{} `json:"blueprint_inputs,omitempty"`
+}
+
+// TraceStep is a single execution of a trigger, condition, or action.
+type TraceStep struct {
+	Path             string                 `json:"path"`
+	Timestamp        time.Time              `json:"timestamp"`
+	ChangedVariables map[string]interface{} `json:"changed_variables,omitempty"`
+	Result           map[string]interface{} `json:"result,omitempty"`
+	Error            string                 `json:"error,omitempty"`
+	// ChildId identifies the run of a script started by this step, if any.
+	ChildId *TraceItem `json:"child_id,omitempty"`
+}
+
+// TraceItem identifies a single run of an automation or script.
+type TraceItem struct {
+	Domain string `json:"domain"`
+	ItemId string `json:"item_id"`
+	RunId  string `json:"run_id"`
+}
+
+type TraceListMessage struct {
+	Domain string `json:"domain,omitempty"`
+	ItemId string `json:"item_id,omitempty"`
+}
+
+func (TraceListMessage) Type() string { return "trace/list" }
+
+type TraceGetMessage struct {
+	Domain string `json:"domain"`
+	ItemId string `json:"item_id"`
+	RunId  string `json:"run_id"`
+}
+
+func (TraceGetMessage) Type() string { return "trace/get" }
+
+type TraceContextsMessage struct {
+	Domain string `json:"domain,omitempty"`
+	ItemId string `json:"item_id,omitempty"`
+}
+
+func (TraceContextsMessage) Type() string { return "trace/contexts" }
+
+// ListTraces lists the stored runs of the given item in the given domain (e.g., `automation` and an automation's
+// config ID). If itemId is empty, runs of every item in the domain are listed; if domain is also empty, all stored runs
+// are listed.
+func (c *Client) ListTraces(domain, itemId string) ([]TraceSummary, error) {
+	retI, err := c.RawWebsocketRequestAs(TraceListMessage{domain, itemId}, []TraceSummary{})
+	if err != nil {
+		return nil, err
+	}
+
+	ret, ok := retI.([]TraceSummary)
+	if !ok {
+		return nil, fmt.Errorf("received %T instead of []TraceSummary", retI)
+	}
+	return ret, nil
+}
+
+// GetTrace retrieves the full trace of the given run.
+func (c *Client) GetTrace(domain, itemId, runId string) (*Trace, error) {
+	retI, err := c.RawWebsocketRequestAs(TraceGetMessage{domain, itemId, runId}, (*Trace)(nil))
+	if err != nil {
+		return nil, err
+	}
+
+	ret, ok := retI.(*Trace)
+	if !ok {
+		return nil, fmt.Errorf("received %T instead of *Trace", retI)
+	}
+	return ret, nil
+}
+
+// ListTraceContexts maps the IDs of contexts created by stored runs to the runs that created them, which allows
+// following a chain of automations and scripts that triggered one another. domain and itemId narrow the results as in
+// ListTraces.
+func (c *Client) ListTraceContexts(domain, itemId string) (map[string]TraceItem, error) {
+	retI, err := c.RawWebsocketRequestAs(TraceContextsMessage{domain, itemId}, map[string]TraceItem{})
+	if err != nil {
+		return nil, err
+	}
+
+	ret, ok := retI.(map[string]TraceItem)
+	if !ok {
+		return nil, fmt.Errorf("received %T instead of map[string]TraceItem", retI)
+	}
+	return ret, nil
+}
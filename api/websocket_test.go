@@ -0,0 +1,55 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWebsocket starts a server that authenticates any client, and then answers each websocket request with the
+// result returned by handle. If handle returns an error, the request fails with that error's message.
+func fakeWebsocket(t *testing.T, handle func(request map[string]interface{}) (interface{}, error)) *Client {
+	return fakeServer(t, handle, nil)
+}
+
+// fakeServer is fakeWebsocket, but also answers REST requests (those to paths other than the websocket's) with rest.
+func fakeServer(t *testing.T, handle func(request map[string]interface{}) (interface{}, error),
+	rest http.HandlerFunc) *Client {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rest != nil && r.URL.Path != "/api/websocket" {
+			rest(w, r)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		require.NoError(t, conn.WriteJSON(map[string]interface{}{"type": "auth_required"}))
+		var auth map[string]interface{}
+		require.NoError(t, conn.ReadJSON(&auth))
+		require.NoError(t, conn.WriteJSON(map[string]interface{}{"type": "auth_ok"}))
+
+		for {
+			var request map[string]interface{}
+			if err := conn.ReadJSON(&request); err != nil {
+				return
+			}
+			response := map[string]interface{}{"id": request["id"], "type": "result", "success": true}
+			result, err := handle(request)
+			if err != nil {
+				response["success"] = false
+				response["error"] = map[string]interface{}{"code": "unknown_error", "message": err.Error()}
+			} else {
+				response["result"] = result
+			}
+			require.NoError(t, conn.WriteJSON(response))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return &Client{Server: server.URL}
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"fmt"
+)
+
+// Zone is a zone created through the UI (or the API). Zones defined in configuration.yaml, including the home zone,
+// cannot be managed this way. Radius is in meters. A passive zone is only used for automations; entities are not shown
+// as being in it.
+type Zone struct {
+	Id        string  `json:"id"`
+	Name      string  `json:"name"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Radius    float64 `json:"radius,omitempty"`
+	Icon      string  `json:"icon,omitempty"`
+	Passive   bool    `json:"passive"`
+}
+
+type ZoneListMessage struct{}
+
+func (ZoneListMessage) Type() string { return "zone/list" }
+
+// zoneFields are the fields of a zone that can be set when creating or updating it. Radius is omitted if zero, and
+// homeassistant uses its default of 100 meters.
+type zoneFields struct {
+	Name      string  `json:"name"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Radius    float64 `json:"radius,omitempty"`
+	Icon      string  `json:"icon,omitempty"`
+	Passive   bool    `json:"passive"`
+}
+
+func newZoneFields(zone Zone) zoneFields {
+	return zoneFields{zone.Name, zone.Latitude, zone.Longitude, zone.Radius, zone.Icon, zone.Passive}
+}
+
+type ZoneCreateMessage struct {
+	zoneFields
+}
+
+func (ZoneCreateMessage) Type() string { return "zone/create" }
+
+type ZoneUpdateMessage struct {
+	ZoneId string `json:"zone_id"`
+	zoneFields
+}
+
+func (ZoneUpdateMessage) Type() string { return "zone/update" }
+
+type ZoneDeleteMessage struct {
+	ZoneId string `json:"zone_id"`
+}
+
+func (ZoneDeleteMessage) Type() string { return "zone/delete" }
+
+func (c *Client) ListZones() ([]Zone, error) {
+	retI, err := c.RawWebsocketRequestAs(ZoneListMessage{}, []Zone{})
+	if err != nil {
+		return nil, fmt.Errorf("listing zones: %w", err)
+	}
+	return retI.([]Zone), nil
+}
+
+// GetZone returns the zone with the given ID. If there is none, the error satisfies IsNotFound.
+func (c *Client) GetZone(id string) (*Zone, error) {
+	zones, err := c.ListZones()
+	if err != nil {
+		return nil, err
+	}
+	for _, zone := range zones {
+		if zone.Id == id {
+			return &zone, nil
+		}
+	}
+	return nil, notFound("zone", id)
+}
+
+// CreateZone creates a zone with the fields of the given one, other than Id, which homeassistant generates. It returns
+// the zone as created.
+func (c *Client) CreateZone(zone Zone) (*Zone, error) {
+	retI, err := c.RawWebsocketRequestAs(ZoneCreateMessage{newZoneFields(zone)}, (*Zone)(nil))
+	if err != nil {
+		return nil, fmt.Errorf("creating zone %q: %w", zone.Name, err)
+	}
+	return retI.(*Zone), nil
+}
+
+// UpdateZone sets all the fields of the zone with the given one's Id to those of the given one, and returns the
+// updated zone.
+func (c *Client) UpdateZone(zone Zone) (*Zone, error) {
+	retI, err := c.RawWebsocketRequestAs(ZoneUpdateMessage{zone.Id, newZoneFields(zone)}, (*Zone)(nil))
+	if err != nil {
+		return nil, fmt.Errorf("updating zone %q: %w", zone.Id, err)
+	}
+	return retI.(*Zone), nil
+}
+
+func (c *Client) DeleteZone(id string) error {
+	if _, err := c.RawWebsocketRequest(ZoneDeleteMessage{id}); err != nil {
+		return fmt.Errorf("deleting zone %q: %w", id, err)
+	}
+	return nil
+}
@@ -1,12 +1,17 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
 	"github.com/asymmetricia/ghastly/api"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
-	"strings"
+	"gopkg.in/yaml.v3"
 )
 
 var automationCmd = &cobra.Command{
@@ -27,17 +32,19 @@ var automationListCmd = &cobra.Command{
 
 		switch output {
 		case "text":
-			printTable(ret)
+			printTable(ret, []string{"id", "entity_id", "friendly_name", "state", "mode", "current", "last_triggered"})
 		case "json":
 			retJson, _ := json.Marshal(ret)
 			fmt.Println(string(retJson))
+		case "yaml":
+			printYAML(ret)
 		}
 	},
 }
 
 var automationGetCmd = &cobra.Command{
 	Use:   "get [automation-id]",
-	Short: "retrieve the configuration data for the given automation",
+	Short: "retrieve the configuration data for the given automation, as JSON or (with `-o yaml`) YAML",
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		log := logrus.WithField("automation_id", args[0])
@@ -46,34 +53,241 @@ var automationGetCmd = &cobra.Command{
 			log.WithError(err).Fatal("could not get automation")
 		}
 
+		if output, _ := cmd.Flags().GetString("output"); output == "yaml" {
+			yb, err := automationToYAML(ret)
+			if err != nil {
+				log.WithError(err).Fatal("could not marshal automation to YAML")
+			}
+			fmt.Print(string(yb))
+			return
+		}
+
 		jb, err := json.Marshal(ret)
 		if err != nil {
 			log.WithError(err).Fatal("could not marshal automation to JSON")
 		}
 		fmt.Println(string(jb))
 	},
-	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-		if len(args) != 0 {
-			return nil, cobra.ShellCompDirectiveNoFileComp
+	ValidArgsFunction: completeAutomationId,
+}
+
+var automationApplyCmd = &cobra.Command{
+	Use:   "apply -f <file>",
+	Short: "create or update an automation from a YAML (or JSON) file; if the file has no id, a new one is assigned",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		file, _ := cmd.Flags().GetString("file")
+		log := logrus.WithField("file", file)
+
+		data, err := readFileOrStdin(file)
+		if err != nil {
+			log.WithError(err).Fatal("could not read automation")
 		}
 
-		automations, err := client(cmd).ListAutomations()
+		automation, err := automationFromYAML(data)
 		if err != nil {
-			logrus.WithError(err).Fatal("could not get automations list")
-			return nil, cobra.ShellCompDirectiveError
+			log.WithError(err).Fatal("could not parse automation")
+		}
+		if err := automation.Validate(); err != nil {
+			log.WithError(err).Fatal("automation is not valid")
 		}
 
-		var ret []string
-		for _, auto := range automations {
-			if strings.HasPrefix(string(auto.Id), toComplete) {
-				ret = append(ret, string(auto.Id))
-			}
+		if err := client(cmd).SaveAutomation(automation); err != nil {
+			log.WithError(err).Fatal("could not save automation")
+		}
+		log.WithField("automation_id", automation.Id).Info("automation saved")
+	},
+}
+
+var automationDeleteCmd = &cobra.Command{
+	Use:   "delete [automation-id]",
+	Short: "delete the given automation",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := client(cmd).DeleteAutomation(api.AutomationId(args[0])); err != nil {
+			logrus.WithError(err).WithField("automation_id", args[0]).Fatal("could not delete automation")
+		}
+	},
+	ValidArgsFunction: completeAutomationId,
+}
+
+var automationEditCmd = &cobra.Command{
+	Use:   "edit [automation-id]",
+	Short: "open the given automation as YAML in $EDITOR, and save it back once the editor exits",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		log := logrus.WithField("automation_id", args[0])
+		id := api.AutomationId(args[0])
+		automation, err := client(cmd).GetAutomation(id)
+		if err != nil {
+			log.WithError(err).Fatal("could not get automation")
+		}
+
+		original, err := automationToYAML(automation)
+		if err != nil {
+			log.WithError(err).Fatal("could not convert automation to YAML")
+		}
+
+		f, err := os.CreateTemp("", "ghastly-automation-*.yaml")
+		if err != nil {
+			log.WithError(err).Fatal("could not create temporary file")
+		}
+		log = log.WithField("file", f.Name())
+		_, err = f.Write(original)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			log.WithError(err).Fatal("could not write temporary file")
+		}
+
+		editor := os.Getenv("EDITOR")
+		if editor == "" {
+			editor = "vi"
+		}
+		editorArgs := strings.Fields(editor)
+		edit := exec.Command(editorArgs[0], append(editorArgs[1:], f.Name())...)
+		edit.Stdin, edit.Stdout, edit.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := edit.Run(); err != nil {
+			log.WithError(err).Fatal("editor failed; your changes are preserved in the temporary file")
+		}
+
+		edited, err := os.ReadFile(f.Name())
+		if err != nil {
+			log.WithError(err).Fatal("could not read edited automation")
+		}
+		if bytes.Equal(original, edited) {
+			os.Remove(f.Name())
+			log.Info("no changes made")
+			return
+		}
+
+		automation, err = automationFromYAML(edited)
+		if err == nil {
+			err = automation.Validate()
+		}
+		if err != nil {
+			log.WithError(err).Fatal("edited automation is not valid; your changes are preserved in the temporary file")
+		}
+		if automation.Id != id {
+			log.Warnf("ignoring changed id %q", automation.Id)
+			automation.Id = id
+		}
+
+		if err := client(cmd).SaveAutomation(automation); err != nil {
+			log.WithError(err).Fatal("could not save automation; your changes are preserved in the temporary file")
+		}
+		os.Remove(f.Name())
+		log.Info("automation saved")
+	},
+	ValidArgsFunction: completeAutomationId,
+}
+
+var automationTriggerCmd = &cobra.Command{
+	Use:   "trigger [automation-id]",
+	Short: "run the actions of the given automation, identified by config ID or entity ID",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		skip, _ := cmd.Flags().GetBool("skip-condition")
+		if err := client(cmd).TriggerAutomation(api.AutomationId(args[0]), skip); err != nil {
+			logrus.WithError(err).WithField("automation_id", args[0]).Fatal("could not trigger automation")
+		}
+	},
+	ValidArgsFunction: completeAutomationId,
+}
+
+var automationEnableCmd = &cobra.Command{
+	Use:   "enable [automation-id]",
+	Short: "turn on the given automation, identified by config ID or entity ID",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := client(cmd).EnableAutomation(api.AutomationId(args[0])); err != nil {
+			logrus.WithError(err).WithField("automation_id", args[0]).Fatal("could not enable automation")
+		}
+	},
+	ValidArgsFunction: completeAutomationId,
+}
+
+var automationDisableCmd = &cobra.Command{
+	Use:   "disable [automation-id]",
+	Short: "turn off the given automation, identified by config ID or entity ID",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := client(cmd).DisableAutomation(api.AutomationId(args[0])); err != nil {
+			logrus.WithError(err).WithField("automation_id", args[0]).Fatal("could not disable automation")
 		}
-		return ret, cobra.ShellCompDirectiveNoFileComp
 	},
+	ValidArgsFunction: completeAutomationId,
+}
+
+var automationReloadCmd = &cobra.Command{
+	Use:   "reload",
+	Short: "reload all automations from the homeassistant configuration",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := client(cmd).ReloadAutomations(); err != nil {
+			logrus.WithError(err).Fatal("could not reload automations")
+		}
+	},
+}
+
+// automationFromYAML decodes an automation from YAML. JSON is accepted as well; while it is nominally a subset of YAML,
+// JSON indented with tabs is not, so anything that looks like a JSON object is decoded as JSON.
+func automationFromYAML(data []byte) (*api.Automation, error) {
+	ret := &api.Automation{}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		if err := json.Unmarshal(data, ret); err != nil {
+			return nil, err
+		}
+		return ret, nil
+	}
+
+	if err := yaml.Unmarshal(data, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// automationToYAML encodes an automation as YAML, laid out as homeassistant's automation editor shows it.
+func automationToYAML(automation *api.Automation) ([]byte, error) {
+	return api.EncodeYAML(automation)
+}
+
+func completeAutomationId(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) != 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	automations, err := client(cmd).ListAutomations()
+	if err != nil {
+		logrus.WithError(err).Fatal("could not get automations list")
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	var ret []string
+	for _, auto := range automations {
+		if auto.Id != "" && strings.HasPrefix(string(auto.Id), toComplete) {
+			ret = append(ret, string(auto.Id))
+		}
+	}
+	return ret, cobra.ShellCompDirectiveNoFileComp
 }
 
 func init() {
-	automationCmd.AddCommand(automationListCmd, automationGetCmd)
+	automationApplyCmd.Flags().StringP("file", "f", "", "the file containing the automation, or `-` for stdin")
+	_ = automationApplyCmd.MarkFlagRequired("file")
+	automationTriggerCmd.Flags().Bool("skip-condition", false, "if true, run the actions without checking "+
+		"the automation's conditions")
+
+	automationCmd.AddCommand(
+		automationListCmd,
+		automationGetCmd,
+		automationApplyCmd,
+		automationDeleteCmd,
+		automationEditCmd,
+		automationTriggerCmd,
+		automationEnableCmd,
+		automationDisableCmd,
+		automationReloadCmd)
 	Root.AddCommand(automationCmd)
 }
@@ -0,0 +1,307 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var blueprintCmd = &cobra.Command{
+	Use:   "blueprint",
+	Short: "sub-commands for managing blueprints and creating automations from them",
+}
+
+var blueprintListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list the blueprints known to the server",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		domain, _ := cmd.Flags().GetString("domain")
+		blueprints, err := client(cmd).ListBlueprints(domain)
+		if err != nil {
+			logrus.WithError(err).Fatal("could not list blueprints")
+		}
+
+		switch output, _ := cmd.Flags().GetString("output"); output {
+		case "text":
+			type row struct {
+				Path      string `json:"path"`
+				Name      string `json:"name"`
+				SourceUrl string `json:"source_url"`
+				Error     string `json:"error"`
+			}
+			var rows []row
+			for _, blueprint := range blueprints {
+				r := row{Path: blueprint.Path, Error: blueprint.Error}
+				if blueprint.Metadata != nil {
+					r.Name, r.SourceUrl = blueprint.Metadata.Name, blueprint.Metadata.SourceUrl
+				}
+				rows = append(rows, r)
+			}
+			printTable(rows, []string{"path", "name", "source_url", "error"})
+		case "json":
+			blueprintsJson, _ := json.Marshal(blueprints)
+			fmt.Println(string(blueprintsJson))
+		case "yaml":
+			printYAML(blueprints)
+		}
+	},
+}
+
+var blueprintShowCmd = &cobra.Command{
+	Use:   "show [path]",
+	Short: "describe the given blueprint and the inputs it accepts",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		domain, _ := cmd.Flags().GetString("domain")
+		blueprint, err := client(cmd).GetBlueprint(domain, args[0])
+		if err != nil {
+			logrus.WithError(err).WithField("path", args[0]).Fatal("could not get blueprint")
+		}
+
+		switch output, _ := cmd.Flags().GetString("output"); output {
+		case "text":
+			fmt.Printf("%s (%s)\n", blueprint.Name, blueprint.Domain)
+			if blueprint.SourceUrl != "" {
+				fmt.Printf("source: %s\n", blueprint.SourceUrl)
+			}
+			if blueprint.Description != "" {
+				fmt.Printf("\n%s\n", blueprint.Description)
+			}
+			fmt.Println()
+
+			type row struct {
+				Input    string `json:"input"`
+				Name     string `json:"name"`
+				Required string `json:"required"`
+				Default  string `json:"default"`
+				Selector string `json:"selector"`
+			}
+			var rows []row
+			for name, input := range blueprint.Inputs() {
+				r := row{Input: name, Name: input.Name, Required: "yes"}
+				if input.HasDefault {
+					r.Required = "no"
+					defaultJson, _ := json.Marshal(input.Default)
+					r.Default = string(defaultJson)
+				}
+				if input.Selector != nil {
+					selectorJson, _ := json.Marshal(input.Selector)
+					r.Selector = string(selectorJson)
+				}
+				rows = append(rows, r)
+			}
+			sort.Slice(rows, func(i, j int) bool { return rows[i].Input < rows[j].Input })
+			printTable(rows, []string{"input", "name", "required", "default", "selector"})
+		case "json":
+			blueprintJson, _ := json.Marshal(blueprint)
+			fmt.Println(string(blueprintJson))
+		case "yaml":
+			printYAML(blueprint)
+		}
+	},
+	ValidArgsFunction: completeBlueprintPath,
+}
+
+var blueprintImportCmd = &cobra.Command{
+	Use:   "import [file-or-url]",
+	Short: "save a blueprint from a local file, or have the server import one from a URL",
+	Long: "Saves a blueprint to the server. If the argument is an http or https URL, the server retrieves the " +
+		"blueprint itself, as the `Import Blueprint` button in the UI does; otherwise the blueprint is read from the " +
+		"given file, or from stdin if the file is `-`.\n\n" +
+		"The blueprint is saved at --path, relative to the blueprint directory of its domain. By default, this is " +
+		"the path suggested by the server for URLs, or the file's name for files.",
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		source := args[0]
+		log := logrus.WithField("source", source)
+		path, _ := cmd.Flags().GetString("path")
+		override, _ := cmd.Flags().GetBool("override")
+
+		var data []byte
+		var metadata api.BlueprintMetadata
+		sourceUrl := ""
+		if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+			imported, err := client(cmd).ImportBlueprint(source)
+			if err != nil {
+				log.WithError(err).Fatal("could not import blueprint")
+			}
+			if len(imported.ValidationErrors) > 0 {
+				log.WithField("errors", imported.ValidationErrors).Fatal("blueprint is not valid")
+			}
+			if imported.Exists && !override {
+				log.Fatal("blueprint already exists; use --override to replace it")
+			}
+			data, metadata, sourceUrl = []byte(imported.RawData), imported.Blueprint.Metadata, source
+			if path == "" {
+				path = imported.SuggestedFilename + ".yaml"
+			}
+		} else {
+			var err error
+			data, err = readFileOrStdin(source)
+			if err != nil {
+				log.WithError(err).Fatal("could not read blueprint")
+			}
+			blueprint, err := api.ParseBlueprint(data)
+			if err != nil {
+				log.WithError(err).Fatal("could not parse blueprint")
+			}
+			metadata, sourceUrl = blueprint.Metadata, blueprint.Metadata.SourceUrl
+			if path == "" {
+				if source == "-" {
+					log.Fatal("--path is required when reading from stdin")
+				}
+				path = filepath.Base(source)
+			}
+		}
+
+		if err := client(cmd).SaveBlueprint(metadata.Domain, path, data, sourceUrl, override); err != nil {
+			log.WithError(err).Fatal("could not save blueprint")
+		}
+		log.WithField("domain", metadata.Domain).WithField("path", path).Info("blueprint saved")
+	},
+}
+
+var blueprintUseCmd = &cobra.Command{
+	Use:   "use [path]",
+	Short: "create an automation from the given blueprint",
+	Long: "Creates an automation from the given blueprint. Inputs are given with --input `name=value`, where value " +
+		"is parsed as YAML (so `120` is a number and `[a, b]` is a list), and/or with --inputs-file, a YAML file " +
+		"mapping input names to values. Inputs are checked against the blueprint before the automation is saved.",
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		log := logrus.WithField("path", args[0])
+		blueprint, err := client(cmd).GetBlueprint("automation", args[0])
+		if err != nil {
+			log.WithError(err).Fatal("could not get blueprint")
+		}
+
+		inputs, err := blueprintInputs(cmd)
+		if err != nil {
+			log.WithError(err).Fatal("could not parse inputs")
+		}
+
+		automation, err := blueprint.Instantiate(args[0], inputs)
+		if err != nil {
+			log.WithError(err).Fatal("could not use blueprint")
+		}
+		if alias, _ := cmd.Flags().GetString("alias"); alias != "" {
+			automation.Alias = alias
+		}
+		if id, _ := cmd.Flags().GetString("id"); id != "" {
+			automation.Id = api.AutomationId(id)
+		}
+
+		if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+			yb, err := automationToYAML(automation)
+			if err != nil {
+				log.WithError(err).Fatal("could not marshal automation to YAML")
+			}
+			fmt.Print(string(yb))
+			return
+		}
+
+		if err := client(cmd).SaveAutomation(automation); err != nil {
+			log.WithError(err).Fatal("could not save automation")
+		}
+		log.WithField("automation_id", automation.Id).Info("automation saved")
+	},
+	ValidArgsFunction: completeBlueprintPath,
+}
+
+var blueprintDeleteCmd = &cobra.Command{
+	Use:   "delete [path]",
+	Short: "delete the given blueprint; homeassistant refuses if it is in use",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		domain, _ := cmd.Flags().GetString("domain")
+		if err := client(cmd).DeleteBlueprint(domain, args[0]); err != nil {
+			logrus.WithError(err).WithField("path", args[0]).Fatal("could not delete blueprint")
+		}
+	},
+	ValidArgsFunction: completeBlueprintPath,
+}
+
+// blueprintInputs collects the inputs given by --inputs-file and --input, the latter taking precedence.
+func blueprintInputs(cmd *cobra.Command) (map[string]interface{}, error) {
+	inputs := map[string]interface{}{}
+
+	if file, _ := cmd.Flags().GetString("inputs-file"); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		generic, err := api.DecodeYAML(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		fileInputs, ok := generic.(map[string]interface{})
+		if !ok && generic != nil {
+			return nil, fmt.Errorf("%s: expected a mapping of input names to values", file)
+		}
+		for k, v := range fileInputs {
+			inputs[k] = v
+		}
+	}
+
+	pairs, _ := cmd.Flags().GetStringArray("input")
+	assigned, err := parseAssignments(pairs)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range assigned {
+		inputs[k] = v
+	}
+
+	return inputs, nil
+}
+
+func completeBlueprintPath(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) != 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	domain := "automation"
+	if cmd.Flags().Lookup("domain") != nil {
+		domain, _ = cmd.Flags().GetString("domain")
+	}
+	blueprints, err := client(cmd).ListBlueprints(domain)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	var ret []string
+	for _, blueprint := range blueprints {
+		if strings.HasPrefix(blueprint.Path, toComplete) {
+			ret = append(ret, blueprint.Path)
+		}
+	}
+	return ret, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	for _, c := range []*cobra.Command{blueprintListCmd, blueprintShowCmd, blueprintDeleteCmd} {
+		c.Flags().String("domain", "automation", "the blueprint domain; `automation` or `script`")
+	}
+	blueprintImportCmd.Flags().String("path", "", "the path to save the blueprint at, relative to the blueprint "+
+		"directory of its domain")
+	blueprintImportCmd.Flags().Bool("override", false, "if true, replace any existing blueprint at the same path")
+	blueprintUseCmd.Flags().StringArrayP("input", "i", nil, "an input to the blueprint, as `name=value`; may be "+
+		"repeated")
+	blueprintUseCmd.Flags().String("inputs-file", "", "a YAML file mapping input names to values")
+	blueprintUseCmd.Flags().String("alias", "", "the alias of the new automation; defaults to the blueprint's name")
+	blueprintUseCmd.Flags().String("id", "", "the ID of the new automation; if an automation with this ID "+
+		"exists, it is replaced")
+	blueprintUseCmd.Flags().Bool("dry-run", false, "if true, print the automation instead of saving it")
+
+	blueprintCmd.AddCommand(blueprintListCmd, blueprintShowCmd, blueprintImportCmd, blueprintUseCmd,
+		blueprintDeleteCmd)
+	Root.AddCommand(blueprintCmd)
+}
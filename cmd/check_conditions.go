@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var automationCheckConditionsCmd = &cobra.Command{
+	Use: "check-conditions [automation-id]",
+	Short: "evaluate the conditions of the given automation against the current states, to see whether any would " +
+		"block it right now",
+	Long: "Evaluates the conditions of the given automation against the current states, time, and sun position. " +
+		"Template and device conditions cannot be evaluated locally, and are reported as unknown.",
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		log := logrus.WithField("automation_id", args[0])
+		automation, err := client(cmd).GetAutomation(api.AutomationId(args[0]))
+		if err != nil {
+			log.WithError(err).Fatal("could not get automation")
+		}
+
+		ctx, err := client(cmd).CurrentEvalContext()
+		if err != nil {
+			log.WithError(err).Fatal("could not get current states")
+		}
+		ctx.TriggerId, _ = cmd.Flags().GetString("trigger-id")
+
+		result, trace := automation.Condition.Evaluate(ctx)
+
+		switch output, _ := cmd.Flags().GetString("output"); output {
+		case "text":
+			for i, child := range trace.Children {
+				printConditionTrace(os.Stdout, fmt.Sprintf("condition/%d", i), child, "")
+			}
+			switch {
+			case result:
+				fmt.Println("conditions pass; the automation would run")
+			case trace.Unsupported:
+				fmt.Println("conditions depend on conditions that cannot be evaluated locally")
+			default:
+				fmt.Println("conditions fail; the automation would not run")
+			}
+		case "json":
+			traceJson, _ := json.Marshal(trace)
+			fmt.Println(string(traceJson))
+		case "yaml":
+			printYAML(trace)
+		}
+	},
+	ValidArgsFunction: completeAutomationId,
+}
+
+// printConditionTrace renders a condition trace as a tree, marking each condition as passed (✓), failed (✗), or
+// unknown (?).
+func printConditionTrace(w io.Writer, path string, trace *api.ConditionTrace, indent string) {
+	mark := "✗"
+	if trace.Result {
+		mark = "✓"
+	} else if trace.Unsupported {
+		mark = "?"
+	}
+	label := trace.Condition
+	if trace.Alias != "" {
+		label = fmt.Sprintf("%s (%s)", trace.Condition, trace.Alias)
+	}
+	fmt.Fprintf(w, "%s%s %s %s: %s\n", indent, mark, path, label, trace.Detail)
+
+	for i, child := range trace.Children {
+		printConditionTrace(w, fmt.Sprintf("%s/conditions/%d", path, i), child, indent+"  ")
+	}
+}
+
+func init() {
+	automationCheckConditionsCmd.Flags().String("trigger-id", "", "evaluate trigger conditions as if the "+
+		"automation had been triggered by the trigger with this ID")
+	automationCmd.AddCommand(automationCheckConditionsCmd)
+}
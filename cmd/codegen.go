@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/asymmetricia/ghastly/codegen"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var codegenCmd = &cobra.Command{
+	Use:   "codegen",
+	Short: "sub-commands for generating Go code from homeassistant",
+}
+
+var codegenServicesCmd = &cobra.Command{
+	Use:   "services [domain...]",
+	Short: "generate a Go package of typed service calls for each of the given domains, or for every domain",
+	Long: "Generates a Go package for each of the given domains, or for every domain, with a typed function and data " +
+		"struct for each of its services. Each package is written to a directory named for it under --out; e.g., " +
+		"`light` is written to `<out>/light/light.go`, and `switch` (a Go keyword) to `<out>/switchsvc/switchsvc.go`." +
+		"\n\nServices are read from homeassistant, or from a file saved via `ghastly raw services > services.json` " +
+		"with --from, which suits `go:generate`:\n\n" +
+		"\t//go:generate ghastly codegen services light switch --from services.json --out .",
+	Run: func(cmd *cobra.Command, args []string) {
+		var services []api.Service
+		var err error
+		if from, _ := cmd.Flags().GetString("from"); from != "" {
+			data, err := readFileOrStdin(from)
+			if err != nil {
+				logrus.WithError(err).Fatal("could not read services")
+			}
+			services, err = api.ParseServices(data)
+			if err != nil {
+				logrus.WithError(err).Fatalf("could not parse services from %q", from)
+			}
+		} else {
+			services, err = client(cmd).ListServices()
+			if err != nil {
+				logrus.WithError(err).Fatal("could not list services")
+			}
+		}
+
+		out, _ := cmd.Flags().GetString("out")
+		if err := codegen.Write(out, services, args...); err != nil {
+			logrus.WithError(err).Fatal("could not generate service packages")
+		}
+	},
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		svcs, err := client(cmd).ListServices()
+		if err != nil {
+			logrus.WithError(err).Error("could not list services for tab completion")
+			return nil, cobra.ShellCompDirectiveError
+		}
+		return completeServiceDomain(svcs)
+	},
+}
+
+func init() {
+	codegenServicesCmd.Flags().String("out", ".", "the directory under which to write the generated packages")
+	codegenServicesCmd.Flags().String("from", "", "read services from this file (or `-` for stdin), as returned by "+
+		"homeassistant's /api/services, instead of from homeassistant")
+	codegenCmd.AddCommand(codegenServicesCmd)
+	Root.AddCommand(codegenCmd)
+}
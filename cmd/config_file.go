@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+// configFile is the on-disk ghastly config file, holding state that persists between invocations. It is unrelated to
+// the homeassistant config entries manipulated by `ghastly config`.
+type configFile struct {
+	Searches map[string]savedSearch `json:"searches,omitempty"`
+}
+
+// savedSearch is a named query, run against either devices or entities.
+type savedSearch struct {
+	Kind  string `json:"kind"`
+	Query string `json:"query"`
+}
+
+// defaultConfigFilePath returns the config file location within the user's config directory, or an empty string if
+// the user has no config directory.
+func defaultConfigFilePath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "ghastly", "config.json")
+}
+
+func configFilePath(cmd *cobra.Command) (string, error) {
+	path := cmd.Flag("config-file").Value.String()
+	if path == "" {
+		return "", errors.New("no config file location; provide one via --config-file")
+	}
+	return path, nil
+}
+
+// loadConfigFile reads the config file named by the --config-file flag. A missing file is not an error; an empty
+// configFile is returned instead.
+func loadConfigFile(cmd *cobra.Command) (*configFile, error) {
+	path, err := configFilePath(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &configFile{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ret, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading config file %q: %w", path, err)
+	}
+
+	if err := json.Unmarshal(data, ret); err != nil {
+		return nil, fmt.Errorf("parsing config file %q: %w", path, err)
+	}
+	return ret, nil
+}
+
+// save writes the config file to the location named by the --config-file flag, creating its directory if needed.
+func (c *configFile) save(cmd *cobra.Command) error {
+	path, err := configFilePath(cmd)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling config file: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("creating config directory: %w", err)
+	}
+
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("writing config file %q: %w", path, err)
+	}
+	return nil
+}
@@ -6,8 +6,6 @@ import (
 	"os"
 	"strings"
 
-	"github.com/asymmetricia/ghastly/search"
-
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -34,33 +32,18 @@ var deviceSearchCmd = &cobra.Command{
 func runDeviceSearch(cmd *cobra.Command, args []string) {
 	qs := strings.Join(args, " ")
 	log := logrus.WithField("query", qs)
-	queryI, err := search.Parse("query", []byte(qs))
+	query, err := parseQuery(qs)
 	if err != nil {
 		log.WithError(err).Fatal("could not parse query")
 	}
 
-	query, ok := queryI.(search.Node)
-	if !ok {
-		log.Fatalf("query parser returned nil error, but query was %T not search.Node", query)
-	}
-
 	devices, err := client(cmd).ListDevices()
 	if err != nil {
 		log.WithError(err).Fatal("could not get devices from HomeAssistant")
 	}
 
 	for _, device := range devices {
-		attrs, err := search.Attributes(device)
-		if err != nil {
-			log.WithError(err).Fatalf("could not convert device %v to attributes: %v", device, err)
-		}
-		log.Debug(attrs)
-		match, err := query.Evaluate(attrs)
-		log.Debugf("result = %v, %v", match, err)
-		if err != nil {
-			log.WithError(err).Fatal("error during query evaluation")
-		}
-		if match {
+		if matchQuery(log, query, device) {
 			deviceJson, _ := json.Marshal(device)
 			fmt.Println(string(deviceJson))
 		}
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/asymmetricia/ghastly/graph"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var graphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "sub-commands for analyzing what automations, scripts, and scenes refer to",
+}
+
+// graphRow is a flattened graph.Edge, for printTable.
+type graphRow struct {
+	Kind   graph.Kind `json:"kind"`
+	Id     string     `json:"id"`
+	Name   string     `json:"name"`
+	Path   string     `json:"path"`
+	Refers string     `json:"refers_to"`
+}
+
+func graphRows(g *graph.Graph, edges []graph.Edge) []graphRow {
+	rows := make([]graphRow, len(edges))
+	for i, e := range edges {
+		rows[i] = graphRow{e.From.Kind, e.From.Id, g.Label(e.From), e.Path, e.To.String()}
+	}
+	return rows
+}
+
+func printEdges(cmd *cobra.Command, g *graph.Graph, edges []graph.Edge) {
+	switch output, _ := cmd.Flags().GetString("output"); output {
+	case "text":
+		printTable(graphRows(g, edges), []string{"kind", "id", "name", "path", "refers_to"})
+	case "json":
+		edgesJson, _ := json.Marshal(edges)
+		fmt.Println(string(edgesJson))
+	case "yaml":
+		printYAML(edges)
+	}
+}
+
+var graphUsesCmd = &cobra.Command{
+	Use:   "uses [entity-id]",
+	Short: "list the automations, scripts, and scenes that refer to the given entity",
+	Long: "Lists the automations, scripts, and scenes that refer to the given entity. Other kinds of nodes may be " +
+		"given with a prefix: `device:<device-id>`, `area:<area-id>`, `service:<domain>.<service>`, or " +
+		"`automation:<automation-id>`.",
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		g, _ := buildGraph(client(cmd))
+		printEdges(cmd, g, g.Uses(graph.ParseNode(args[0])))
+	},
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		states, err := client(cmd).ListStates()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		var ret []string
+		for _, state := range states {
+			if strings.HasPrefix(state.EntityId, toComplete) {
+				ret = append(ret, state.EntityId)
+			}
+		}
+		return ret, cobra.ShellCompDirectiveNoFileComp
+	},
+}
+
+var graphOrphansCmd = &cobra.Command{
+	Use:   "orphans",
+	Short: "list references by automations, scripts, and scenes to entities that do not exist",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		c := client(cmd)
+		g, states := buildGraph(c)
+
+		exists := map[string]bool{}
+		for _, state := range states {
+			exists[state.EntityId] = true
+		}
+		// disabled entities have no state, but are still in the registry
+		entities, err := c.ListEntities()
+		if err != nil {
+			logrus.WithError(err).Fatal("could not list entities")
+		}
+		for _, entity := range entities {
+			exists[entity.EntityId] = true
+		}
+
+		printEdges(cmd, g, g.Missing(func(entityId string) bool { return exists[entityId] }))
+	},
+}
+
+var graphExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "write the whole graph in graphviz DOT or mermaid format, for documentation",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		g, _ := buildGraph(client(cmd))
+
+		var err error
+		switch format, _ := cmd.Flags().GetString("format"); format {
+		case "dot":
+			err = g.WriteDOT(os.Stdout)
+		case "mermaid":
+			err = g.WriteMermaid(os.Stdout)
+		default:
+			logrus.Fatalf("unsupported format %q; use dot or mermaid", format)
+		}
+		if err != nil {
+			logrus.WithError(err).Fatal("could not write graph")
+		}
+	},
+}
+
+// buildGraph builds the graph of every automation, script, and scene on the server. The states, which are retrieved
+// once and used to find the automations, scripts, and scenes, are returned as well.
+func buildGraph(c *api.Client) (*graph.Graph, []api.State) {
+	g := graph.New()
+
+	states, err := c.ListStates()
+	if err != nil {
+		logrus.WithError(err).Fatal("could not list states")
+	}
+
+	automations, err := api.AutomationsFromStates(states)
+	if err != nil {
+		logrus.WithError(err).Fatal("could not list automations")
+	}
+	for _, entry := range automations {
+		if entry.Id == "" {
+			// automations defined outside automations.yaml cannot be retrieved
+			continue
+		}
+		automation, err := c.GetAutomation(entry.Id)
+		if err != nil {
+			logrus.WithError(err).WithField("automation_id", entry.Id).Warn("could not get automation; skipping it")
+			continue
+		}
+		g.AddAutomation(automation)
+	}
+
+	scripts, err := api.ScriptsFromStates(states)
+	if err != nil {
+		logrus.WithError(err).Fatal("could not list scripts")
+	}
+	for _, entry := range scripts {
+		script, err := c.GetScript(entry.Id)
+		if err != nil {
+			// scripts defined outside scripts.yaml cannot be retrieved
+			logrus.WithError(err).WithField("script_id", entry.Id).Warn("could not get script; skipping it")
+			continue
+		}
+		g.AddScript(entry.EntityId, script.Alias, script.Sequence)
+	}
+
+	// scenes' entities are available from their states, even for scenes that cannot be retrieved
+	for _, scene := range api.ScenesFromStates(states) {
+		g.AddScene(scene.EntityId, scene.FriendlyName, scene.Entities...)
+	}
+
+	return g, states
+}
+
+func init() {
+	graphExportCmd.Flags().String("format", "dot", "the graph format to write; `dot` or `mermaid`")
+	graphCmd.AddCommand(graphUsesCmd, graphOrphansCmd, graphExportCmd)
+	Root.AddCommand(graphCmd)
+}
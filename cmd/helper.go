@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var helperCmd = &cobra.Command{
+	Use:   "helper",
+	Short: "sub-commands for manipulating helpers, e.g. input_number or timer",
+}
+
+var helperListCmd = &cobra.Command{
+	Use:   "list [domain...]",
+	Short: "retrieve a list of the helpers in the given domains, or in all helper domains",
+	Run: func(cmd *cobra.Command, args []string) {
+		domains := args
+		if len(domains) == 0 {
+			domains = api.HelperDomains
+		}
+
+		var ret []api.Helper
+		for _, domain := range domains {
+			helpers, err := client(cmd).ListHelpers(domain)
+			if err != nil {
+				logrus.WithError(err).WithField("domain", domain).Fatal("could not list helpers")
+			}
+			for _, helper := range helpers {
+				helper["entity_id"] = helper.EntityId(domain)
+				ret = append(ret, helper)
+			}
+		}
+
+		switch output, _ := cmd.Flags().GetString("output"); output {
+		case "text":
+			printTable(ret, []string{"entity_id", "name"})
+		case "json":
+			retJson, _ := json.Marshal(ret)
+			fmt.Println(string(retJson))
+		case "yaml":
+			printYAML(ret)
+		}
+	},
+	ValidArgsFunction: completeHelperDomains,
+}
+
+var helperCreateCmd = &cobra.Command{
+	Use:   "create <domain> name=<name> [field=value...]",
+	Short: "create a helper; values are parsed as YAML, e.g. `min=0 max=100` or `options=[low, high]`",
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		log := logrus.WithField("domain", args[0])
+		fields, err := parseAssignments(args[1:])
+		if err != nil {
+			log.WithError(err).Fatal("could not parse fields")
+		}
+		if _, ok := fields["name"]; !ok {
+			log.Fatal("helpers require a name")
+		}
+
+		helper, err := client(cmd).CreateHelper(args[0], fields)
+		if err != nil {
+			log.WithError(err).Fatal("could not create helper")
+		}
+		log.WithField("entity_id", helper.EntityId(args[0])).Info("helper created")
+	},
+	ValidArgsFunction: completeHelperDomain,
+}
+
+var helperDeleteCmd = &cobra.Command{
+	Use:   "delete <domain> <id>",
+	Short: "delete the given helper",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := client(cmd).DeleteHelper(args[0], args[1]); err != nil {
+			logrus.WithError(err).WithField("domain", args[0]).WithField("id", args[1]).
+				Fatal("could not delete helper")
+		}
+	},
+	ValidArgsFunction: completeHelper,
+}
+
+// completeHelperDomains completes any number of helper domains.
+func completeHelperDomains(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var ret []string
+	for _, domain := range api.HelperDomains {
+		if strings.HasPrefix(domain, toComplete) {
+			ret = append(ret, domain)
+		}
+	}
+	return ret, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeHelperDomain completes a helper domain as the first argument.
+func completeHelperDomain(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) != 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeHelperDomains(cmd, args, toComplete)
+}
+
+func completeHelper(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return completeHelperDomain(cmd, args, toComplete)
+	case 1:
+	default:
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	helpers, err := client(cmd).ListHelpers(args[0])
+	if err != nil {
+		logrus.WithError(err).Fatal("could not get helpers list")
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	var ret []string
+	for _, helper := range helpers {
+		if strings.HasPrefix(helper.Id(), toComplete) {
+			ret = append(ret, helper.Id())
+		}
+	}
+	return ret, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	helperCmd.AddCommand(helperListCmd, helperCreateCmd, helperDeleteCmd)
+	Root.AddCommand(helperCmd)
+}
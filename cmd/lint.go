@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/asymmetricia/ghastly/lint"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var automationLintCmd = &cobra.Command{
+	Use: "lint [automation-id...]",
+	Short: "check automations for mistakes, such as references to entities or services that do not exist; exits " +
+		"non-zero if any errors are found",
+	Long: "Checks the given automations, or the automations in the files given with -f, or all automations if " +
+		"neither is given. Files may hold a single automation or a list of them, as automations.yaml does.\n\n" +
+		"Unless --offline is given, entity and service references are checked against the server.\n\n" +
+		"An automation that cannot be retrieved or decoded is reported as an `unreadable-automation` error.\n\n" +
+		"Output formats are `text`, `json`, and `sarif`.",
+	Run: func(cmd *cobra.Command, args []string) {
+		files, _ := cmd.Flags().GetStringArray("file")
+		offline, _ := cmd.Flags().GetBool("offline")
+
+		var findings []lint.Finding
+		env := &lint.Environment{}
+		if !offline {
+			env = lintEnvironment(client(cmd))
+		}
+
+		for _, file := range files {
+			log := logrus.WithField("file", file)
+			data, err := readFileOrStdin(file)
+			if err != nil {
+				log.WithError(err).Fatal("could not read automations")
+			}
+
+			automations, err := automationsFromYAML(data)
+			if err != nil {
+				log.WithError(err).Fatal("could not parse automations")
+			}
+			for _, automation := range automations {
+				for _, finding := range lint.Lint(automation, env) {
+					finding.File = file
+					findings = append(findings, finding)
+				}
+			}
+		}
+
+		ids := args
+		if len(ids) == 0 && len(files) == 0 {
+			automations, err := client(cmd).ListAutomations()
+			if err != nil {
+				logrus.WithError(err).Fatal("could not list automations")
+			}
+			for _, automation := range automations {
+				if automation.Id != "" {
+					ids = append(ids, string(automation.Id))
+				}
+			}
+		}
+		for _, id := range ids {
+			automation, err := client(cmd).GetAutomation(api.AutomationId(id))
+			if err != nil {
+				// the rest are still checked, but the run fails
+				findings = append(findings, lint.Unreadable(id, err))
+				continue
+			}
+			findings = append(findings, lint.Lint(automation, env)...)
+		}
+
+		switch output, _ := cmd.Flags().GetString("output"); output {
+		case "text":
+			if len(findings) > 0 {
+				printTable(findings, []string{"severity", "rule", "automation", "file", "path", "message"})
+			}
+		case "json":
+			findingsJson, _ := json.Marshal(findings)
+			fmt.Println(string(findingsJson))
+		case "sarif":
+			if err := lint.WriteSARIF(os.Stdout, findings); err != nil {
+				logrus.WithError(err).Fatal("could not write SARIF")
+			}
+		default:
+			logrus.Fatalf("unsupported output format %q; use text, json, or sarif", output)
+		}
+
+		if lint.HasErrors(findings) {
+			os.Exit(1)
+		}
+	},
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeAutomationId(cmd, nil, toComplete)
+	},
+}
+
+// lintEnvironment retrieves the entities and services known to the server. If either cannot be retrieved, a warning
+// is logged and the corresponding checks are skipped.
+func lintEnvironment(c *api.Client) *lint.Environment {
+	env := &lint.Environment{}
+
+	entities, err := c.ListEntities()
+	var states []api.State
+	if err == nil {
+		// not every entity is in the registry, so entities are also discovered via their states
+		states, err = c.ListStates()
+	}
+	if err != nil {
+		logrus.WithError(err).Warn("could not list entities; entity references will not be checked")
+	} else {
+		for _, entity := range entities {
+			env.AddEntities(entity.EntityId)
+		}
+		for _, state := range states {
+			env.AddEntities(state.EntityId)
+		}
+	}
+
+	services, err := c.ListServices()
+	if err != nil {
+		logrus.WithError(err).Warn("could not list services; service calls will not be checked")
+	} else {
+		env.AddServices(services...)
+	}
+
+	return env
+}
+
+// automationsFromYAML decodes either a single automation or a list of automations from YAML or JSON.
+func automationsFromYAML(data []byte) ([]*api.Automation, error) {
+	var ret []*api.Automation
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		if err := json.Unmarshal(data, &ret); err != nil {
+			return nil, err
+		}
+		return ret, nil
+	}
+
+	var node yaml.Node
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		if err := yaml.Unmarshal(data, &node); err != nil {
+			return nil, err
+		}
+	}
+	if len(node.Content) > 0 && node.Content[0].Kind == yaml.SequenceNode {
+		if err := node.Decode(&ret); err != nil {
+			return nil, err
+		}
+		return ret, nil
+	}
+
+	automation, err := automationFromYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	return []*api.Automation{automation}, nil
+}
+
+func init() {
+	automationLintCmd.Flags().StringArrayP("file", "f", nil, "a file containing automations to check, or `-` "+
+		"for stdin; may be repeated")
+	automationLintCmd.Flags().Bool("offline", false, "if true, do not contact the server; entity and service "+
+		"references are not checked")
+	automationCmd.AddCommand(automationLintCmd)
+}
@@ -46,7 +46,10 @@ func init() {
 	Root.PersistentFlags().String("token", os.Getenv("HASS_TOKEN"), "the bearer token used to authenticate to homeassistant. defaults to value of HASS_TOKEN environment variable")
 	Root.PersistentFlags().String("server", os.Getenv("HASS_SERVER"), "the URL used to access homeassistant. defaults to value of HASS_SERVER environment variable")
 	Root.PersistentFlags().String("loglevel", "INFO", "log level; one of TRACE, DEBUG, INFO, WARN, ERROR, FATAL, PANIC")
-	Root.PersistentFlags().StringP("output", "o", "text", "output format for commands; options are `text` or `json`")
+	Root.PersistentFlags().String("config-file", defaultConfigFilePath(), "the ghastly config file, which holds "+
+		"saved searches")
+	Root.PersistentFlags().StringP("output", "o", "text", "output format for commands; options are `text` or `json`, "+
+		"and `yaml` for automation commands")
 
 	cobra.OnInitialize(func() {
 		lvlStr := Root.Flag("loglevel").Value.String()
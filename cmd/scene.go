@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var sceneCmd = &cobra.Command{
+	Use:   "scene",
+	Short: "sub-commands for manipulating and interacting with scenes",
+}
+
+var sceneListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "retrieve a list of all known scenes",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ret, err := client(cmd).ListScenes()
+		if err != nil {
+			logrus.WithError(err).Fatal("could not list scenes")
+		}
+
+		switch output, _ := cmd.Flags().GetString("output"); output {
+		case "text":
+			printTable(ret, []string{"id", "entity_id", "friendly_name", "state", "entities"})
+		case "json":
+			retJson, _ := json.Marshal(ret)
+			fmt.Println(string(retJson))
+		case "yaml":
+			printYAML(ret)
+		}
+	},
+}
+
+var sceneGetCmd = &cobra.Command{
+	Use:   "get [scene-id]",
+	Short: "retrieve the configuration data for the given scene, as JSON or (with `-o yaml`) YAML",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		log := logrus.WithField("scene_id", args[0])
+		ret, err := client(cmd).GetScene(api.SceneId(args[0]))
+		if err != nil {
+			log.WithError(err).Fatal("could not get scene")
+		}
+		printScene(log, cmd, ret)
+	},
+	ValidArgsFunction: completeSceneId,
+}
+
+var sceneApplyCmd = &cobra.Command{
+	Use:   "apply -f <file>",
+	Short: "create or update a scene from a YAML (or JSON) file; if the file has no id, a new one is assigned",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		file, _ := cmd.Flags().GetString("file")
+		log := logrus.WithField("file", file)
+
+		data, err := readFileOrStdin(file)
+		if err != nil {
+			log.WithError(err).Fatal("could not read scene")
+		}
+
+		scene := &api.Scene{}
+		if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+			err = json.Unmarshal(data, scene)
+		} else {
+			err = yaml.Unmarshal(data, scene)
+		}
+		if err != nil {
+			log.WithError(err).Fatal("could not parse scene")
+		}
+		if err := scene.Validate(); err != nil {
+			log.WithError(err).Fatal("scene is not valid")
+		}
+
+		if err := client(cmd).SaveScene(scene); err != nil {
+			log.WithError(err).Fatal("could not save scene")
+		}
+		log.WithField("scene_id", scene.Id).Info("scene saved")
+	},
+}
+
+var sceneDeleteCmd = &cobra.Command{
+	Use:   "delete [scene-id]",
+	Short: "delete the given scene",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := client(cmd).DeleteScene(api.SceneId(args[0])); err != nil {
+			logrus.WithError(err).WithField("scene_id", args[0]).Fatal("could not delete scene")
+		}
+	},
+	ValidArgsFunction: completeSceneId,
+}
+
+var sceneActivateCmd = &cobra.Command{
+	Use:   "activate [scene-id]",
+	Short: "activate the given scene, by config or entity ID",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := client(cmd).ActivateScene(api.SceneId(args[0])); err != nil {
+			logrus.WithError(err).WithField("scene_id", args[0]).Fatal("could not activate scene")
+		}
+	},
+	ValidArgsFunction: completeSceneId,
+}
+
+var sceneSnapshotCmd = &cobra.Command{
+	Use:   "snapshot [entity-id...] --name <name>",
+	Short: "create a scene that restores the current states and attributes of the given entities",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name, _ := cmd.Flags().GetString("name")
+		log := logrus.WithField("name", name)
+
+		states, err := client(cmd).ListStates()
+		if err != nil {
+			log.WithError(err).Fatal("could not list states")
+		}
+		byId := map[string]api.State{}
+		for _, state := range states {
+			byId[state.EntityId] = state
+		}
+
+		var snapshot []api.State
+		for _, entityId := range args {
+			state, ok := byId[entityId]
+			if !ok {
+				log.WithField("entity_id", entityId).Fatal("entity has no state")
+			}
+			snapshot = append(snapshot, state)
+		}
+		scene := api.SceneFromStates(name, snapshot...)
+
+		if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+			printScene(log, cmd, scene)
+			return
+		}
+		if err := client(cmd).SaveScene(scene); err != nil {
+			log.WithError(err).Fatal("could not save scene")
+		}
+		log.WithField("scene_id", scene.Id).Info("scene saved")
+	},
+}
+
+// printScene prints the scene as JSON or, with `-o yaml`, YAML.
+func printScene(log logrus.FieldLogger, cmd *cobra.Command, scene *api.Scene) {
+	if output, _ := cmd.Flags().GetString("output"); output == "yaml" {
+		yb, err := api.EncodeYAML(scene)
+		if err != nil {
+			log.WithError(err).Fatal("could not marshal scene to YAML")
+		}
+		fmt.Print(string(yb))
+		return
+	}
+
+	jb, err := json.Marshal(scene)
+	if err != nil {
+		log.WithError(err).Fatal("could not marshal scene to JSON")
+	}
+	fmt.Println(string(jb))
+}
+
+func completeSceneId(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) != 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	scenes, err := client(cmd).ListScenes()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	var ret []string
+	for _, scene := range scenes {
+		if scene.Id != "" && strings.HasPrefix(string(scene.Id), toComplete) {
+			ret = append(ret, string(scene.Id))
+		}
+	}
+	return ret, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	sceneApplyCmd.Flags().StringP("file", "f", "", "the file containing the scene, or `-` for stdin")
+	_ = sceneApplyCmd.MarkFlagRequired("file")
+	sceneSnapshotCmd.Flags().String("name", "", "the name of the new scene")
+	_ = sceneSnapshotCmd.MarkFlagRequired("name")
+	sceneSnapshotCmd.Flags().Bool("dry-run", false, "if true, print the scene instead of saving it")
+
+	sceneCmd.AddCommand(sceneListCmd, sceneGetCmd, sceneApplyCmd, sceneDeleteCmd, sceneActivateCmd, sceneSnapshotCmd)
+	Root.AddCommand(sceneCmd)
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var scriptCmd = &cobra.Command{
+	Use:   "script",
+	Short: "sub-commands for manipulating and interacting with scripts",
+}
+
+var scriptListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "retrieve a list of all known scripts",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ret, err := client(cmd).ListScripts()
+		if err != nil {
+			logrus.WithError(err).Fatal("could not list scripts")
+		}
+
+		switch output, _ := cmd.Flags().GetString("output"); output {
+		case "text":
+			printTable(ret, []string{"id", "entity_id", "friendly_name", "state", "mode", "current", "last_triggered"})
+		case "json":
+			retJson, _ := json.Marshal(ret)
+			fmt.Println(string(retJson))
+		case "yaml":
+			printYAML(ret)
+		}
+	},
+}
+
+var scriptGetCmd = &cobra.Command{
+	Use:   "get [script-id]",
+	Short: "retrieve the configuration data for the given script, as JSON or (with `-o yaml`) YAML",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		log := logrus.WithField("script_id", args[0])
+		ret, err := client(cmd).GetScript(api.ScriptId(args[0]))
+		if err != nil {
+			log.WithError(err).Fatal("could not get script")
+		}
+
+		if output, _ := cmd.Flags().GetString("output"); output == "yaml" {
+			yb, err := api.EncodeYAML(ret)
+			if err != nil {
+				log.WithError(err).Fatal("could not marshal script to YAML")
+			}
+			fmt.Print(string(yb))
+			return
+		}
+
+		jb, err := json.Marshal(ret)
+		if err != nil {
+			log.WithError(err).Fatal("could not marshal script to JSON")
+		}
+		fmt.Println(string(jb))
+	},
+	ValidArgsFunction: completeScriptId,
+}
+
+var scriptApplyCmd = &cobra.Command{
+	Use: "apply [script-id] -f <file>",
+	Short: "create or update a script from a YAML (or JSON) file; if no id is given, one is made from the script's " +
+		"alias",
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		file, _ := cmd.Flags().GetString("file")
+		log := logrus.WithField("file", file)
+
+		data, err := readFileOrStdin(file)
+		if err != nil {
+			log.WithError(err).Fatal("could not read script")
+		}
+
+		script := &api.Script{}
+		if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+			err = json.Unmarshal(data, script)
+		} else {
+			err = yaml.Unmarshal(data, script)
+		}
+		if err != nil {
+			log.WithError(err).Fatal("could not parse script")
+		}
+		if err := script.Validate(); err != nil {
+			log.WithError(err).Fatal("script is not valid")
+		}
+
+		if len(args) > 0 {
+			script.Id = api.ScriptId(args[0])
+		}
+		if err := client(cmd).SaveScript(script); err != nil {
+			log.WithError(err).Fatal("could not save script")
+		}
+		log.WithField("script_id", script.Id).Info("script saved")
+	},
+	ValidArgsFunction: completeScriptId,
+}
+
+var scriptDeleteCmd = &cobra.Command{
+	Use:   "delete [script-id]",
+	Short: "delete the given script",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := client(cmd).DeleteScript(api.ScriptId(args[0])); err != nil {
+			logrus.WithError(err).WithField("script_id", args[0]).Fatal("could not delete script")
+		}
+	},
+	ValidArgsFunction: completeScriptId,
+}
+
+var scriptRunCmd = &cobra.Command{
+	Use: "run [script-id]",
+	Short: "start the given script, without waiting for it to finish; variables are given with --var " +
+		"`name=value`, where value is parsed as YAML",
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		log := logrus.WithField("script_id", args[0])
+		pairs, _ := cmd.Flags().GetStringArray("var")
+		variables, err := parseAssignments(pairs)
+		if err != nil {
+			log.WithError(err).Fatal("could not parse variables")
+		}
+		if err := client(cmd).RunScript(api.ScriptId(args[0]), variables); err != nil {
+			log.WithError(err).Fatal("could not run script")
+		}
+	},
+	ValidArgsFunction: completeScriptId,
+}
+
+func completeScriptId(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) != 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	scripts, err := client(cmd).ListScripts()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	var ret []string
+	for _, script := range scripts {
+		if strings.HasPrefix(string(script.Id), toComplete) {
+			ret = append(ret, string(script.Id))
+		}
+	}
+	return ret, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	scriptApplyCmd.Flags().StringP("file", "f", "", "the file containing the script, or `-` for stdin")
+	_ = scriptApplyCmd.MarkFlagRequired("file")
+	scriptRunCmd.Flags().StringArray("var", nil, "a variable to pass to the script, as `name=value`; may be "+
+		"repeated")
+
+	scriptCmd.AddCommand(scriptListCmd, scriptGetCmd, scriptApplyCmd, scriptDeleteCmd, scriptRunCmd)
+	Root.AddCommand(scriptCmd)
+}
@@ -0,0 +1,346 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/asymmetricia/ghastly/search"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search",
+	Short: "sub-commands for saving and running named device and entity searches",
+}
+
+var searchSaveCmd = &cobra.Command{
+	Use: "save <name> <query>",
+	Short: "save the given query under the given name, using a simplified lucene syntax: " +
+		"`field:value AND otherfield:othervalue`",
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		kind, _ := cmd.Flags().GetString("kind")
+		if kind != "device" && kind != "entity" {
+			logrus.Fatalf("--kind must be `device` or `entity`, not %q", kind)
+		}
+
+		qs := strings.Join(args[1:], " ")
+		if _, err := parseQuery(qs); err != nil {
+			logrus.WithError(err).WithField("query", qs).Fatal("could not parse query")
+		}
+
+		cfg, err := loadConfigFile(cmd)
+		if err != nil {
+			logrus.WithError(err).Fatal("could not load config file")
+		}
+		if cfg.Searches == nil {
+			cfg.Searches = map[string]savedSearch{}
+		}
+		cfg.Searches[args[0]] = savedSearch{Kind: kind, Query: qs}
+		if err := cfg.save(cmd); err != nil {
+			logrus.WithError(err).Fatal("could not save config file")
+		}
+	},
+}
+
+var searchListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list saved searches",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := loadConfigFile(cmd)
+		if err != nil {
+			logrus.WithError(err).Fatal("could not load config file")
+		}
+
+		switch output, _ := cmd.Flags().GetString("output"); output {
+		case "text":
+			var table []map[string]string
+			for name, s := range cfg.Searches {
+				table = append(table, map[string]string{"name": name, "kind": s.Kind, "query": s.Query})
+			}
+			sort.Slice(table, func(i, j int) bool { return table[i]["name"] < table[j]["name"] })
+			printTable(table, []string{"name", "kind", "query"})
+		case "json":
+			retJson, _ := json.Marshal(cfg.Searches)
+			fmt.Println(string(retJson))
+		}
+	},
+}
+
+var searchDeleteCmd = &cobra.Command{
+	Use:               "delete <name>",
+	Short:             "delete the saved search with the given name",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSavedSearch,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := loadConfigFile(cmd)
+		if err != nil {
+			logrus.WithError(err).Fatal("could not load config file")
+		}
+		if _, ok := cfg.Searches[args[0]]; !ok {
+			logrus.Fatalf("no saved search named %q", args[0])
+		}
+		delete(cfg.Searches, args[0])
+		if err := cfg.save(cmd); err != nil {
+			logrus.WithError(err).Fatal("could not save config file")
+		}
+	},
+}
+
+var searchRunCmd = &cobra.Command{
+	Use:               "run <name>",
+	Short:             "run the saved search with the given name and print the matching devices or entities",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSavedSearch,
+	Run:               runSearchRun,
+}
+
+func runSearchRun(cmd *cobra.Command, args []string) {
+	log := logrus.WithField("search", args[0])
+	cfg, err := loadConfigFile(cmd)
+	if err != nil {
+		log.WithError(err).Fatal("could not load config file")
+	}
+	saved, ok := cfg.Searches[args[0]]
+	if !ok {
+		log.Fatal("no saved search by that name")
+	}
+
+	query, err := parseQuery(saved.Query)
+	if err != nil {
+		log.WithError(err).Fatal("could not parse saved query")
+	}
+
+	as, _ := cmd.Flags().GetString("as")
+	if as != "" && as != "terraform" {
+		log.Fatalf("--as must be `terraform` if provided, not %q", as)
+	}
+
+	var matched interface{}
+	switch saved.Kind {
+	case "device":
+		if as == "terraform" {
+			log.Fatal("only entity searches can be exported to terraform")
+		}
+		devices, err := client(cmd).ListDevices()
+		if err != nil {
+			log.WithError(err).Fatal("could not get devices from HomeAssistant")
+		}
+		var ret []*api.Device
+		for _, device := range devices {
+			if matchQuery(log, query, device) {
+				ret = append(ret, device)
+			}
+		}
+		matched = ret
+	case "entity":
+		entities, err := client(cmd).ListEntities()
+		if err != nil {
+			log.WithError(err).Fatal("could not get entities from HomeAssistant")
+		}
+		var ret []api.Entity
+		for _, entity := range entities {
+			if matchQuery(log, query, entity) {
+				ret = append(ret, entity)
+			}
+		}
+		if as == "terraform" {
+			devices, err := client(cmd).ListDevices()
+			if err != nil {
+				log.WithError(err).Fatal("could not get devices from HomeAssistant")
+			}
+			fmt.Print(entityIdsTerraform(log, args[0], saved.Query, query, entities, devices, ret))
+			return
+		}
+		matched = ret
+	default:
+		log.Fatalf("saved search has unknown kind %q", saved.Kind)
+	}
+
+	switch output, _ := cmd.Flags().GetString("output"); output {
+	case "text":
+		printTable(matched)
+	case "json":
+		retJson, _ := json.Marshal(matched)
+		fmt.Println(string(retJson))
+	}
+}
+
+// parseQuery parses the given search query string.
+func parseQuery(qs string) (search.Node, error) {
+	return search.ParseQuery(qs)
+}
+
+// matchQuery evaluates the query against the attributes of obj, exiting if that cannot be done.
+func matchQuery(log logrus.FieldLogger, query search.Node, obj interface{}) bool {
+	attrs, err := search.Attributes(obj)
+	if err != nil {
+		log.WithError(err).Fatalf("could not convert %v to attributes", obj)
+	}
+	log.Debug(attrs)
+	match, err := query.Evaluate(attrs)
+	log.Debugf("result = %v, %v", match, err)
+	if err != nil {
+		log.WithError(err).Fatal("error during query evaluation")
+	}
+	return match
+}
+
+var hclIdentifierInvalid = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// entityIdsTerraform renders a `homeassistant_entity_ids` data source block equivalent to the given query, qs parsed.
+// Where it can, the block filters on entity fields (see entityIdsFilter), which reads more plainly; otherwise it
+// passes the query along as the data source's `query`.
+func entityIdsTerraform(log logrus.FieldLogger, name, qs string, query search.Node, entities []api.Entity,
+	devices []*api.Device, matched []api.Entity) string {
+	filter, err := entityIdsFilter(query, entities, devices, matched)
+	if err != nil {
+		log.WithError(err).Debug("exporting search as a query")
+		filter = map[string]string{"query": qs}
+	}
+
+	var keys []string
+	width := 0
+	for k := range filter {
+		keys = append(keys, k)
+		if len(k) > width {
+			width = len(k)
+		}
+	}
+	sort.Strings(keys)
+
+	label := hclIdentifierInvalid.ReplaceAllString(name, "_")
+	if label == "" || (label[0] >= '0' && label[0] <= '9') {
+		label = "search_" + label
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "data \"homeassistant_entity_ids\" %q {\n", label)
+	for _, k := range keys {
+		fmt.Fprintf(&sb, "  %-*s = %s\n", width, k, hclString(filter[k]))
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// entityIdsFilter returns the `homeassistant_entity_ids` field filters equivalent to the given query. Only queries
+// made of AND-ed exact matches against entity fields, or a prefix match against entity_id, can be expressed this way.
+// Since the data source matches fields case-sensitively and the query does not, the filter is checked against the
+// given entities and an error is returned if it would not select exactly the matched set. Like the data source, an
+// entity with no area of its own is taken to be in its device's area.
+func entityIdsFilter(query search.Node, entities []api.Entity, devices []*api.Device,
+	matched []api.Entity) (map[string]string, error) {
+	terms, err := search.Terms(query)
+	if err != nil {
+		return nil, err
+	}
+
+	// the fields the data source filters on
+	fields := search.StringFields(reflect.TypeOf(api.Entity{}), "id")
+
+	filter := map[string]string{}
+	for _, term := range terms {
+		attr := term.Field
+		value := term.Pattern
+		if _, ok := fields[attr]; !ok {
+			return nil, fmt.Errorf("%q is not a field the data source can filter on", attr)
+		}
+		if attr == "entity_id" && strings.HasSuffix(value, "*") &&
+			!strings.ContainsAny(strings.TrimSuffix(value, "*"), `*?[]{}\`) {
+			attr = "entity_id_prefix"
+			value = strings.TrimSuffix(value, "*")
+		} else if strings.ContainsAny(value, `*?[]{}\`) {
+			return nil, fmt.Errorf("%s:%s uses a pattern, which the data source cannot express", term.Field, term.Pattern)
+		}
+		if existing, ok := filter[attr]; ok && existing != value {
+			return nil, fmt.Errorf("%s is constrained to both %q and %q", attr, existing, value)
+		}
+		filter[attr] = value
+	}
+
+	deviceAreas := map[string]string{}
+	for _, device := range devices {
+		if device.AreaId != nil {
+			deviceAreas[device.ID] = *device.AreaId
+		}
+	}
+
+	want := map[string]bool{}
+	for _, entity := range matched {
+		want[entity.EntityId] = true
+	}
+	got := 0
+	for _, entity := range entities {
+		match := strings.HasPrefix(entity.EntityId, filter["entity_id_prefix"])
+		for attr, value := range filter {
+			idx, ok := fields[attr]
+			if !ok {
+				continue
+			}
+			actual := search.StringField(entity, idx)
+			if attr == "area_id" && actual == "" && entity.DeviceId != "" {
+				actual = deviceAreas[entity.DeviceId]
+			}
+			if actual != value {
+				match = false
+			}
+		}
+		if !match {
+			continue
+		}
+		if !want[entity.EntityId] {
+			return nil, fmt.Errorf("the data source would also match %q, which the query does not", entity.EntityId)
+		}
+		got++
+	}
+	if got != len(want) {
+		return nil, fmt.Errorf("the query matches %d entities, but the data source would only match %d; check the "+
+			"capitalization of query values", len(want), got)
+	}
+
+	return filter, nil
+}
+
+// hclString quotes s as an HCL string literal, escaping template sequences.
+func hclString(s string) string {
+	q, _ := json.Marshal(s)
+	ret := strings.ReplaceAll(string(q), "${", "$${")
+	return strings.ReplaceAll(ret, "%{", "%%{")
+}
+
+func completeSavedSearch(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) != 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	cfg, err := loadConfigFile(cmd)
+	if err != nil {
+		logrus.WithError(err).Error("could not load config file")
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	var ret []string
+	for name := range cfg.Searches {
+		if strings.HasPrefix(name, toComplete) {
+			ret = append(ret, name)
+		}
+	}
+	sort.Strings(ret)
+	return ret, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	searchSaveCmd.Flags().String("kind", "entity", "what the query searches; `device` or `entity`")
+	searchRunCmd.Flags().String("as", "", "if `terraform`, print an equivalent homeassistant_entity_ids data "+
+		"source block instead of the matching entities; it filters on entity fields if it can, and on the query "+
+		"otherwise")
+	searchCmd.AddCommand(searchSaveCmd, searchRunCmd, searchListCmd, searchDeleteCmd)
+	Root.AddCommand(searchCmd)
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntityIdsTerraform(t *testing.T) {
+	kitchen := "kitchen"
+	devices := []*api.Device{{ID: "fridge", AreaId: &kitchen}}
+	entities := []api.Entity{
+		{EntityId: "light.kitchen", AreaId: "kitchen"},
+		{EntityId: "sensor.fridge_temperature", DeviceId: "fridge"},
+		{EntityId: "light.hall", AreaId: "hall"},
+	}
+
+	export := func(qs string) string {
+		query, err := parseQuery(qs)
+		require.NoError(t, err)
+		var matched []api.Entity
+		for _, entity := range entities {
+			if matchQuery(logrus.StandardLogger(), query, entity) {
+				matched = append(matched, entity)
+			}
+		}
+		return entityIdsTerraform(logrus.StandardLogger(), "lights", qs, query, entities, devices, matched)
+	}
+
+	require.Equal(t, "data \"homeassistant_entity_ids\" \"lights\" {\n  area_id = \"hall\"\n}\n",
+		export("area_id:hall"))
+
+	// the data source would also find the fridge's sensor in its device's area, so the query is kept
+	require.Equal(t, "data \"homeassistant_entity_ids\" \"lights\" {\n  query = \"area_id:kitchen\"\n}\n",
+		export("area_id:kitchen"))
+}
@@ -140,11 +140,16 @@ var serviceCallCmd = &cobra.Command{
 	Short: "call the given service; each argument after service name should be " +
 		"a `key=value` pair, which will be passed as a field in service_data",
 	Long: "call the given service. each argument is a `key=value` pair " +
-		"passed as a field in service_data. Examples:\n\nToggle a light " +
+		"passed as a field in service_data, except that `entity_id`, " +
+		"`device_id`, `area_id`, `floor_id`, and `label_id` are added to " +
+		"the target unless the service has a field of that name. Examples:\n\nToggle a light " +
 		"entity with ID `light.nanoleaf`:\n\tcall light toggle " +
 		"entity_id=light.nanoleaf\n\nSet the color temperature of " +
 		"light.nanoleaf to 500 mired:\n\tcall light turn_on " +
-		"entity_id=light.nanoleaf color_temp=500",
+		"entity_id=light.nanoleaf color_temp=500\n\nTurn on every light in " +
+		"the kitchen:\n\tcall light turn_on --target area=kitchen\n\nGet the " +
+		"forecast for weather.home:\n\tcall weather get_forecasts " +
+		"--target entity=weather.home type=daily --response",
 	Args:              cobra.MinimumNArgs(2),
 	Run:               serviceCallCmd_Run,
 	ValidArgsFunction: serviceCallCmd_Complete,
@@ -201,75 +206,192 @@ func serviceCallCmd_Complete(cmd *cobra.Command, args []string, complete string)
 			cobra.ShellCompDirectiveNoFileComp
 	}
 
-	if field.Type == api.Values {
+	// values are completed whole, or for fields that take several, after the last comma
+	prefix := fieldName + "="
+	if field.Selector != nil && field.Selector.Multiple() {
+		prefix = complete[:strings.LastIndex(complete, "=")+1]
+		if i := strings.LastIndex(complete, ","); i >= 0 {
+			prefix = complete[:i+1]
+		}
+	}
+
+	var values []string
+	switch {
+	case field.Selector != nil && field.Selector.Select != nil:
+		for _, option := range field.Selector.Select.Options {
+			values = append(values, option.Value)
+		}
+	case field.Selector != nil && field.Selector.Kind == "entity":
+		states, err := client.ListStates()
+		if err != nil {
+			logrus.WithError(err).Error("could not list states")
+			return nil, cobra.ShellCompDirectiveError
+		}
+		for _, state := range states {
+			if field.Selector.Entity.Allows(state.EntityId) {
+				values = append(values, state.EntityId)
+			}
+		}
+	case field.Type == api.Boolean:
+		values = []string{"true", "false"}
+	case field.Type == api.Values:
 		for _, v := range field.Values {
-			ret = append(ret, fmt.Sprintf("%v", v))
+			values = append(values, fmt.Sprintf("%v", v))
 		}
-		return ret, cobra.ShellCompDirectiveNoFileComp
+	default:
+		return nil, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
 	}
 
-	return nil, 0
+	for _, v := range values {
+		ret = append(ret, prefix+v)
+	}
+	sort.Strings(ret)
+	return ret, cobra.ShellCompDirectiveNoFileComp
 }
 
-func serviceCallCmd_Run(cmd *cobra.Command, args []string) {
-	svc, err := client(cmd).GetService(args[0], args[1])
-	if err != nil {
-		logrus.WithError(err).Fatal("could not find service")
+// coerceField converts a value given on the command line to what the field expects. Fields with a selector are
+// coerced by it; fields of unknown type are parsed as YAML, so that lists and objects may be given.
+func coerceField(field *api.ServiceField, value string) (interface{}, error) {
+	if field.Selector != nil {
+		return field.Selector.Coerce(value)
 	}
 
-	logrus.Tracef("call invoked with args %v", args)
+	switch field.Type {
+	case api.Values, api.String:
+		return value, nil
+	case api.Number:
+		return strconv.ParseFloat(value, 64)
+	case api.Boolean:
+		return strconv.ParseBool(value)
+	}
+	return api.DecodeYAML([]byte(value))
+}
 
+// serviceCallData parses `key=value` arguments into service data for svc. Target keys (e.g., `entity_id`) that svc
+// does not have a field for are added to target instead, which is returned.
+func serviceCallData(svc *api.Service, target *api.Target, args []string) (map[string]interface{}, *api.Target, error) {
 	payload := map[string]interface{}{}
-	for _, kv := range args[2:] {
+	for _, kv := range args {
 		logrus.Tracef("parsing argument %q", kv)
-		parts := strings.SplitN(kv, "=", 2)
-		if len(parts) != 2 {
-			logrus.Fatalf("%q was not `key=value` pair", kv)
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			return nil, nil, fmt.Errorf("%q was not `key=value` pair", kv)
+		}
+
+		if kind, ok := targetKinds[strings.TrimSuffix(key, "_id")]; ok && strings.HasSuffix(key, "_id") &&
+			svc.Fields[key] == nil {
+			// homeassistant takes these as the target, not as service data
+			if target == nil {
+				target = &api.Target{}
+			}
+			*kind(target) = append(*kind(target), strings.Split(value, ",")...)
+			continue
 		}
 
-		field, ok := svc.Fields[parts[0]]
+		field, ok := svc.Fields[key]
 		if !ok {
-			logrus.Fatalf("service %s.%s does not have field %q", svc.Domain, svc.Name, parts[0])
+			return nil, nil, fmt.Errorf("service %s.%s does not have field %q", svc.Domain, svc.Name, key)
 		}
 		logrus.Tracef("found field %v", field)
 
-		switch field.Type {
-		case api.Values:
-			fallthrough
-		case api.String:
-			payload[parts[0]] = parts[1]
-		case api.Number:
-			payload[parts[0]], err = strconv.ParseFloat(parts[1], 64)
-			if err != nil {
-				logrus.WithError(err).Fatalf("service %s.%s field %s is "+
-					"numeric, but could not parse %q", svc.Domain, svc.Name,
-					parts[0], parts[1])
-			}
-		case api.Boolean:
-			payload[parts[0]], err = strconv.ParseBool(parts[1])
-			if err != nil {
-				logrus.WithError(err).Fatalf("service %s.%s field %s is "+
-					"boolean, but could not parse %q", svc.Domain, svc.Name,
-					parts[0], parts[1])
-			}
-		default:
-			logrus.Fatalf("field %q has unhandled type %s", field.Name, field.Type)
+		var err error
+		payload[key], err = coerceField(field, value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not parse %q for service %s.%s field %s: %w", value, svc.Domain,
+				svc.Name, key, err)
 		}
 	}
+	return payload, target, nil
+}
+
+func serviceCallCmd_Run(cmd *cobra.Command, args []string) {
+	svc, err := client(cmd).GetService(args[0], args[1])
+	if err != nil {
+		logrus.WithError(err).Fatal("could not find service")
+	}
+
+	logrus.Tracef("call invoked with args %v", args)
+
+	target, err := parseTarget(cmd)
+	if err != nil {
+		logrus.WithError(err).Fatal("could not parse target")
+	}
+
+	payload, target, err := serviceCallData(svc, target, args[2:])
+	if err != nil {
+		logrus.WithError(err).Fatal("could not parse arguments")
+	}
+
+	returnResponse, _ := cmd.Flags().GetBool("response")
+	if svc.Response != nil && !svc.Response.Optional {
+		// the call fails without it, so there's no sense making the user ask
+		returnResponse = true
+	}
 
 	logrus.Tracef("calling with payload %v", payload)
-	states, err := svc.Call(payload)
+	result, err := svc.CallWithOptions(api.CallOptions{
+		Target:         target,
+		Data:           payload,
+		ReturnResponse: returnResponse,
+		// the changed states are printed unless the response is
+		ChangedStates: !returnResponse,
+	})
 	if err != nil {
 		logrus.WithError(err).Fatalf("failed to call service %s.%s", svc.Domain, svc.Name)
 	}
-	statesJson, err := json.Marshal(states)
+
+	var ret interface{} = result.ChangedStates
+	if returnResponse {
+		ret = result.Response
+	}
+	if output, _ := cmd.Flags().GetString("output"); output == "yaml" {
+		printYAML(ret)
+		return
+	}
+	retJson, err := json.Marshal(ret)
 	if err != nil {
-		logrus.WithError(err).Fatal("could not marshal states to JSON: %w", err)
+		logrus.WithError(err).Fatal("could not marshal result to JSON")
+	}
+	fmt.Println(string(retJson))
+}
+
+// targetKinds maps the kinds accepted by --target to the corresponding fields of api.Target.
+var targetKinds = map[string]func(t *api.Target) *[]string{
+	"entity": func(t *api.Target) *[]string { return &t.EntityId },
+	"device": func(t *api.Target) *[]string { return &t.DeviceId },
+	"area":   func(t *api.Target) *[]string { return &t.AreaId },
+	"floor":  func(t *api.Target) *[]string { return &t.FloorId },
+	"label":  func(t *api.Target) *[]string { return &t.LabelId },
+}
+
+// parseTarget builds a target from the --target flags, each of the form `kind=id[,id...]`.
+func parseTarget(cmd *cobra.Command) (*api.Target, error) {
+	targets, _ := cmd.Flags().GetStringArray("target")
+	if len(targets) == 0 {
+		return nil, nil
+	}
+
+	ret := &api.Target{}
+	for _, target := range targets {
+		kind, ids, ok := strings.Cut(target, "=")
+		if !ok {
+			return nil, fmt.Errorf("target %q should be kind=id", target)
+		}
+		field, ok := targetKinds[strings.TrimSuffix(kind, "_id")]
+		if !ok {
+			return nil, fmt.Errorf("target %q has unknown kind %q; use entity, device, area, floor, or label", target,
+				kind)
+		}
+		*field(ret) = append(*field(ret), strings.Split(ids, ",")...)
 	}
-	fmt.Println(string(statesJson))
+	return ret, nil
 }
 
 func init() {
+	serviceCallCmd.Flags().StringArray("target", nil, "what the service should act on, as `kind=id[,id...]`, "+
+		"where kind is entity, device, area, floor, or label; may be repeated")
+	serviceCallCmd.Flags().Bool("response", false, "if true, request the service's response data and print it "+
+		"instead of the changed states")
 	serviceCmd.AddCommand(
 		serviceListCmd,
 		serviceGetCmd,
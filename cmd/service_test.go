@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceCallData(t *testing.T) {
+	svc := &api.Service{Domain: "light", Name: "turn_on", Fields: map[string]*api.ServiceField{
+		"color_temp": {Name: "color_temp", Type: api.Number},
+	}}
+
+	payload, target, err := serviceCallData(svc, nil, []string{"entity_id=light.nanoleaf,light.desk",
+		"color_temp=500", "area_id=kitchen"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"color_temp": float64(500)}, payload)
+	require.Equal(t, &api.Target{EntityId: []string{"light.nanoleaf", "light.desk"}, AreaId: []string{"kitchen"}},
+		target)
+
+	// a service that takes entity_id as data gets it as data
+	svc.Fields["entity_id"] = &api.ServiceField{Name: "entity_id", Type: api.String}
+	payload, target, err = serviceCallData(svc, &api.Target{AreaId: []string{"kitchen"}},
+		[]string{"entity_id=light.nanoleaf"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"entity_id": "light.nanoleaf"}, payload)
+	require.Equal(t, &api.Target{AreaId: []string{"kitchen"}}, target)
+
+	for _, bad := range []string{"color_temp", "brightness=5", "color_temp=warm"} {
+		_, _, err = serviceCallData(svc, nil, []string{bad})
+		require.Error(t, err, bad)
+	}
+}
@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var automationTracesCmd = &cobra.Command{
+	Use:   "traces [automation-id]",
+	Short: "list the stored runs of the given automation",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ret, err := client(cmd).ListTraces("automation", args[0])
+		if err != nil {
+			logrus.WithError(err).WithField("automation_id", args[0]).Fatal("could not list traces")
+		}
+		sort.Slice(ret, func(i, j int) bool { return ret[i].Timestamp.Start.Before(ret[j].Timestamp.Start) })
+
+		switch output, _ := cmd.Flags().GetString("output"); output {
+		case "text":
+			printTable(traceTable(ret), []string{"run_id", "start", "trigger", "state", "script_execution", "last_step", "error"})
+		case "json":
+			retJson, _ := json.Marshal(ret)
+			fmt.Println(string(retJson))
+		case "yaml":
+			printYAML(ret)
+		}
+	},
+	ValidArgsFunction: completeAutomationId,
+}
+
+var automationTraceCmd = &cobra.Command{
+	Use:   "trace [automation-id] [run-id]",
+	Short: "show the step-by-step trace of a single run of the given automation",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ret, err := client(cmd).GetTrace("automation", args[0], args[1])
+		if err != nil {
+			logrus.WithError(err).WithField("automation_id", args[0]).Fatal("could not get trace")
+		}
+
+		switch output, _ := cmd.Flags().GetString("output"); output {
+		case "text":
+			printTrace(os.Stdout, ret)
+		case "json":
+			retJson, _ := json.Marshal(ret)
+			fmt.Println(string(retJson))
+		case "yaml":
+			printYAML(ret)
+		}
+	},
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return completeAutomationId(cmd, args, toComplete)
+		}
+		if len(args) > 1 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		traces, err := client(cmd).ListTraces("automation", args[0])
+		if err != nil {
+			logrus.WithError(err).Error("could not list traces")
+			return nil, cobra.ShellCompDirectiveError
+		}
+		var ret []string
+		for _, t := range traces {
+			if strings.HasPrefix(t.RunId, toComplete) {
+				ret = append(ret, t.RunId)
+			}
+		}
+		return ret, cobra.ShellCompDirectiveNoFileComp
+	},
+}
+
+// traceTable returns a row for each of the given traces, as `automation traces` prints them.
+func traceTable(traces []api.TraceSummary) []map[string]string {
+	var table []map[string]string
+	for _, t := range traces {
+		table = append(table, map[string]string{
+			"run_id":           t.RunId,
+			"start":            t.Timestamp.Start.Local().Format(time.RFC3339),
+			"state":            t.State,
+			"script_execution": t.ScriptExecution,
+			"last_step":        t.LastStep,
+			"trigger":          t.Trigger,
+			"error":            t.Error,
+		})
+	}
+	return table
+}
+
+// traceNode is a single step path within a trace, along with the steps nested beneath it.
+type traceNode struct {
+	path     string
+	steps    []api.TraceStep
+	children []*traceNode
+}
+
+// traceTree arranges the steps of a trace into a tree, where each path is the child of the longest other path that
+// prefixes it (e.g. `action/0/choose/0/sequence/0` is a child of `action/0`). Siblings are ordered by the time they
+// first ran.
+func traceTree(trace *api.Trace) []*traceNode {
+	var nodes []*traceNode
+	for path, steps := range trace.Steps {
+		nodes = append(nodes, &traceNode{path: path, steps: steps})
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		ti, tj := firstRun(nodes[i]), firstRun(nodes[j])
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return nodes[i].path < nodes[j].path
+	})
+
+	var roots []*traceNode
+	for i, node := range nodes {
+		var parent *traceNode
+		for j, candidate := range nodes {
+			if i == j || !strings.HasPrefix(node.path, candidate.path+"/") {
+				continue
+			}
+			if parent == nil || len(candidate.path) > len(parent.path) {
+				parent = candidate
+			}
+		}
+		if parent == nil {
+			roots = append(roots, node)
+		} else {
+			parent.children = append(parent.children, node)
+		}
+	}
+	return roots
+}
+
+func firstRun(node *traceNode) time.Time {
+	if len(node.steps) == 0 {
+		return time.Time{}
+	}
+	return node.steps[0].Timestamp
+}
+
+// printTrace renders the trace as a tree of steps, with each step's result, changed variables, and error.
+func printTrace(w io.Writer, trace *api.Trace) {
+	fmt.Fprintf(w, "Run %s of %s.%s\n", trace.RunId, trace.Domain, trace.ItemId)
+	fmt.Fprintf(w, "Started:      %s\n", trace.Timestamp.Start.Local().Format(time.RFC3339Nano))
+	if trace.Timestamp.Finish != nil {
+		fmt.Fprintf(w, "Finished:     %s (%s)\n", trace.Timestamp.Finish.Local().Format(time.RFC3339Nano),
+			trace.Timestamp.Finish.Sub(trace.Timestamp.Start))
+	}
+	if trace.Trigger != "" {
+		fmt.Fprintf(w, "Triggered by: %s\n", trace.Trigger)
+	}
+	fmt.Fprintf(w, "State:        %s (%s)\n", trace.State, trace.ScriptExecution)
+	if trace.Error != "" {
+		fmt.Fprintf(w, "Error:        %s\n", trace.Error)
+	}
+	fmt.Fprintln(w)
+
+	roots := traceTree(trace)
+	for i, root := range roots {
+		printTraceNode(w, root, "", i == len(roots)-1)
+	}
+}
+
+func printTraceNode(w io.Writer, node *traceNode, prefix string, last bool) {
+	branch, indent := "├─ ", "│  "
+	if last {
+		branch, indent = "└─ ", "   "
+	}
+
+	for i, step := range node.steps {
+		label := node.path
+		if len(node.steps) > 1 {
+			label = fmt.Sprintf("%s (run %d of %d)", node.path, i+1, len(node.steps))
+		}
+		if i == 0 {
+			fmt.Fprintf(w, "%s%s%s  %s\n", prefix, branch, label, step.Timestamp.Local().Format("15:04:05.000"))
+		} else {
+			fmt.Fprintf(w, "%s%s%s  %s\n", prefix, indent, label, step.Timestamp.Local().Format("15:04:05.000"))
+		}
+
+		detail := prefix + indent + "   "
+		if len(step.Result) > 0 {
+			fmt.Fprintf(w, "%sresult: %s\n", detail, traceValues(step.Result))
+		}
+		if len(step.ChangedVariables) > 0 {
+			fmt.Fprintf(w, "%svariables: %s\n", detail, traceValues(step.ChangedVariables))
+		}
+		if step.ChildId != nil {
+			fmt.Fprintf(w, "%sstarted: %s.%s run %s\n", detail, step.ChildId.Domain, step.ChildId.ItemId,
+				step.ChildId.RunId)
+		}
+		if step.Error != "" {
+			fmt.Fprintf(w, "%serror: %s\n", detail, step.Error)
+		}
+	}
+
+	for i, child := range node.children {
+		printTraceNode(w, child, prefix+indent, i == len(node.children)-1)
+	}
+}
+
+// traceValues renders a map as sorted key=value pairs, shortening long values; the full values are available via
+// `-o json`.
+func traceValues(values map[string]interface{}) string {
+	var keys []string
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, k := range keys {
+		var v string
+		if s, ok := values[k].(string); ok {
+			v = s
+		} else {
+			vb, _ := json.Marshal(values[k])
+			v = string(vb)
+		}
+		if runes := []rune(v); len(runes) > 80 {
+			v = string(runes[:77]) + "..."
+		}
+		pairs = append(pairs, k+"="+v)
+	}
+	return strings.Join(pairs, " ")
+}
+
+func init() {
+	automationCmd.AddCommand(automationTracesCmd, automationTraceCmd)
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTraceTable(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	table := traceTable([]api.TraceSummary{{
+		RunId:           "abc",
+		State:           "stopped",
+		ScriptExecution: "finished",
+		LastStep:        "action/0",
+		Trigger:         "state of light.kitchen",
+		Timestamp:       api.TraceTimestamp{Start: start},
+	}})
+	require.Equal(t, []map[string]string{{
+		"run_id":           "abc",
+		"start":            start.Local().Format(time.RFC3339),
+		"state":            "stopped",
+		"script_execution": "finished",
+		"last_step":        "action/0",
+		"trigger":          "state of light.kitchen",
+		"error":            "",
+	}}, table)
+}
+
+func TestPrintTrace(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	finish := start.Add(2 * time.Second)
+	trace := &api.Trace{
+		TraceSummary: api.TraceSummary{
+			Domain:          "automation",
+			ItemId:          "hall_light",
+			RunId:           "abc",
+			State:           "stopped",
+			ScriptExecution: "finished",
+			Trigger:         "state of binary_sensor.door",
+			Timestamp:       api.TraceTimestamp{Start: start, Finish: &finish},
+		},
+		Steps: map[string][]api.TraceStep{
+			"trigger/0": {{Path: "trigger/0", Timestamp: start}},
+			"action/0": {{
+				Path:      "action/0",
+				Timestamp: start.Add(time.Second),
+				Result:    map[string]interface{}{"choice": 0.0},
+			}},
+			"action/0/choose/0/sequence/0": {{
+				Path:      "action/0/choose/0/sequence/0",
+				Timestamp: start.Add(1500 * time.Millisecond),
+				Error:     "light.hall is unavailable",
+			}},
+		},
+	}
+
+	var buf bytes.Buffer
+	printTrace(&buf, trace)
+	out := buf.String()
+
+	require.Contains(t, out, "Run abc of automation.hall_light\n")
+	require.Contains(t, out, "Triggered by: state of binary_sensor.door\n")
+	require.Contains(t, out, "State:        stopped (finished)\n")
+
+	var paths []string
+	for _, line := range strings.Split(out, "\n") {
+		if i := strings.IndexAny(line, "├└"); i >= 0 {
+			paths = append(paths, strings.Fields(line[i:])[1])
+		}
+	}
+	require.Equal(t, []string{"trigger/0", "action/0", "action/0/choose/0/sequence/0"}, paths)
+	require.Contains(t, out, "result: choice=0\n")
+	require.Contains(t, out, "error: light.hall is unavailable\n")
+}
+
+func TestTraceValues(t *testing.T) {
+	require.Equal(t, `a=1 b=x c={"d":true}`, traceValues(map[string]interface{}{
+		"b": "x",
+		"a": 1.0,
+		"c": map[string]interface{}{"d": true},
+	}))
+
+	long := strings.Repeat("é", 100)
+	got := traceValues(map[string]interface{}{"v": long})
+	require.Equal(t, "v="+strings.Repeat("é", 77)+"...", got)
+}
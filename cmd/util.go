@@ -3,9 +3,13 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"sort"
+	"strconv"
+	"strings"
 
+	"github.com/asymmetricia/ghastly/api"
 	prettyTable "github.com/jedib0t/go-pretty/v6/table"
 	"github.com/jedib0t/go-pretty/v6/text"
 	"github.com/sirupsen/logrus"
@@ -22,8 +26,10 @@ func (t *tableCell) UnmarshalJSON(data []byte) error {
 	case string:
 		*t = tableCell(o)
 	case float64:
-		*t = tableCell(fmt.Sprintf("%f", o))
-	case []interface{}:
+		*t = tableCell(strconv.FormatFloat(o, 'f', -1, 64))
+	case bool:
+		*t = tableCell(fmt.Sprint(o))
+	case []interface{}, map[string]interface{}:
 		*t = tableCell(data)
 	case nil:
 	default:
@@ -93,3 +99,47 @@ func printTable(obj interface{}, order ...[]string) {
 	}
 	t.Render()
 }
+
+// printYAML prints obj as YAML. Like printTable, it goes by way of obj's JSON
+// representation, so that field names match those of `-o json`.
+func printYAML(obj interface{}) {
+	var generic interface{}
+	jsonBytes, err := json.Marshal(obj)
+	if err == nil {
+		err = json.Unmarshal(jsonBytes, &generic)
+	}
+	var yamlBytes []byte
+	if err == nil {
+		yamlBytes, err = api.EncodeYAML(generic)
+	}
+	if err != nil {
+		logrus.Fatalf("converting to YAML: %v", err)
+	}
+	fmt.Print(string(yamlBytes))
+}
+
+// readFileOrStdin reads the named file, or stdin if the name is `-`.
+func readFileOrStdin(file string) ([]byte, error) {
+	if file == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(file)
+}
+
+// parseAssignments parses `name=value` pairs into a map, with each value parsed as YAML; so `120` is a number, `[a, b]`
+// is a list, and `on` is a string.
+func parseAssignments(pairs []string) (map[string]interface{}, error) {
+	ret := map[string]interface{}{}
+	for _, pair := range pairs {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q should be name=value", pair)
+		}
+		generic, err := api.DecodeYAML([]byte(value))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		ret[name] = generic
+	}
+	return ret, nil
+}
@@ -0,0 +1,448 @@
+// Package codegen generates Go packages for calling homeassistant services, one package per domain, with a typed
+// function and data struct for each service. For example, given the `light` domain, it generates a package `light`
+// such that
+//
+//	light.TurnOn(client, &api.Target{AreaId: []string{"kitchen"}}, light.TurnOnData{Brightness: api.Float(128)})
+//
+// checks its data against the service's fields as they were when the package was generated, and then calls
+// `light.turn_on`.
+package codegen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/asymmetricia/ghastly/api"
+)
+
+// Header is the first line of each generated file, which marks it as generated for tools that care.
+const Header = "// Code generated by ghastly codegen services; DO NOT EDIT."
+
+// PackageName returns the name of the package generated for the given domain: the domain without underscores (e.g.,
+// `media_player` becomes `mediaplayer`), with `svc` appended if it would otherwise be a Go keyword (e.g., `switchsvc`).
+// Names that would start with a digit are prefixed with `x`, as goName does.
+func PackageName(domain string) string {
+	ret := strings.ToLower(strings.ReplaceAll(domain, "_", ""))
+	if token.IsKeyword(ret) {
+		ret += "svc"
+	}
+	if ret == "" || unicode.IsDigit([]rune(ret)[0]) {
+		ret = "x" + ret
+	}
+	return ret
+}
+
+// Write generates a package for each of the given domains that appears in services, or for every domain if none are
+// given, in a directory named for the package under dir.
+func Write(dir string, services []api.Service, domains ...string) error {
+	byDomain := map[string][]api.Service{}
+	for _, svc := range services {
+		byDomain[svc.Domain] = append(byDomain[svc.Domain], svc)
+	}
+
+	if len(domains) == 0 {
+		for domain := range byDomain {
+			domains = append(domains, domain)
+		}
+		sort.Strings(domains)
+	}
+
+	for _, domain := range domains {
+		if len(byDomain[domain]) == 0 {
+			return fmt.Errorf("domain %q has no services", domain)
+		}
+
+		src, err := Package(domain, byDomain[domain])
+		if err != nil {
+			return err
+		}
+
+		pkg := PackageName(domain)
+		if err := os.MkdirAll(filepath.Join(dir, pkg), 0755); err != nil {
+			return fmt.Errorf("creating package directory for %q: %w", domain, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, pkg, pkg+".go"), src, 0644); err != nil {
+			return fmt.Errorf("writing package for %q: %w", domain, err)
+		}
+	}
+
+	return nil
+}
+
+// Package returns the formatted source of the package generated for the given domain's services. Services of other
+// domains are ignored.
+func Package(domain string, services []api.Service) ([]byte, error) {
+	var svcs []api.Service
+	for _, svc := range services {
+		if svc.Domain == domain {
+			svcs = append(svcs, svc)
+		}
+	}
+	if len(svcs) == 0 {
+		return nil, errors.New("no services to generate")
+	}
+	sort.Slice(svcs, func(i, j int) bool {
+		return svcs[i].Name < svcs[j].Name
+	})
+
+	g := &generator{names: map[string]bool{}, imports: map[string]bool{}}
+	for _, svc := range svcs {
+		g.service(svc)
+	}
+	body := g.buf.String()
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "%s\n\n", Header)
+	fmt.Fprintf(&out, "// Package %s calls the services of the homeassistant `%s` domain.\n", PackageName(domain), domain)
+	fmt.Fprintf(&out, "package %s\n\nimport (\n", PackageName(domain))
+	for _, imp := range []string{"errors", "fmt"} {
+		if g.imports[imp] {
+			fmt.Fprintf(&out, "\t%q\n", imp)
+		}
+	}
+	fmt.Fprintf(&out, "\n\t\"github.com/asymmetricia/ghastly/api\"\n)\n%s", body)
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated source for %q: %w", domain, err)
+	}
+	return src, nil
+}
+
+type generator struct {
+	buf bytes.Buffer
+	// names holds the package-level identifiers declared so far, so that later ones can be made unique
+	names map[string]bool
+	// imports holds the standard packages the generated code uses, other than api, which it always uses
+	imports map[string]bool
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// name returns a package-level identifier based on the given name, which has not been used before.
+func (g *generator) name(name string) string {
+	ret := name
+	for i := 2; g.names[ret]; i++ {
+		ret = name + strconv.Itoa(i)
+	}
+	g.names[ret] = true
+	return ret
+}
+
+// field is a service field as it appears in a generated data struct.
+type field struct {
+	*api.ServiceField
+	GoName string
+	GoType string
+	// Enum is the name of the field's enumerated type, if it has one; its element type if the field is a list.
+	Enum       string
+	EnumValues []string
+	List       bool
+	Pointer    bool
+}
+
+// dataMethods holds the names of the methods of generated data structs, which fields cannot also have.
+var dataMethods = map[string]bool{"Map": true, "Validate": true}
+
+func (g *generator) service(svc api.Service) {
+	funcName := g.name(goName(svc.Name))
+	qualified := svc.Domain + "." + svc.Name
+
+	var fields []*field
+	var keys []string
+	for key := range svc.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	used := map[string]bool{}
+	for _, key := range keys {
+		name := goName(key)
+		if dataMethods[name] {
+			name += "Field"
+		}
+		f := &field{ServiceField: svc.Fields[key], GoName: name}
+		for i := 2; used[f.GoName]; i++ {
+			f.GoName = name + strconv.Itoa(i)
+		}
+		used[f.GoName] = true
+		fields = append(fields, f)
+	}
+
+	var dataName string
+	if len(fields) > 0 {
+		dataName = g.name(funcName + "Data")
+		for _, f := range fields {
+			g.fieldType(funcName, dataName, f)
+		}
+		g.dataStruct(qualified, dataName, fields)
+	}
+
+	g.printf("\n")
+	g.comment("", fmt.Sprintf("%s calls %s. %s", funcName, qualified, svc.Description))
+	params := []string{"c *api.Client"}
+	if svc.Target != nil {
+		params = append(params, "target *api.Target")
+	}
+	if dataName != "" {
+		params = append(params, "data "+dataName)
+	}
+	g.printf("func %s(%s) (*api.CallResult, error) {\n", funcName, strings.Join(params, ", "))
+	if svc.Response != nil && !svc.Response.Optional {
+		g.printf("\topts := api.CallOptions{ReturnResponse: true}\n")
+	} else {
+		g.printf("\topts := api.CallOptions{}\n")
+	}
+	if svc.Target != nil {
+		g.printf("\topts.Target = target\n")
+	}
+	if dataName != "" {
+		g.printf("\tif err := data.Validate(); err != nil {\n")
+		g.imports["fmt"] = true
+		g.printf("\t\treturn nil, fmt.Errorf(\"%s: %%w\", err)\n", qualified)
+		g.printf("\t}\n")
+		g.printf("\topts.Data = data.Map()\n")
+	}
+	g.printf("\treturn c.CallService(%q, %q, opts)\n", svc.Domain, svc.Name)
+	g.printf("}\n")
+}
+
+// fieldType decides the Go type of the given field, declaring an enumerated type for it if it has a fixed set of
+// values. Numbers and booleans are pointers, so that unset can be told from zero or false; other types are left unset
+// by leaving them empty.
+func (g *generator) fieldType(funcName, dataName string, f *field) {
+	var values []string
+	custom := false
+	switch {
+	case f.Selector != nil:
+		switch f.Selector.Kind {
+		case "number", "color_temp":
+			f.GoType, f.Pointer = "float64", true
+		case "boolean":
+			f.GoType, f.Pointer = "bool", true
+		case "color_rgb":
+			f.GoType = "[]int"
+		case "select":
+			f.GoType = "string"
+			custom = f.Selector.Select.CustomValue
+			for _, option := range f.Selector.Select.Options {
+				values = append(values, option.Value)
+			}
+		case "entity", "device", "area", "floor", "label", "text", "template", "icon", "theme", "time", "date",
+			"datetime", "conversation_agent":
+			f.GoType = "string"
+		default:
+			f.GoType = "interface{}"
+		}
+		if f.Selector.Multiple() && f.GoType == "string" {
+			f.GoType, f.List = "[]string", true
+		}
+	case f.Type == api.Number:
+		f.GoType, f.Pointer = "float64", true
+	case f.Type == api.Boolean:
+		f.GoType, f.Pointer = "bool", true
+	case f.Type == api.String:
+		f.GoType = "string"
+	case f.Type == api.Values:
+		f.GoType = "string"
+		for _, v := range f.Values {
+			s, ok := v.(string)
+			if !ok {
+				f.GoType, values = "interface{}", nil
+				break
+			}
+			values = append(values, s)
+		}
+	default:
+		f.GoType = "interface{}"
+	}
+
+	if len(values) == 0 || custom {
+		return
+	}
+
+	seen := map[string]bool{"": true}
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			f.EnumValues = append(f.EnumValues, v)
+		}
+	}
+	if len(f.EnumValues) == 0 {
+		return
+	}
+
+	f.Enum = g.name(funcName + f.GoName)
+	if f.List {
+		f.GoType = "[]" + f.Enum
+	} else {
+		f.GoType = f.Enum
+	}
+
+	g.printf("\n// %s is a value of %s.%s.\n", f.Enum, dataName, f.GoName)
+	g.printf("type %s string\n\nconst (\n", f.Enum)
+	for _, v := range f.EnumValues {
+		g.printf("\t%s %s = %q\n", g.name(f.Enum+goName(v)), f.Enum, v)
+	}
+	g.printf(")\n")
+}
+
+func (g *generator) dataStruct(qualified, dataName string, fields []*field) {
+	g.printf("\n// %s holds the fields of %s.\n", dataName, qualified)
+	g.printf("type %s struct {\n", dataName)
+	for i, f := range fields {
+		if i > 0 {
+			g.printf("\n")
+		}
+		doc := fmt.Sprintf("%s is field `%s`.", f.GoName, f.Name)
+		if f.Required {
+			doc += " It is required."
+		}
+		if f.Description != "" {
+			doc += " " + f.Description
+		}
+		if f.Selector != nil && f.Selector.Number != nil && f.Selector.Number.UnitOfMeasurement != "" {
+			doc += " In " + f.Selector.Number.UnitOfMeasurement + "."
+		}
+		g.comment("\t", doc)
+		typ := f.GoType
+		if f.Pointer {
+			typ = "*" + typ
+		}
+		g.printf("\t%s %s `json:\"%s,omitempty\"`\n", f.GoName, typ, f.Name)
+	}
+	g.printf("}\n")
+
+	g.printf("\n// Validate checks the data against the fields of %s as they were when this package was generated.\n",
+		qualified)
+	g.printf("func (d %s) Validate() error {\n", dataName)
+	for _, f := range fields {
+		ref := "d." + f.GoName
+		if f.Required {
+			g.printf("\tif %s {\n", unset(f, ref))
+			g.imports["errors"] = true
+			g.printf("\t\treturn errors.New(\"field %s is required\")\n", f.Name)
+			g.printf("\t}\n")
+		}
+		if f.Selector != nil && f.Selector.Number != nil {
+			if min := f.Selector.Number.Min; min != nil {
+				g.imports["fmt"] = true
+				g.printf("\tif %s != nil && *%s < %v {\n", ref, ref, *min)
+				g.printf("\t\treturn fmt.Errorf(\"field %s: %%v is less than the minimum, %v\", *%s)\n", f.Name, *min, ref)
+				g.printf("\t}\n")
+			}
+			if max := f.Selector.Number.Max; max != nil {
+				g.imports["fmt"] = true
+				g.printf("\tif %s != nil && *%s > %v {\n", ref, ref, *max)
+				g.printf("\t\treturn fmt.Errorf(\"field %s: %%v is more than the maximum, %v\", *%s)\n", f.Name, *max, ref)
+				g.printf("\t}\n")
+			}
+		}
+		if f.Enum != "" {
+			v := ref
+			if f.List {
+				g.printf("\tfor _, v := range %s {\n", ref)
+				v = "v"
+			}
+			g.printf("\tswitch %s {\n\tcase \"\"", v)
+			for _, value := range f.EnumValues {
+				g.printf(", %q", value)
+			}
+			g.printf(":\n\tdefault:\n")
+			msg := fmt.Sprintf("field %s: %%q is not one of %s", f.Name,
+				strings.ReplaceAll(strings.Join(f.EnumValues, ", "), "%", "%%"))
+			g.imports["fmt"] = true
+			g.printf("\t\treturn fmt.Errorf(%q, %s)\n", msg, v)
+			g.printf("\t}\n")
+			if f.List {
+				g.printf("\t}\n")
+			}
+		}
+	}
+	g.printf("\treturn nil\n}\n")
+
+	g.printf("\n// Map returns the data as service data, omitting unset fields.\n")
+	g.printf("func (d %s) Map() map[string]interface{} {\n", dataName)
+	g.printf("\tret := map[string]interface{}{}\n")
+	for _, f := range fields {
+		ref := "d." + f.GoName
+		g.printf("\tif %s {\n", set(f, ref))
+		switch {
+		case f.Pointer:
+			g.printf("\t\tret[%q] = *%s\n", f.Name, ref)
+		case f.Enum != "" && !f.List:
+			g.printf("\t\tret[%q] = string(%s)\n", f.Name, ref)
+		default:
+			g.printf("\t\tret[%q] = %s\n", f.Name, ref)
+		}
+		g.printf("\t}\n")
+	}
+	g.printf("\treturn ret\n}\n")
+}
+
+// unset returns an expression that is true if the given field is not set.
+func unset(f *field, ref string) string {
+	switch {
+	case f.Pointer, f.GoType == "interface{}":
+		return ref + " == nil"
+	case strings.HasPrefix(f.GoType, "[]"):
+		return "len(" + ref + ") == 0"
+	}
+	return ref + ` == ""`
+}
+
+// set returns an expression that is true if the given field is set.
+func set(f *field, ref string) string {
+	switch {
+	case f.Pointer, f.GoType == "interface{}":
+		return ref + " != nil"
+	case strings.HasPrefix(f.GoType, "[]"):
+		return "len(" + ref + ") > 0"
+	}
+	return ref + ` != ""`
+}
+
+// comment writes text as a comment, wrapped at 120 columns and with each line starting with indent.
+func (g *generator) comment(indent, text string) {
+	line := indent + "//"
+	for _, word := range strings.Fields(text) {
+		if len(line)+1+len(word) > 120 && line != indent+"//" {
+			g.printf("%s\n", line)
+			line = indent + "//"
+		}
+		line += " " + word
+	}
+	g.printf("%s\n", line)
+}
+
+// goName returns name as an exported Go identifier; e.g., `turn_on` becomes `TurnOn` and `entity_id` becomes
+// `EntityId`. Names that would start with a digit are prefixed with `X`.
+func goName(name string) string {
+	var ret strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		ret.WriteRune(r)
+	}
+	if ret.Len() == 0 || unicode.IsDigit([]rune(ret.String())[0]) {
+		return "X" + ret.String()
+	}
+	return ret.String()
+}
@@ -0,0 +1,108 @@
+package codegen
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackage(t *testing.T) {
+	data, err := os.ReadFile("testdata/services.json")
+	require.NoError(t, err)
+	services, err := api.ParseServices(data)
+	require.NoError(t, err)
+
+	src, err := Package("light", services)
+	require.NoError(t, err)
+	golden, err := os.ReadFile("testdata/light.go.golden")
+	require.NoError(t, err)
+	require.Equal(t, string(golden), string(src))
+
+	_, err = Package("nonexistent", services)
+	require.Error(t, err)
+
+	dir := t.TempDir()
+	require.NoError(t, Write(dir, services, "switch", "weather"))
+	for _, path := range []string{"switchsvc/switchsvc.go", "weather/weather.go"} {
+		_, err := os.Stat(dir + "/" + path)
+		require.NoError(t, err, path)
+	}
+	require.Error(t, Write(dir, services, "nonexistent"))
+}
+
+// typeCheck parses and type-checks the given generated source, which format.Source does not.
+func typeCheck(t *testing.T, fset *token.FileSet, imp types.Importer, src []byte) {
+	t.Helper()
+	// the importer finds the api package relative to the file, so it must seem to be in this module
+	dir, err := filepath.Abs("testdata")
+	require.NoError(t, err)
+	file, err := parser.ParseFile(fset, filepath.Join(dir, "generated.go"), src, 0)
+	require.NoError(t, err)
+	_, err = (&types.Config{Importer: imp}).Check(file.Name.Name, fset, []*ast.File{file}, nil)
+	require.NoError(t, err, string(src))
+}
+
+func TestPackage_TypeCheck(t *testing.T) {
+	fset := token.NewFileSet()
+	imp := importer.ForCompiler(fset, "source", nil)
+
+	golden, err := os.ReadFile("testdata/light.go.golden")
+	require.NoError(t, err)
+	typeCheck(t, fset, imp, golden)
+
+	data, err := os.ReadFile("testdata/services.json")
+	require.NoError(t, err)
+	services, err := api.ParseServices(data)
+	require.NoError(t, err)
+	domains := map[string]bool{}
+	for _, svc := range services {
+		domains[svc.Domain] = true
+	}
+	for domain := range domains {
+		src, err := Package(domain, services)
+		require.NoError(t, err)
+		typeCheck(t, fset, imp, src)
+	}
+
+	// descriptions mentioning packages do not import them
+	src, err := Package("blinds", []api.Service{{
+		Domain:      "blinds",
+		Name:        "open",
+		Description: "Opens the blinds; see errors. and fmt. for details.",
+	}})
+	require.NoError(t, err)
+	typeCheck(t, fset, imp, src)
+
+	// fields named for the data struct's methods are renamed
+	src, err = Package("notify", []api.Service{{Domain: "notify", Name: "send", Fields: map[string]*api.ServiceField{
+		"map":      {Name: "map", Type: api.String},
+		"validate": {Name: "validate", Type: api.Boolean},
+	}}})
+	require.NoError(t, err)
+	require.Contains(t, string(src), "MapField string `json:\"map,omitempty\"`")
+	require.Contains(t, string(src), "ValidateField *bool `json:\"validate,omitempty\"`")
+	typeCheck(t, fset, imp, src)
+
+	// domains starting with digits still make valid packages
+	src, err = Package("3_day_blinds", []api.Service{{Domain: "3_day_blinds", Name: "open"}})
+	require.NoError(t, err)
+	typeCheck(t, fset, imp, src)
+}
+
+func TestNames(t *testing.T) {
+	require.Equal(t, "mediaplayer", PackageName("media_player"))
+	require.Equal(t, "switchsvc", PackageName("switch"))
+	require.Equal(t, "x3dayblinds", PackageName("3_day_blinds"))
+	require.Equal(t, "TurnOn", goName("turn_on"))
+	require.Equal(t, "EntityId", goName("entity_id"))
+	require.Equal(t, "X3dPrinter", goName("3d_printer"))
+	require.Equal(t, "TwiceDaily", goName("twice-daily"))
+}
@@ -0,0 +1,90 @@
+package graph
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WriteDOT writes the graph in graphviz DOT format. Multiple references between the same two nodes are drawn as a
+// single edge.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph ghastly {\n")
+	b.WriteString("  rankdir=LR;\n")
+	for _, n := range g.Nodes() {
+		fmt.Fprintf(&b, "  %s [label=%s, shape=%s];\n",
+			strconv.Quote(n.String()), strconv.Quote(g.Label(n)), dotShapes[n.Kind])
+	}
+	for _, e := range g.uniqueEdges() {
+		fmt.Fprintf(&b, "  %s -> %s;\n", strconv.Quote(e.From.String()), strconv.Quote(e.To.String()))
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var dotShapes = map[Kind]string{
+	Automation: "box",
+	Script:     "box",
+	Scene:      "box",
+	Entity:     "ellipse",
+	Device:     "component",
+	Area:       "folder",
+	Service:    "cds",
+}
+
+// WriteMermaid writes the graph as a mermaid flowchart, suitable for embedding in markdown documentation.
+func (g *Graph) WriteMermaid(w io.Writer) error {
+	ids := map[Node]string{}
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for i, n := range g.Nodes() {
+		ids[n] = fmt.Sprintf("n%d", i)
+		open, close := mermaidShapes[n.Kind][0], mermaidShapes[n.Kind][1]
+		fmt.Fprintf(&b, "  %s%s\"%s\"%s\n", ids[n], open, mermaidEscape(string(n.Kind)+": "+g.Label(n)), close)
+	}
+	for _, e := range g.uniqueEdges() {
+		fmt.Fprintf(&b, "  %s --> %s\n", ids[e.From], ids[e.To])
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var mermaidShapes = map[Kind][2]string{
+	Automation: {"[", "]"},
+	Script:     {"[", "]"},
+	Scene:      {"[", "]"},
+	Entity:     {"(", ")"},
+	Device:     {"[[", "]]"},
+	Area:       {"[/", "/]"},
+	Service:    {"{{", "}}"},
+}
+
+// mermaidEscape replaces the characters that cannot appear in a quoted mermaid label with their entity codes.
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;").Replace(s)
+}
+
+// uniqueEdges returns one edge for each pair of connected nodes, sorted.
+func (g *Graph) uniqueEdges() []Edge {
+	seen := map[[2]Node]bool{}
+	var ret []Edge
+	for _, e := range g.edges {
+		key := [2]Node{e.From, e.To}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		ret = append(ret, Edge{From: e.From, To: e.To})
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].From != ret[j].From {
+			return ret[i].From.String() < ret[j].From.String()
+		}
+		return ret[i].To.String() < ret[j].To.String()
+	})
+	return ret
+}
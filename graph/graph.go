@@ -0,0 +1,333 @@
+// Package graph builds a graph of what automations, scripts, and scenes refer to: entities, devices, areas, services,
+// and each other. It answers questions like "what uses this entity?" before it is removed or renamed.
+package graph
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/asymmetricia/ghastly/internal/refs"
+)
+
+type Kind string
+
+const (
+	Automation Kind = "automation"
+	Script     Kind = "script"
+	Scene      Kind = "scene"
+	Entity     Kind = "entity"
+	Device     Kind = "device"
+	Area       Kind = "area"
+	Service    Kind = "service"
+)
+
+// Node is a single thing in the graph. Automations are identified by config ID, scripts and scenes by entity ID, and
+// services by `domain.service`.
+type Node struct {
+	Kind Kind   `json:"kind"`
+	Id   string `json:"id"`
+}
+
+func (n Node) String() string {
+	return string(n.Kind) + ":" + n.Id
+}
+
+// ParseNode parses the `kind:id` form returned by Node.String. If there is no known kind prefix, the node is taken to
+// be an entity.
+func ParseNode(s string) Node {
+	if i := strings.Index(s, ":"); i > 0 {
+		switch kind := Kind(s[:i]); kind {
+		case Automation, Script, Scene, Entity, Device, Area, Service:
+			return Node{kind, s[i+1:]}
+		}
+	}
+	return Node{Entity, s}
+}
+
+// Edge is a reference from one node to another. Path locates the reference within From, the same way
+// homeassistant's traces do, e.g. `action/1/choose/0/sequence/2`.
+type Edge struct {
+	From Node   `json:"from"`
+	To   Node   `json:"to"`
+	Path string `json:"path"`
+}
+
+// Graph holds the references between nodes. The zero value is not usable; use New.
+type Graph struct {
+	labels map[Node]string
+	edges  []Edge
+}
+
+func New() *Graph {
+	return &Graph{labels: map[Node]string{}}
+}
+
+// AddAutomation adds the references made by the given automation.
+func (g *Graph) AddAutomation(automation *api.Automation) {
+	from := Node{Automation, string(automation.Id)}
+	g.labels[from] = automation.Alias
+
+	w := &walker{graph: g, from: from}
+	for i, trigger := range automation.Trigger {
+		w.trigger(fmt.Sprintf("trigger/%d", i), trigger.Trigger)
+	}
+	w.conditions("condition", automation.Condition)
+	w.actions("action", automation.Action)
+}
+
+// AddScript adds the references made by the script with the given entity ID, whose actions are sequence.
+func (g *Graph) AddScript(entityId string, alias string, sequence api.ActionSequence) {
+	from := Node{Script, entityId}
+	g.labels[from] = alias
+	w := &walker{graph: g, from: from}
+	w.actions("sequence", sequence)
+}
+
+// AddScene adds the references made by the scene with the given entity ID to the entities it sets.
+func (g *Graph) AddScene(entityId string, name string, entityIds ...string) {
+	from := Node{Scene, entityId}
+	g.labels[from] = name
+	for _, id := range entityIds {
+		g.add(from, Node{Entity, id}, "entities/"+id)
+	}
+}
+
+func (g *Graph) add(from, to Node, path string) {
+	if _, ok := g.labels[to]; !ok {
+		g.labels[to] = ""
+	}
+	g.edges = append(g.edges, Edge{from, to, path})
+}
+
+// Label returns the human-readable name of the node, if it has one, or else its ID.
+func (g *Graph) Label(n Node) string {
+	if label := g.labels[n]; label != "" {
+		return label
+	}
+	return n.Id
+}
+
+// Nodes returns every node in the graph, sorted.
+func (g *Graph) Nodes() []Node {
+	ret := make([]Node, 0, len(g.labels))
+	for n := range g.labels {
+		ret = append(ret, n)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].String() < ret[j].String() })
+	return ret
+}
+
+// Edges returns every edge in the graph, in the order they were added.
+func (g *Graph) Edges() []Edge {
+	return append([]Edge(nil), g.edges...)
+}
+
+// Uses returns the edges that refer to n. Scripts and scenes are also entities, so a script or scene node and the
+// entity node with the same ID are treated as the same.
+func (g *Graph) Uses(n Node) []Edge {
+	var ret []Edge
+	for _, e := range g.edges {
+		if e.To == n || sameEntity(e.To, n) {
+			ret = append(ret, e)
+		}
+	}
+	return ret
+}
+
+// References returns the edges from n.
+func (g *Graph) References(n Node) []Edge {
+	var ret []Edge
+	for _, e := range g.edges {
+		if e.From == n {
+			ret = append(ret, e)
+		}
+	}
+	return ret
+}
+
+// Missing returns the edges to entities for which exists returns false.
+func (g *Graph) Missing(exists func(entityId string) bool) []Edge {
+	var ret []Edge
+	for _, e := range g.edges {
+		if e.To.Kind == Entity && !exists(e.To.Id) {
+			ret = append(ret, e)
+		}
+	}
+	return ret
+}
+
+func sameEntity(a, b Node) bool {
+	entityLike := func(n Node) bool { return n.Kind == Entity || n.Kind == Script || n.Kind == Scene }
+	return a.Id == b.Id && entityLike(a) && entityLike(b)
+}
+
+type walker struct {
+	graph *Graph
+	from  Node
+}
+
+func (w *walker) entities(path string, entityIds ...string) {
+	for _, id := range entityIds {
+		if id == "" || id == "all" || id == "none" || refs.Dynamic(id) || !refs.LooksLikeEntityId(id) {
+			continue
+		}
+		w.graph.add(w.from, Node{Entity, id}, path)
+	}
+}
+
+func (w *walker) devices(path string, deviceIds ...string) {
+	for _, id := range deviceIds {
+		if id != "" && !refs.Dynamic(id) {
+			w.graph.add(w.from, Node{Device, id}, path)
+		}
+	}
+}
+
+// templateEntity matches the ways a template usually refers to an entity: `states('light.x')`, `is_state('light.x',
+// 'on')`, `state_attr('light.x', 'brightness')`, and `states.light.x`.
+var templateEntity = regexp.MustCompile(
+	`(?:states|is_state|state_attr|is_state_attr|has_value)\(\s*['"]([a-z0-9_]+\.[a-z0-9_]+)['"]|` +
+		`states\.([a-z0-9_]+\.[a-z0-9_]+)`)
+
+func (w *walker) template(path string, template string) {
+	for _, match := range templateEntity.FindAllStringSubmatch(template, -1) {
+		w.entities(path, match[1]+match[2])
+	}
+}
+
+func (w *walker) trigger(path string, trigger api.Trigger) {
+	switch t := trigger.(type) {
+	case *api.StateTrigger:
+		w.entities(path, t.EntityId.Values...)
+	case *api.NumericStateTrigger:
+		w.entities(path, t.EntityId.Values...)
+		w.bounds(path, t.Above, t.Below)
+		w.template(path, t.ValueTemplate)
+	case *api.ZoneTrigger:
+		w.entities(path, t.EntityId.Values...)
+		w.entities(path, t.Zone)
+	case *api.TimeTrigger:
+		w.entities(path, t.At.Values...)
+	case *api.CalendarTrigger:
+		w.entities(path, t.EntityId)
+	case *api.TemplateTrigger:
+		w.template(path, t.ValueTemplate)
+	case *api.DeviceTrigger:
+		w.devices(path, t.DeviceId)
+		w.entities(path, t.EntityId)
+	case *api.TagTrigger:
+		w.devices(path, t.DeviceId.Values...)
+	}
+}
+
+func (w *walker) bounds(path string, bounds ...api.StringOrFloat) {
+	for _, bound := range bounds {
+		if _, ok := bound.Float(); !ok && bound.IsSet() {
+			w.entities(path, bound.String())
+		}
+	}
+}
+
+func (w *walker) conditions(path string, conditions api.ConditionList) {
+	for i, condition := range conditions {
+		w.condition(fmt.Sprintf("%s/%d", path, i), condition.Condition)
+	}
+}
+
+func (w *walker) condition(path string, condition api.Condition) {
+	switch c := condition.(type) {
+	case *api.AndCondition:
+		w.conditions(path+"/conditions", c.Conditions)
+	case *api.OrCondition:
+		w.conditions(path+"/conditions", c.Conditions)
+	case *api.NotCondition:
+		w.conditions(path+"/conditions", c.Conditions)
+	case *api.StateCondition:
+		w.entities(path, c.EntityId.Values...)
+	case *api.NumericStateCondition:
+		w.entities(path, c.EntityId.Values...)
+		w.bounds(path, c.Above, c.Below)
+		w.template(path, c.ValueTemplate)
+	case *api.ZoneCondition:
+		w.entities(path, c.EntityId.Values...)
+		w.entities(path, c.Zone.Values...)
+	case *api.TimeCondition:
+		w.entities(path, c.After, c.Before)
+	case *api.TemplateCondition:
+		w.template(path, c.ValueTemplate)
+	case *api.DeviceCondition:
+		w.devices(path, c.DeviceId)
+		w.entities(path, c.EntityId)
+	}
+}
+
+func (w *walker) actions(path string, actions api.ActionSequence) {
+	for i, action := range actions {
+		w.action(fmt.Sprintf("%s/%d", path, i), action.Action)
+	}
+}
+
+func (w *walker) action(path string, action api.Action) {
+	switch a := action.(type) {
+	case *api.ServiceAction:
+		w.service(path, a)
+	case *api.SceneAction:
+		w.entities(path, a.Scene)
+	case *api.DeviceAction:
+		w.devices(path, a.DeviceId)
+		w.entities(path, a.EntityId)
+	case *api.WaitAction:
+		w.template(path, a.WaitTemplate)
+	case *api.ConditionAction:
+		w.condition(path, a.Condition)
+	case *api.ChooseAction:
+		for i, option := range a.Choose {
+			optionPath := fmt.Sprintf("%s/choose/%d", path, i)
+			w.conditions(optionPath+"/conditions", option.Conditions)
+			w.actions(optionPath+"/sequence", option.Sequence)
+		}
+		w.actions(path+"/default", a.Default)
+	case *api.IfAction:
+		w.conditions(path+"/if", a.If)
+		w.actions(path+"/then", a.Then)
+		w.actions(path+"/else", a.Else)
+	case *api.RepeatAction:
+		w.conditions(path+"/repeat/while", a.Repeat.While)
+		w.conditions(path+"/repeat/until", a.Repeat.Until)
+		w.actions(path+"/repeat/sequence", a.Repeat.Sequence)
+	case *api.ParallelAction:
+		w.actions(path+"/parallel", a.Parallel)
+	case *api.SequenceAction:
+		w.actions(path+"/sequence", a.Sequence)
+	case *api.WaitForTriggerAction:
+		for i, trigger := range a.WaitForTrigger {
+			w.trigger(fmt.Sprintf("%s/wait_for_trigger/%d", path, i), trigger.Trigger)
+		}
+	}
+}
+
+func (w *walker) service(path string, action *api.ServiceAction) {
+	name := action.ServiceName()
+	if name != "" && !refs.Dynamic(name) {
+		w.graph.add(w.from, Node{Service, name}, path)
+		// scripts may be called directly as services
+		if strings.HasPrefix(name, "script.") && name != "script.turn_on" && name != "script.turn_off" &&
+			name != "script.toggle" && name != "script.reload" {
+			w.entities(path, name)
+		}
+	}
+
+	w.entities(path, action.EntityId.Values...)
+	for _, target := range []map[string]interface{}{action.Target, action.Data} {
+		w.entities(path, refs.StringValues(target["entity_id"])...)
+		w.devices(path, refs.StringValues(target["device_id"])...)
+		for _, area := range refs.StringValues(target["area_id"]) {
+			if !refs.Dynamic(area) {
+				w.graph.add(w.from, Node{Area, area}, path)
+			}
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/stretchr/testify/require"
+)
+
+func testGraph(t *testing.T) *Graph {
+	var automation api.Automation
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"id": "motion_lights",
+		"alias": "Motion \"lights\"",
+		"triggers": [
+			{"trigger": "state", "entity_id": "binary_sensor.motion", "to": "on"},
+			{"trigger": "device", "device_id": "abc123", "domain": "zha", "type": "remote_button_short_press"},
+			{"trigger": "time", "at": ["07:30", "input_datetime.wake"]}
+		],
+		"conditions": [
+			{"condition": "or", "conditions": [
+				"{{ is_state('sun.sun', 'below_horizon') }}",
+				{"condition": "numeric_state", "entity_id": "sensor.lux", "below": "input_number.lux_threshold"}
+			]}
+		],
+		"actions": [
+			{"action": "light.turn_on", "target": {"entity_id": ["light.kitchen"], "area_id": "hallway"}},
+			{"choose": [
+				{"conditions": [{"condition": "state", "entity_id": "light.kitchen", "state": "on"}],
+					"sequence": [{"action": "script.wind_down"}]}
+			], "default": [{"scene": "scene.evening"}]},
+			{"action": "notify.{{ target }}", "data": {"message": "{{ states.sensor.temperature.state }}"}}
+		]
+	}`), &automation))
+
+	g := New()
+	g.AddAutomation(&automation)
+	g.AddScript("script.wind_down", "Wind down", api.ActionSequence{
+		{Action: &api.ServiceAction{Action: "light.turn_off", EntityId: api.StringListOf("light.bedroom")}},
+	})
+	g.AddScene("scene.evening", "Evening", "light.kitchen", "light.lamp")
+	return g
+}
+
+func TestReferences(t *testing.T) {
+	g := testGraph(t)
+
+	var refs []string
+	for _, e := range g.References(Node{Automation, "motion_lights"}) {
+		refs = append(refs, e.Path+" "+e.To.String())
+	}
+	require.Equal(t, []string{
+		"trigger/0 entity:binary_sensor.motion",
+		"trigger/1 device:abc123",
+		"trigger/2 entity:input_datetime.wake",
+		"condition/0/conditions/0 entity:sun.sun",
+		"condition/0/conditions/1 entity:sensor.lux",
+		"condition/0/conditions/1 entity:input_number.lux_threshold",
+		"action/0 service:light.turn_on",
+		"action/0 entity:light.kitchen",
+		"action/0 area:hallway",
+		"action/1/choose/0/conditions/0 entity:light.kitchen",
+		"action/1/choose/0/sequence/0 service:script.wind_down",
+		"action/1/choose/0/sequence/0 entity:script.wind_down",
+		"action/1/default/0 entity:scene.evening",
+	}, refs)
+}
+
+func TestUses(t *testing.T) {
+	g := testGraph(t)
+
+	var users []string
+	for _, e := range g.Uses(ParseNode("light.kitchen")) {
+		users = append(users, e.From.String()+" "+e.Path)
+	}
+	require.Equal(t, []string{
+		"automation:motion_lights action/0",
+		"automation:motion_lights action/1/choose/0/conditions/0",
+		"scene:scene.evening entities/light.kitchen",
+	}, users)
+
+	// a script is used by whatever calls it as a service
+	uses := g.Uses(ParseNode("script:script.wind_down"))
+	require.Len(t, uses, 1)
+	require.Equal(t, "action/1/choose/0/sequence/0", uses[0].Path)
+
+	require.Len(t, g.Uses(ParseNode("service:light.turn_off")), 1)
+	require.Equal(t, Node{Area, "hallway"}, ParseNode("area:hallway"))
+	require.Equal(t, Node{Entity, "nope:thing"}, ParseNode("nope:thing"))
+}
+
+func TestMissing(t *testing.T) {
+	g := testGraph(t)
+	exists := map[string]bool{
+		"binary_sensor.motion": true, "input_datetime.wake": true, "sun.sun": true, "sensor.lux": true,
+		"input_number.lux_threshold": true, "light.kitchen": true, "script.wind_down": true, "scene.evening": true,
+		"light.bedroom": true,
+	}
+	missing := g.Missing(func(entityId string) bool { return exists[entityId] })
+	require.Len(t, missing, 1)
+	require.Equal(t, Edge{Node{Scene, "scene.evening"}, Node{Entity, "light.lamp"}, "entities/light.lamp"},
+		missing[0])
+}
+
+func TestExport(t *testing.T) {
+	g := New()
+	g.AddScene("scene.evening", "Evening", "light.kitchen", "light.kitchen")
+
+	var dot bytes.Buffer
+	require.NoError(t, g.WriteDOT(&dot))
+	require.Equal(t, `digraph ghastly {
+  rankdir=LR;
+  "entity:light.kitchen" [label="light.kitchen", shape=ellipse];
+  "scene:scene.evening" [label="Evening", shape=box];
+  "scene:scene.evening" -> "entity:light.kitchen";
+}
+`, dot.String())
+
+	g = testGraph(t)
+	var mermaid bytes.Buffer
+	require.NoError(t, g.WriteMermaid(&mermaid))
+	require.Contains(t, mermaid.String(), `["automation: Motion #quot;lights#quot;"]`)
+	require.Contains(t, mermaid.String(), `{{"service: light.turn_on"}}`)
+}
@@ -0,0 +1,42 @@
+// Package refs holds the helpers graph and lint share for finding the entities an automation or script refers to.
+package refs
+
+import "strings"
+
+// Dynamic returns true if the value is a template or a blueprint input, and so cannot be known ahead of time.
+func Dynamic(s string) bool {
+	return strings.Contains(s, "{{") || strings.Contains(s, "{%") || strings.HasPrefix(s, "!")
+}
+
+// LooksLikeEntityId returns true if s has the form of an entity ID, e.g. `light.kitchen`. Device automations may refer
+// to entities by registry ID instead, which does not.
+func LooksLikeEntityId(s string) bool {
+	parts := strings.Split(s, ".")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// StringValues returns v if it is a string, or the strings in it if it is a list; e.g., the entity IDs in a service
+// call's `entity_id` target.
+func StringValues(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var ret []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				ret = append(ret, s)
+			}
+		}
+		return ret
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package refs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefs(t *testing.T) {
+	require.True(t, LooksLikeEntityId("light.kitchen_2"))
+	require.False(t, LooksLikeEntityId("light"))
+	require.False(t, LooksLikeEntityId("Light.Kitchen"))
+	require.False(t, LooksLikeEntityId("0123456789abcdef0123456789abcdef"))
+
+	require.True(t, Dynamic("{{ trigger.entity_id }}"))
+	require.True(t, Dynamic("!input light"))
+	require.False(t, Dynamic("light.kitchen"))
+
+	require.Equal(t, []string{"light.kitchen"}, StringValues("light.kitchen"))
+	require.Equal(t, []string{"light.a", "light.b"}, StringValues([]interface{}{"light.a", 1.0, "light.b"}))
+	require.Nil(t, StringValues(nil))
+}
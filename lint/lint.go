@@ -0,0 +1,447 @@
+// Package lint checks automations for mistakes that homeassistant would only reveal at runtime, or not at all:
+// references to entities and services that don't exist, service data that the service doesn't accept, branches that can
+// never be reached, and so on.
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/asymmetricia/ghastly/internal/refs"
+)
+
+type Severity string
+
+const (
+	Error   Severity = "error"
+	Warning Severity = "warning"
+	Info    Severity = "info"
+)
+
+// Rule is a single kind of problem the linter reports.
+type Rule struct {
+	Id          string
+	Severity    Severity
+	Description string
+}
+
+var (
+	UnknownEntity = &Rule{"unknown-entity", Error,
+		"references an entity ID that does not exist"}
+	UnknownService = &Rule{"unknown-service", Error,
+		"calls a service that does not exist"}
+	UnknownServiceField = &Rule{"unknown-service-field", Warning,
+		"passes service data that the service does not declare"}
+	NumericStateNoBounds = &Rule{"numeric-state-no-bounds", Error,
+		"numeric_state trigger or condition has neither `above` nor `below`"}
+	UnreachableBranch = &Rule{"unreachable-branch", Warning,
+		"`choose` option or default can never run, because an earlier option always matches or has the same conditions"}
+	InvalidTime = &Rule{"invalid-time", Error,
+		"time is not HH:MM or HH:MM:SS, or weekday is not one of mon, tue, wed, thu, fri, sat, sun"}
+	DeprecatedSyntax = &Rule{"deprecated-syntax", Warning,
+		"uses syntax that homeassistant has deprecated in favor of a newer form"}
+	UnreadableAutomation = &Rule{"unreadable-automation", Error,
+		"automation could not be retrieved or decoded, so it was not checked"}
+)
+
+// Rules lists every rule the linter checks.
+var Rules = []*Rule{
+	UnknownEntity,
+	UnknownService,
+	UnknownServiceField,
+	NumericStateNoBounds,
+	UnreachableBranch,
+	InvalidTime,
+	DeprecatedSyntax,
+	UnreadableAutomation,
+}
+
+// Finding is a single problem found in an automation. Path locates the problem within the automation the same way
+// homeassistant's traces do, e.g. `action/1/choose/0/sequence/2`.
+type Finding struct {
+	Rule       string   `json:"rule"`
+	Severity   Severity `json:"severity"`
+	Automation string   `json:"automation"`
+	File       string   `json:"file,omitempty"`
+	Path       string   `json:"path"`
+	Message    string   `json:"message"`
+}
+
+// HasErrors returns true if any of the findings has Error severity.
+func HasErrors(findings []Finding) bool {
+	for _, f := range findings {
+		if f.Severity == Error {
+			return true
+		}
+	}
+	return false
+}
+
+// Unreadable returns the finding for an automation that could not be checked at all, because it could not be
+// retrieved or decoded.
+func Unreadable(automation string, err error) Finding {
+	return Finding{
+		Rule:       UnreadableAutomation.Id,
+		Severity:   UnreadableAutomation.Severity,
+		Automation: automation,
+		Message:    err.Error(),
+	}
+}
+
+// Environment describes the homeassistant instance the automations will run on.
+type Environment struct {
+	// Entities is the set of entity IDs that exist. If nil, entity references are not checked.
+	Entities map[string]bool
+	// Services maps each `domain.service` that exists to its description. If nil, services are not checked.
+	Services map[string]*api.Service
+}
+
+// AddEntities marks the given entity IDs as existing, enabling entity checks.
+func (e *Environment) AddEntities(entityIds ...string) {
+	if e.Entities == nil {
+		e.Entities = map[string]bool{}
+	}
+	for _, id := range entityIds {
+		e.Entities[id] = true
+	}
+}
+
+// AddServices marks the given services as existing, enabling service checks.
+func (e *Environment) AddServices(services ...api.Service) {
+	if e.Services == nil {
+		e.Services = map[string]*api.Service{}
+	}
+	for i := range services {
+		e.Services[services[i].Domain+"."+services[i].Name] = &services[i]
+	}
+}
+
+// Lint checks the automation, returning its findings in the order they appear. env may be nil, in which case no
+// references are checked.
+func Lint(automation *api.Automation, env *Environment) []Finding {
+	if env == nil {
+		env = &Environment{}
+	}
+
+	name := string(automation.Id)
+	if name == "" {
+		name = automation.Alias
+	}
+	l := &linter{env: env, automation: name}
+
+	if !automation.PluralKeys && (len(automation.Trigger) > 0 || len(automation.Action) > 0) {
+		l.report(DeprecatedSyntax, "", "`trigger`, `condition`, and `action` have been renamed to `triggers`, "+
+			"`conditions`, and `actions`")
+	}
+
+	for i, trigger := range automation.Trigger {
+		l.trigger(fmt.Sprintf("trigger/%d", i), trigger)
+	}
+	l.conditions("condition", automation.Condition)
+	l.actions("action", automation.Action)
+
+	return l.findings
+}
+
+type linter struct {
+	env        *Environment
+	automation string
+	findings   []Finding
+}
+
+func (l *linter) report(rule *Rule, path string, format string, args ...interface{}) {
+	l.findings = append(l.findings, Finding{
+		Rule:       rule.Id,
+		Severity:   rule.Severity,
+		Automation: l.automation,
+		Path:       path,
+		Message:    fmt.Sprintf(format, args...),
+	})
+}
+
+func (l *linter) trigger(path string, trigger api.AutomationTrigger) {
+	if trigger.PlatformKey != "trigger" {
+		l.report(DeprecatedSyntax, path, "`platform: %s` has been renamed to `trigger: %s`",
+			trigger.Platform(), trigger.Platform())
+	}
+
+	switch t := trigger.Trigger.(type) {
+	case *api.StateTrigger:
+		l.entities(path, t.EntityId.Values...)
+	case *api.NumericStateTrigger:
+		l.entities(path, t.EntityId.Values...)
+		l.numericBounds(path, t.Above, t.Below)
+	case *api.ZoneTrigger:
+		l.entities(path, t.EntityId.Values...)
+		l.entities(path, t.Zone)
+	case *api.TimeTrigger:
+		for _, at := range t.At.Values {
+			l.timeOrEntity(path, at)
+		}
+	case *api.CalendarTrigger:
+		l.entities(path, t.EntityId)
+	case *api.DeviceTrigger:
+		l.entities(path, t.EntityId)
+	}
+}
+
+func (l *linter) conditions(path string, conditions api.ConditionList) {
+	for i, condition := range conditions {
+		l.condition(fmt.Sprintf("%s/%d", path, i), condition.Condition)
+	}
+}
+
+func (l *linter) condition(path string, condition api.Condition) {
+	switch c := condition.(type) {
+	case *api.AndCondition:
+		l.conditions(path+"/conditions", c.Conditions)
+	case *api.OrCondition:
+		l.conditions(path+"/conditions", c.Conditions)
+	case *api.NotCondition:
+		l.conditions(path+"/conditions", c.Conditions)
+	case *api.StateCondition:
+		l.entities(path, c.EntityId.Values...)
+	case *api.NumericStateCondition:
+		l.entities(path, c.EntityId.Values...)
+		l.numericBounds(path, c.Above, c.Below)
+	case *api.ZoneCondition:
+		l.entities(path, c.EntityId.Values...)
+		l.entities(path, c.Zone.Values...)
+	case *api.TimeCondition:
+		if c.After != "" {
+			l.timeOrEntity(path, c.After)
+		}
+		if c.Before != "" {
+			l.timeOrEntity(path, c.Before)
+		}
+		for _, day := range c.Weekday.Values {
+			if !weekdays[day] && !refs.Dynamic(day) {
+				l.report(InvalidTime, path, "weekday %q is not one of mon, tue, wed, thu, fri, sat, sun", day)
+			}
+		}
+	case *api.DeviceCondition:
+		l.entities(path, c.EntityId)
+	}
+}
+
+var weekdays = map[string]bool{
+	"mon": true, "tue": true, "wed": true, "thu": true, "fri": true, "sat": true, "sun": true,
+}
+
+func (l *linter) actions(path string, actions api.ActionSequence) {
+	for i, action := range actions {
+		l.action(fmt.Sprintf("%s/%d", path, i), action.Action)
+	}
+}
+
+func (l *linter) action(path string, action api.Action) {
+	switch a := action.(type) {
+	case *api.ServiceAction:
+		l.service(path, a)
+	case *api.EventAction:
+		if a.EventDataTemplate != nil {
+			l.report(DeprecatedSyntax, path, "`event_data_template` is deprecated; templates are accepted in "+
+				"`event_data`")
+		}
+	case *api.SceneAction:
+		l.entities(path, a.Scene)
+	case *api.DeviceAction:
+		l.entities(path, a.EntityId)
+	case *api.ConditionAction:
+		l.condition(path, a.Condition)
+	case *api.ChooseAction:
+		l.choose(path, a)
+	case *api.IfAction:
+		l.conditions(path+"/if", a.If)
+		l.actions(path+"/then", a.Then)
+		l.actions(path+"/else", a.Else)
+	case *api.RepeatAction:
+		l.conditions(path+"/repeat/while", a.Repeat.While)
+		l.conditions(path+"/repeat/until", a.Repeat.Until)
+		l.actions(path+"/repeat/sequence", a.Repeat.Sequence)
+	case *api.ParallelAction:
+		l.actions(path+"/parallel", a.Parallel)
+	case *api.SequenceAction:
+		l.actions(path+"/sequence", a.Sequence)
+	case *api.WaitForTriggerAction:
+		for i, trigger := range a.WaitForTrigger {
+			l.trigger(fmt.Sprintf("%s/wait_for_trigger/%d", path, i), trigger)
+		}
+	}
+}
+
+func (l *linter) service(path string, action *api.ServiceAction) {
+	if action.Service != "" {
+		l.report(DeprecatedSyntax, path, "`service: %s` has been renamed to `action: %s`", action.Service,
+			action.Service)
+	}
+	if action.ServiceTemplate != "" {
+		l.report(DeprecatedSyntax, path, "`service_template` is deprecated; templates are accepted in `action`")
+	}
+	if action.DataTemplate != nil {
+		l.report(DeprecatedSyntax, path, "`data_template` is deprecated; templates are accepted in `data`")
+	}
+
+	l.entities(path, action.EntityId.Values...)
+	l.entities(path, refs.StringValues(action.Target["entity_id"])...)
+
+	name := action.ServiceName()
+	if l.env.Services == nil || refs.Dynamic(name) {
+		return
+	}
+	service, ok := l.env.Services[name]
+	if !ok {
+		l.report(UnknownService, path, "service %q does not exist", name)
+		return
+	}
+
+	if len(service.Fields) == 0 {
+		// some services, like those of scripts without declared fields, accept any data
+		return
+	}
+	for _, data := range []map[string]interface{}{action.Data, action.DataTemplate} {
+		var fields []string
+		for field := range data {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			if _, ok := service.Fields[field]; !ok && !targetFields[field] {
+				l.report(UnknownServiceField, path, "service %q does not have field %q", name, field)
+			}
+		}
+	}
+}
+
+// targetFields may be given in service data as well as in the target.
+var targetFields = map[string]bool{
+	"entity_id": true,
+	"device_id": true,
+	"area_id":   true,
+	"floor_id":  true,
+	"label_id":  true,
+}
+
+func (l *linter) choose(path string, action *api.ChooseAction) {
+	var seen []string
+	alwaysPath := ""
+	for i, option := range action.Choose {
+		optionPath := fmt.Sprintf("%s/choose/%d", path, i)
+		l.conditions(optionPath+"/conditions", option.Conditions)
+		l.actions(optionPath+"/sequence", option.Sequence)
+
+		if alwaysPath != "" {
+			l.report(UnreachableBranch, optionPath, "option is never reached, because option %s always matches",
+				alwaysPath)
+			continue
+		}
+
+		key := conditionsKey(option.Conditions)
+		for j, earlier := range seen {
+			if earlier == key {
+				l.report(UnreachableBranch, optionPath, "option is never reached, because option %s/choose/%d "+
+					"has the same conditions", path, j)
+			}
+		}
+		seen = append(seen, key)
+
+		if alwaysTrue(option.Conditions) {
+			alwaysPath = optionPath
+		}
+	}
+
+	l.actions(path+"/default", action.Default)
+	if alwaysPath != "" && len(action.Default) > 0 {
+		l.report(UnreachableBranch, path+"/default", "default is never reached, because option %s always matches",
+			alwaysPath)
+	}
+}
+
+// conditionsKey returns a string that is the same for two condition lists only if they are equivalent.
+func conditionsKey(conditions api.ConditionList) string {
+	var keys []string
+	for _, c := range conditions {
+		jb, _ := c.MarshalJSON()
+		keys = append(keys, string(jb))
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, "\n")
+}
+
+// alwaysTrue returns true if the conditions always pass: there are none (or none enabled), or each is a template that
+// is literally true.
+func alwaysTrue(conditions api.ConditionList) bool {
+	for _, c := range conditions {
+		if c.Enabled != nil && !*c.Enabled {
+			continue
+		}
+		template, ok := c.Condition.(*api.TemplateCondition)
+		if !ok || strings.ToLower(strings.Join(strings.Fields(template.ValueTemplate), "")) != "{{true}}" {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *linter) numericBounds(path string, above, below api.StringOrFloat) {
+	if !above.IsSet() && !below.IsSet() {
+		l.report(NumericStateNoBounds, path, "numeric_state needs at least one of `above` and `below`")
+		return
+	}
+	for _, bound := range []api.StringOrFloat{above, below} {
+		if _, ok := bound.Float(); !ok && bound.IsSet() {
+			l.entities(path, bound.String())
+		}
+	}
+}
+
+// timeOrEntity checks a value that may be either a time of day or an entity whose state is one.
+func (l *linter) timeOrEntity(path string, value string) {
+	if refs.Dynamic(value) {
+		return
+	}
+	if refs.LooksLikeEntityId(value) {
+		l.entities(path, value)
+		return
+	}
+	if !validTime(value) {
+		l.report(InvalidTime, path, "%q is not a valid time; expected HH:MM or HH:MM:SS", value)
+	}
+}
+
+func validTime(value string) bool {
+	parts := strings.Split(value, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return false
+	}
+	for i, part := range parts {
+		if len(part) == 0 || len(part) > 2 {
+			return false
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || (i == 0 && n > 23) || (i > 0 && n > 59) {
+			return false
+		}
+	}
+	return true
+}
+
+// entities checks that each of the given entity IDs exists. Empty values, templates, and the special values `all` and
+// `none` are skipped.
+func (l *linter) entities(path string, entityIds ...string) {
+	if l.env.Entities == nil {
+		return
+	}
+	for _, id := range entityIds {
+		if id == "" || id == "all" || id == "none" || refs.Dynamic(id) || !refs.LooksLikeEntityId(id) {
+			// device automations may refer to entities by registry ID rather than entity ID
+			continue
+		}
+		if !l.env.Entities[id] {
+			l.report(UnknownEntity, path, "entity %q does not exist", id)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/stretchr/testify/require"
+)
+
+func environment() *Environment {
+	env := &Environment{}
+	env.AddEntities("light.kitchen", "binary_sensor.motion", "sensor.temperature", "input_datetime.wake", "zone.home",
+		"person.alex")
+	env.AddServices(api.Service{
+		Domain: "light",
+		Name:   "turn_on",
+		Fields: map[string]*api.ServiceField{"brightness_pct": {}, "transition": {}},
+	})
+	return env
+}
+
+func lintJSON(t *testing.T, input string, env *Environment) []Finding {
+	var automation api.Automation
+	require.NoError(t, json.Unmarshal([]byte(input), &automation))
+	return Lint(&automation, env)
+}
+
+// rules returns the "path rule" of each finding, for brevity in comparisons.
+func rules(findings []Finding) []string {
+	var ret []string
+	for _, f := range findings {
+		ret = append(ret, f.Path+" "+f.Rule)
+	}
+	return ret
+}
+
+func TestLintClean(t *testing.T) {
+	findings := lintJSON(t, `{
+		"id": "clean",
+		"triggers": [
+			{"trigger": "state", "entity_id": "binary_sensor.motion", "to": "on"},
+			{"trigger": "numeric_state", "entity_id": "sensor.temperature", "above": 25},
+			{"trigger": "time", "at": ["07:30", "input_datetime.wake", "{{ states('sensor.x') }}"]}
+		],
+		"conditions": [
+			{"condition": "time", "after": "06:00:00", "before": "23:00", "weekday": ["mon", "fri"]},
+			{"condition": "zone", "entity_id": "person.alex", "zone": "zone.home"}
+		],
+		"actions": [
+			{"action": "light.turn_on", "target": {"entity_id": ["light.kitchen"]},
+				"data": {"brightness_pct": 50, "entity_id": "light.kitchen"}},
+			{"choose": [
+				{"conditions": [{"condition": "state", "entity_id": "light.kitchen", "state": "on"}], "sequence": []},
+				{"conditions": [{"condition": "state", "entity_id": "light.kitchen", "state": "off"}], "sequence": []}
+			], "default": [{"delay": 1}]}
+		]
+	}`, environment())
+	require.Empty(t, findings)
+	require.False(t, HasErrors(findings))
+}
+
+func TestLintFindings(t *testing.T) {
+	findings := lintJSON(t, `{
+		"id": "messy",
+		"trigger": [
+			{"platform": "numeric_state", "entity_id": "sensor.temperature"},
+			{"trigger": "state", "entity_id": ["binary_sensor.motion", "binary_sensor.typo"]},
+			{"trigger": "time", "at": "25:00"}
+		],
+		"condition": [
+			{"condition": "or", "conditions": [{"condition": "time", "weekday": "funday"}]}
+		],
+		"action": [
+			{"service": "light.turn_on", "entity_id": "light.kitchen", "data": {"brightnes": 50}},
+			{"action": "light.explode"},
+			{"choose": [
+				{"conditions": [{"condition": "state", "entity_id": "light.kitchen", "state": "on"}], "sequence": []},
+				{"conditions": [{"condition": "state", "entity_id": "light.kitchen", "state": "on"}], "sequence": []},
+				{"conditions": "{{ true }}", "sequence": [{"scene": "scene.missing"}]},
+				{"conditions": [], "sequence": []}
+			], "default": [{"delay": 1}]}
+		]
+	}`, environment())
+
+	require.Equal(t, []string{
+		" deprecated-syntax",
+		"trigger/0 deprecated-syntax",
+		"trigger/0 numeric-state-no-bounds",
+		"trigger/1 unknown-entity",
+		"trigger/2 invalid-time",
+		"condition/0/conditions/0 invalid-time",
+		"action/0 deprecated-syntax",
+		"action/0 unknown-service-field",
+		"action/1 unknown-service",
+		"action/2/choose/1 unreachable-branch",
+		"action/2/choose/2/sequence/0 unknown-entity",
+		"action/2/choose/3 unreachable-branch",
+		"action/2/default unreachable-branch",
+	}, rules(findings))
+	require.True(t, HasErrors(findings))
+	require.Equal(t, "messy", findings[0].Automation)
+}
+
+func TestLintOffline(t *testing.T) {
+	findings := lintJSON(t, `{
+		"triggers": [{"trigger": "state", "entity_id": "sensor.anything"}],
+		"actions": [{"action": "anything.at_all", "data": {"whatever": 1}}]
+	}`, nil)
+	require.Empty(t, findings)
+}
+
+func TestUnreadable(t *testing.T) {
+	finding := Unreadable("123", errors.New("decoding automation: unexpected field"))
+	require.Equal(t, Finding{
+		Rule:       "unreadable-automation",
+		Severity:   Error,
+		Automation: "123",
+		Message:    "decoding automation: unexpected field",
+	}, finding)
+	require.True(t, HasErrors([]Finding{finding}))
+}
+
+func TestWriteSARIF(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteSARIF(buf, []Finding{{
+		Rule:       UnknownEntity.Id,
+		Severity:   Error,
+		Automation: "123",
+		File:       "automations.yaml",
+		Path:       "trigger/0",
+		Message:    `entity "light.x" does not exist`,
+	}}))
+
+	var log map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+	require.Equal(t, "2.1.0", log["version"])
+	run := log["runs"].([]interface{})[0].(map[string]interface{})
+	require.Len(t, run["tool"].(map[string]interface{})["driver"].(map[string]interface{})["rules"], len(Rules))
+	result := run["results"].([]interface{})[0].(map[string]interface{})
+	require.Equal(t, "unknown-entity", result["ruleId"])
+	require.Equal(t, "error", result["level"])
+	location := result["locations"].([]interface{})[0].(map[string]interface{})
+	require.Equal(t, "123/trigger/0",
+		location["logicalLocations"].([]interface{})[0].(map[string]interface{})["fullyQualifiedName"])
+}
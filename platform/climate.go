@@ -0,0 +1,163 @@
+package platform
+
+import (
+	"github.com/asymmetricia/ghastly/api"
+)
+
+// ClimateFeature is a bitmask of the optional features a climate entity supports.
+type ClimateFeature int
+
+const (
+	ClimateSupportTargetTemperature      ClimateFeature = 1
+	ClimateSupportTargetTemperatureRange ClimateFeature = 2
+	ClimateSupportTargetHumidity         ClimateFeature = 4
+	ClimateSupportFanMode                ClimateFeature = 8
+	ClimateSupportPresetMode             ClimateFeature = 16
+	ClimateSupportSwingMode              ClimateFeature = 32
+	ClimateSupportAuxHeat                ClimateFeature = 64
+	ClimateSupportTurnOff                ClimateFeature = 128
+	ClimateSupportTurnOn                 ClimateFeature = 256
+)
+
+// Has returns true if every feature in flag is supported.
+func (f ClimateFeature) Has(flag ClimateFeature) bool {
+	return f&flag == flag
+}
+
+func (f ClimateFeature) String() string {
+	return featureString(int(f), map[int]string{
+		1:   "target_temperature",
+		2:   "target_temperature_range",
+		4:   "target_humidity",
+		8:   "fan_mode",
+		16:  "preset_mode",
+		32:  "swing_mode",
+		64:  "aux_heat",
+		128: "turn_off",
+		256: "turn_on",
+	})
+}
+
+type Climate struct {
+	Entity
+}
+
+func NewClimate(c *api.Client, entityId string) *Climate {
+	return &Climate{Entity{c, entityId}}
+}
+
+func (c *Climate) TurnOn() error {
+	return c.Call("turn_on", nil)
+}
+
+func (c *Climate) TurnOff() error {
+	return c.Call("turn_off", nil)
+}
+
+// SetTemperature sets the temperature the entity aims for, for entities that support
+// ClimateSupportTargetTemperature.
+func (c *Climate) SetTemperature(temperature float64) error {
+	return c.Call("set_temperature", map[string]interface{}{"temperature": temperature})
+}
+
+// SetTemperatureRange sets the range of temperatures the entity keeps to, for entities that support
+// ClimateSupportTargetTemperatureRange.
+func (c *Climate) SetTemperatureRange(low, high float64) error {
+	return c.Call("set_temperature", map[string]interface{}{"target_temp_low": low, "target_temp_high": high})
+}
+
+// SetHvacMode sets the entity's mode, which must be one of ClimateState.HvacModes.
+func (c *Climate) SetHvacMode(mode string) error {
+	return c.Call("set_hvac_mode", map[string]interface{}{"hvac_mode": mode})
+}
+
+// SetFanMode sets the entity's fan mode, which must be one of ClimateState.FanModes.
+func (c *Climate) SetFanMode(mode string) error {
+	return c.Call("set_fan_mode", map[string]interface{}{"fan_mode": mode})
+}
+
+// SetPresetMode sets the entity's preset, which must be one of ClimateState.PresetModes.
+func (c *Climate) SetPresetMode(mode string) error {
+	return c.Call("set_preset_mode", map[string]interface{}{"preset_mode": mode})
+}
+
+// SetHumidity sets the humidity the entity aims for, as a percentage.
+func (c *Climate) SetHumidity(humidity float64) error {
+	return c.Call("set_humidity", map[string]interface{}{"humidity": humidity})
+}
+
+func (c *Climate) State() (*ClimateState, error) {
+	s, err := c.get()
+	if err != nil {
+		return nil, err
+	}
+	return &ClimateState{*s}, nil
+}
+
+// ClimateState is the state of a climate entity, e.g. a thermostat. Temperatures are in the unit the entity is
+// configured for.
+type ClimateState struct {
+	api.State
+}
+
+// HvacMode returns the entity's mode, e.g. `heat` or `off`.
+func (s *ClimateState) HvacMode() string {
+	return s.State.State
+}
+
+func (s *ClimateState) HvacModes() []string {
+	return attrStrings(&s.State, "hvac_modes")
+}
+
+// HvacAction returns what the entity is doing at the moment, e.g. `heating` or `idle`, or the empty string if the
+// entity doesn't say.
+func (s *ClimateState) HvacAction() string {
+	return attrString(&s.State, "hvac_action")
+}
+
+func (s *ClimateState) CurrentTemperature() (float64, bool) {
+	return attrFloat(&s.State, "current_temperature")
+}
+
+// Temperature returns the temperature the entity aims for, and false if it has no single target.
+func (s *ClimateState) Temperature() (float64, bool) {
+	return attrFloat(&s.State, "temperature")
+}
+
+// TemperatureRange returns the range of temperatures the entity keeps to, and false if it has no target range.
+func (s *ClimateState) TemperatureRange() (low, high float64, ok bool) {
+	low, lowOk := attrFloat(&s.State, "target_temp_low")
+	high, highOk := attrFloat(&s.State, "target_temp_high")
+	return low, high, lowOk && highOk
+}
+
+// TemperatureLimits returns the lowest and highest temperatures the entity may be set to.
+func (s *ClimateState) TemperatureLimits() (min, max float64) {
+	min, _ = attrFloat(&s.State, "min_temp")
+	max, _ = attrFloat(&s.State, "max_temp")
+	return min, max
+}
+
+func (s *ClimateState) CurrentHumidity() (float64, bool) {
+	return attrFloat(&s.State, "current_humidity")
+}
+
+func (s *ClimateState) FanMode() string {
+	return attrString(&s.State, "fan_mode")
+}
+
+func (s *ClimateState) FanModes() []string {
+	return attrStrings(&s.State, "fan_modes")
+}
+
+func (s *ClimateState) PresetMode() string {
+	return attrString(&s.State, "preset_mode")
+}
+
+func (s *ClimateState) PresetModes() []string {
+	return attrStrings(&s.State, "preset_modes")
+}
+
+func (s *ClimateState) SupportedFeatures() ClimateFeature {
+	return ClimateFeature(supportedFeatures(&s.State))
+}
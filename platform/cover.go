@@ -0,0 +1,137 @@
+package platform
+
+import (
+	"fmt"
+
+	"github.com/asymmetricia/ghastly/api"
+)
+
+// CoverFeature is a bitmask of the optional features a cover supports.
+type CoverFeature int
+
+const (
+	CoverSupportOpen            CoverFeature = 1
+	CoverSupportClose           CoverFeature = 2
+	CoverSupportSetPosition     CoverFeature = 4
+	CoverSupportStop            CoverFeature = 8
+	CoverSupportOpenTilt        CoverFeature = 16
+	CoverSupportCloseTilt       CoverFeature = 32
+	CoverSupportStopTilt        CoverFeature = 64
+	CoverSupportSetTiltPosition CoverFeature = 128
+)
+
+// Has returns true if every feature in flag is supported.
+func (f CoverFeature) Has(flag CoverFeature) bool {
+	return f&flag == flag
+}
+
+func (f CoverFeature) String() string {
+	return featureString(int(f), map[int]string{
+		1:   "open",
+		2:   "close",
+		4:   "set_position",
+		8:   "stop",
+		16:  "open_tilt",
+		32:  "close_tilt",
+		64:  "stop_tilt",
+		128: "set_tilt_position",
+	})
+}
+
+// Cover is a cover, e.g. a garage door or window blind. Positions are percentages, where 0 is closed and 100 is open.
+type Cover struct {
+	Entity
+}
+
+func NewCover(c *api.Client, entityId string) *Cover {
+	return &Cover{Entity{c, entityId}}
+}
+
+func (c *Cover) Open() error {
+	return c.Call("open_cover", nil)
+}
+
+func (c *Cover) Close() error {
+	return c.Call("close_cover", nil)
+}
+
+func (c *Cover) Stop() error {
+	return c.Call("stop_cover", nil)
+}
+
+func (c *Cover) Toggle() error {
+	return c.Call("toggle", nil)
+}
+
+// SetPosition moves the cover to the given position, for covers that support CoverSupportSetPosition.
+func (c *Cover) SetPosition(position int) error {
+	if position < 0 || position > 100 {
+		return fmt.Errorf("position %d is not between 0 and 100", position)
+	}
+	return c.Call("set_cover_position", map[string]interface{}{"position": position})
+}
+
+func (c *Cover) OpenTilt() error {
+	return c.Call("open_cover_tilt", nil)
+}
+
+func (c *Cover) CloseTilt() error {
+	return c.Call("close_cover_tilt", nil)
+}
+
+func (c *Cover) StopTilt() error {
+	return c.Call("stop_cover_tilt", nil)
+}
+
+// SetTiltPosition tilts the cover to the given position, for covers that support CoverSupportSetTiltPosition.
+func (c *Cover) SetTiltPosition(position int) error {
+	if position < 0 || position > 100 {
+		return fmt.Errorf("tilt position %d is not between 0 and 100", position)
+	}
+	return c.Call("set_cover_tilt_position", map[string]interface{}{"tilt_position": position})
+}
+
+func (c *Cover) State() (*CoverState, error) {
+	s, err := c.get()
+	if err != nil {
+		return nil, err
+	}
+	return &CoverState{*s}, nil
+}
+
+// CoverState is the state of a cover.
+type CoverState struct {
+	api.State
+}
+
+func (s *CoverState) IsOpen() bool {
+	return s.State.State == "open"
+}
+
+func (s *CoverState) IsClosed() bool {
+	return s.State.State == "closed"
+}
+
+// IsMoving returns true if the cover is opening or closing.
+func (s *CoverState) IsMoving() bool {
+	return s.State.State == "opening" || s.State.State == "closing"
+}
+
+// CurrentPosition returns the cover's position, and false if the cover does not report one.
+func (s *CoverState) CurrentPosition() (int, bool) {
+	return attrInt(&s.State, "current_position")
+}
+
+// CurrentTiltPosition returns the cover's tilt, and false if the cover does not report one.
+func (s *CoverState) CurrentTiltPosition() (int, bool) {
+	return attrInt(&s.State, "current_tilt_position")
+}
+
+// DeviceClass returns the kind of cover, e.g. `garage` or `blind`, or the empty string if it has none.
+func (s *CoverState) DeviceClass() string {
+	return attrString(&s.State, "device_class")
+}
+
+func (s *CoverState) SupportedFeatures() CoverFeature {
+	return CoverFeature(supportedFeatures(&s.State))
+}
@@ -0,0 +1,194 @@
+package platform
+
+import (
+	"math"
+	"time"
+
+	"github.com/asymmetricia/ghastly/api"
+)
+
+// LightFeature is a bitmask of the optional features a light supports. Brightness and color support are described by
+// the light's color modes instead; see LightState.SupportedColorModes.
+type LightFeature int
+
+const (
+	LightSupportEffect     LightFeature = 4
+	LightSupportFlash      LightFeature = 8
+	LightSupportTransition LightFeature = 32
+)
+
+// Has returns true if every feature in flag is supported.
+func (f LightFeature) Has(flag LightFeature) bool {
+	return f&flag == flag
+}
+
+func (f LightFeature) String() string {
+	return featureString(int(f), map[int]string{4: "effect", 8: "flash", 32: "transition"})
+}
+
+type Light struct {
+	Entity
+}
+
+func NewLight(c *api.Client, entityId string) *Light {
+	return &Light{Entity{c, entityId}}
+}
+
+// LightOption sets a field of the data sent to `light.turn_on`.
+type LightOption func(data map[string]interface{})
+
+// WithBrightness sets the light's brightness as a fraction of its maximum, from 0 to 1.
+func WithBrightness(fraction float64) LightOption {
+	return func(data map[string]interface{}) {
+		data["brightness"] = math.Round(math.Max(0, math.Min(1, fraction)) * 255)
+	}
+}
+
+// WithColorTemp sets the light's color temperature, in kelvin.
+func WithColorTemp(kelvin int) LightOption {
+	return func(data map[string]interface{}) {
+		data["color_temp_kelvin"] = kelvin
+	}
+}
+
+// WithRgb sets the light's color.
+func WithRgb(red, green, blue uint8) LightOption {
+	return func(data map[string]interface{}) {
+		data["rgb_color"] = []int{int(red), int(green), int(blue)}
+	}
+}
+
+// WithTransition sets how long the light takes to change, for lights that support LightSupportTransition.
+func WithTransition(d time.Duration) LightOption {
+	return func(data map[string]interface{}) {
+		data["transition"] = d.Seconds()
+	}
+}
+
+// WithEffect sets the light's effect, which must be one of LightState.EffectList.
+func WithEffect(effect string) LightOption {
+	return func(data map[string]interface{}) {
+		data["effect"] = effect
+	}
+}
+
+// WithFlash makes the light flash briefly, or for longer if long is true, for lights that support LightSupportFlash.
+func WithFlash(long bool) LightOption {
+	return func(data map[string]interface{}) {
+		data["flash"] = "short"
+		if long {
+			data["flash"] = "long"
+		}
+	}
+}
+
+func lightData(opts []LightOption) map[string]interface{} {
+	if len(opts) == 0 {
+		return nil
+	}
+	data := map[string]interface{}{}
+	for _, opt := range opts {
+		opt(data)
+	}
+	return data
+}
+
+// TurnOn turns the light on, or changes it if it is already on.
+func (l *Light) TurnOn(opts ...LightOption) error {
+	return l.Call("turn_on", lightData(opts))
+}
+
+// TurnOff turns the light off. Of the options, only WithTransition and WithFlash are meaningful.
+func (l *Light) TurnOff(opts ...LightOption) error {
+	return l.Call("turn_off", lightData(opts))
+}
+
+// Toggle turns the light off if it is on, and on with the given options if it is off.
+func (l *Light) Toggle(opts ...LightOption) error {
+	return l.Call("toggle", lightData(opts))
+}
+
+func (l *Light) State() (*LightState, error) {
+	s, err := l.get()
+	if err != nil {
+		return nil, err
+	}
+	return &LightState{*s}, nil
+}
+
+// LightState is the state of a light.
+type LightState struct {
+	api.State
+}
+
+func (s *LightState) IsOn() bool {
+	return s.State.State == "on"
+}
+
+// Brightness returns the light's brightness as a fraction of its maximum, and false if the light is off or does not
+// support brightness.
+func (s *LightState) Brightness() (float64, bool) {
+	b, ok := attrFloat(&s.State, "brightness")
+	return b / 255, ok
+}
+
+// ColorMode returns the mode the light is in, e.g. `color_temp` or `rgb`, or the empty string if it is off.
+func (s *LightState) ColorMode() string {
+	return attrString(&s.State, "color_mode")
+}
+
+func (s *LightState) SupportedColorModes() []string {
+	return attrStrings(&s.State, "supported_color_modes")
+}
+
+// SupportsBrightness returns true if any of the light's color modes allows setting its brightness.
+func (s *LightState) SupportsBrightness() bool {
+	for _, mode := range s.SupportedColorModes() {
+		if mode != "onoff" && mode != "unknown" {
+			return true
+		}
+	}
+	return false
+}
+
+// ColorTemp returns the light's color temperature in kelvin, and false if it is off or not in `color_temp` mode.
+func (s *LightState) ColorTemp() (int, bool) {
+	return attrInt(&s.State, "color_temp_kelvin")
+}
+
+// ColorTempRange returns the lowest and highest color temperatures the light supports, in kelvin; both are zero if it
+// does not support color temperatures.
+func (s *LightState) ColorTempRange() (min, max int) {
+	min, _ = attrInt(&s.State, "min_color_temp_kelvin")
+	max, _ = attrInt(&s.State, "max_color_temp_kelvin")
+	return min, max
+}
+
+// Rgb returns the light's color, and false if it is off or has no color.
+func (s *LightState) Rgb() (red, green, blue uint8, ok bool) {
+	rgb, _ := s.Attributes["rgb_color"].([]interface{})
+	if len(rgb) != 3 {
+		return 0, 0, 0, false
+	}
+	var c [3]uint8
+	for i, v := range rgb {
+		f, ok := v.(float64)
+		if !ok {
+			return 0, 0, 0, false
+		}
+		c[i] = uint8(f)
+	}
+	return c[0], c[1], c[2], true
+}
+
+func (s *LightState) Effect() string {
+	return attrString(&s.State, "effect")
+}
+
+func (s *LightState) EffectList() []string {
+	return attrStrings(&s.State, "effect_list")
+}
+
+func (s *LightState) SupportedFeatures() LightFeature {
+	return LightFeature(supportedFeatures(&s.State))
+}
@@ -0,0 +1,204 @@
+package platform
+
+import (
+	"fmt"
+
+	"github.com/asymmetricia/ghastly/api"
+)
+
+// MediaPlayerFeature is a bitmask of the optional features a media player supports.
+type MediaPlayerFeature int
+
+const (
+	MediaPlayerSupportPause           MediaPlayerFeature = 1
+	MediaPlayerSupportSeek            MediaPlayerFeature = 2
+	MediaPlayerSupportVolumeSet       MediaPlayerFeature = 4
+	MediaPlayerSupportVolumeMute      MediaPlayerFeature = 8
+	MediaPlayerSupportPreviousTrack   MediaPlayerFeature = 16
+	MediaPlayerSupportNextTrack       MediaPlayerFeature = 32
+	MediaPlayerSupportTurnOn          MediaPlayerFeature = 128
+	MediaPlayerSupportTurnOff         MediaPlayerFeature = 256
+	MediaPlayerSupportPlayMedia       MediaPlayerFeature = 512
+	MediaPlayerSupportVolumeStep      MediaPlayerFeature = 1024
+	MediaPlayerSupportSelectSource    MediaPlayerFeature = 2048
+	MediaPlayerSupportStop            MediaPlayerFeature = 4096
+	MediaPlayerSupportClearPlaylist   MediaPlayerFeature = 8192
+	MediaPlayerSupportPlay            MediaPlayerFeature = 16384
+	MediaPlayerSupportShuffleSet      MediaPlayerFeature = 32768
+	MediaPlayerSupportSelectSoundMode MediaPlayerFeature = 65536
+	MediaPlayerSupportBrowseMedia     MediaPlayerFeature = 131072
+	MediaPlayerSupportRepeatSet       MediaPlayerFeature = 262144
+	MediaPlayerSupportGrouping        MediaPlayerFeature = 524288
+)
+
+// Has returns true if every feature in flag is supported.
+func (f MediaPlayerFeature) Has(flag MediaPlayerFeature) bool {
+	return f&flag == flag
+}
+
+func (f MediaPlayerFeature) String() string {
+	return featureString(int(f), map[int]string{
+		1:      "pause",
+		2:      "seek",
+		4:      "volume_set",
+		8:      "volume_mute",
+		16:     "previous_track",
+		32:     "next_track",
+		128:    "turn_on",
+		256:    "turn_off",
+		512:    "play_media",
+		1024:   "volume_step",
+		2048:   "select_source",
+		4096:   "stop",
+		8192:   "clear_playlist",
+		16384:  "play",
+		32768:  "shuffle_set",
+		65536:  "select_sound_mode",
+		131072: "browse_media",
+		262144: "repeat_set",
+		524288: "grouping",
+	})
+}
+
+type MediaPlayer struct {
+	Entity
+}
+
+func NewMediaPlayer(c *api.Client, entityId string) *MediaPlayer {
+	return &MediaPlayer{Entity{c, entityId}}
+}
+
+func (m *MediaPlayer) TurnOn() error {
+	return m.Call("turn_on", nil)
+}
+
+func (m *MediaPlayer) TurnOff() error {
+	return m.Call("turn_off", nil)
+}
+
+func (m *MediaPlayer) Play() error {
+	return m.Call("media_play", nil)
+}
+
+func (m *MediaPlayer) Pause() error {
+	return m.Call("media_pause", nil)
+}
+
+func (m *MediaPlayer) PlayPause() error {
+	return m.Call("media_play_pause", nil)
+}
+
+func (m *MediaPlayer) Stop() error {
+	return m.Call("media_stop", nil)
+}
+
+func (m *MediaPlayer) NextTrack() error {
+	return m.Call("media_next_track", nil)
+}
+
+func (m *MediaPlayer) PreviousTrack() error {
+	return m.Call("media_previous_track", nil)
+}
+
+// SetVolume sets the player's volume as a fraction of its maximum, from 0 to 1.
+func (m *MediaPlayer) SetVolume(level float64) error {
+	if level < 0 || level > 1 {
+		return fmt.Errorf("volume %v is not between 0 and 1", level)
+	}
+	return m.Call("volume_set", map[string]interface{}{"volume_level": level})
+}
+
+func (m *MediaPlayer) VolumeUp() error {
+	return m.Call("volume_up", nil)
+}
+
+func (m *MediaPlayer) VolumeDown() error {
+	return m.Call("volume_down", nil)
+}
+
+func (m *MediaPlayer) Mute(muted bool) error {
+	return m.Call("volume_mute", map[string]interface{}{"is_volume_muted": muted})
+}
+
+// SelectSource switches the player to the given source, which must be one of MediaPlayerState.SourceList.
+func (m *MediaPlayer) SelectSource(source string) error {
+	return m.Call("select_source", map[string]interface{}{"source": source})
+}
+
+// PlayMedia plays the given media; what contentId and contentType may be depends on the player, e.g. a URL and
+// `music`.
+func (m *MediaPlayer) PlayMedia(contentId, contentType string) error {
+	return m.Call("play_media", map[string]interface{}{
+		"media_content_id":   contentId,
+		"media_content_type": contentType,
+	})
+}
+
+func (m *MediaPlayer) State() (*MediaPlayerState, error) {
+	s, err := m.get()
+	if err != nil {
+		return nil, err
+	}
+	return &MediaPlayerState{*s}, nil
+}
+
+// MediaPlayerState is the state of a media player. Most attributes are reported only while the player is on.
+type MediaPlayerState struct {
+	api.State
+}
+
+// IsOn returns true unless the player is off, in standby, or unavailable.
+func (s *MediaPlayerState) IsOn() bool {
+	switch s.State.State {
+	case "off", "standby", "unavailable", "unknown":
+		return false
+	}
+	return true
+}
+
+func (s *MediaPlayerState) IsPlaying() bool {
+	return s.State.State == "playing"
+}
+
+// VolumeLevel returns the player's volume as a fraction of its maximum, and false if the player does not report one.
+func (s *MediaPlayerState) VolumeLevel() (float64, bool) {
+	return attrFloat(&s.State, "volume_level")
+}
+
+func (s *MediaPlayerState) IsVolumeMuted() bool {
+	muted, _ := s.Attributes["is_volume_muted"].(bool)
+	return muted
+}
+
+func (s *MediaPlayerState) Source() string {
+	return attrString(&s.State, "source")
+}
+
+func (s *MediaPlayerState) SourceList() []string {
+	return attrStrings(&s.State, "source_list")
+}
+
+func (s *MediaPlayerState) MediaTitle() string {
+	return attrString(&s.State, "media_title")
+}
+
+func (s *MediaPlayerState) MediaArtist() string {
+	return attrString(&s.State, "media_artist")
+}
+
+func (s *MediaPlayerState) MediaContentId() string {
+	return attrString(&s.State, "media_content_id")
+}
+
+func (s *MediaPlayerState) MediaContentType() string {
+	return attrString(&s.State, "media_content_type")
+}
+
+// AppName returns the name of the app playing, for players that run apps.
+func (s *MediaPlayerState) AppName() string {
+	return attrString(&s.State, "app_name")
+}
+
+func (s *MediaPlayerState) SupportedFeatures() MediaPlayerFeature {
+	return MediaPlayerFeature(supportedFeatures(&s.State))
+}
@@ -0,0 +1,98 @@
+// Package platform wraps entities of common homeassistant platforms (light, switch, climate, cover, and media_player)
+// in types whose methods call the platform's services, and whose states have typed readers for the platform's
+// attributes. For example:
+//
+//	kitchen := platform.NewLight(client, "light.kitchen")
+//	err := kitchen.TurnOn(platform.WithBrightness(0.5), platform.WithColorTemp(2700))
+package platform
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/asymmetricia/ghastly/api"
+)
+
+// Entity is an entity of any domain. The platform types embed it.
+type Entity struct {
+	Client   *api.Client
+	EntityId string
+}
+
+// Domain returns the entity's domain; e.g., `light` for `light.kitchen`.
+func (e *Entity) Domain() string {
+	return strings.SplitN(e.EntityId, ".", 2)[0]
+}
+
+// Call calls the given service of the entity's domain, targeting the entity.
+func (e *Entity) Call(service string, data map[string]interface{}) error {
+	_, err := e.Client.CallService(e.Domain(), service, api.CallOptions{
+		Target: &api.Target{EntityId: []string{e.EntityId}},
+		Data:   data,
+	})
+	return err
+}
+
+// get returns the entity's current state.
+func (e *Entity) get() (*api.State, error) {
+	return e.Client.GetState(e.EntityId)
+}
+
+// attrString returns the given attribute of the state, or the empty string if it is not a string.
+func attrString(s *api.State, name string) string {
+	ret, _ := s.Attributes[name].(string)
+	return ret
+}
+
+// attrFloat returns the given attribute of the state, and true if it is a number.
+func attrFloat(s *api.State, name string) (float64, bool) {
+	ret, ok := s.Attributes[name].(float64)
+	return ret, ok
+}
+
+// attrInt returns the given attribute of the state rounded down, and true if it is a number.
+func attrInt(s *api.State, name string) (int, bool) {
+	ret, ok := attrFloat(s, name)
+	return int(ret), ok
+}
+
+// attrStrings returns the given attribute of the state, omitting any members that are not strings.
+func attrStrings(s *api.State, name string) []string {
+	list, _ := s.Attributes[name].([]interface{})
+	var ret []string
+	for _, v := range list {
+		if str, ok := v.(string); ok {
+			ret = append(ret, str)
+		}
+	}
+	return ret
+}
+
+// supportedFeatures returns the state's `supported_features` bitmask.
+func supportedFeatures(s *api.State) int {
+	ret, _ := attrInt(s, "supported_features")
+	return ret
+}
+
+// featureString describes the bits set in features, using names for those it has names for and the bit's value for
+// the rest; e.g., `effect|flash|64`.
+func featureString(features int, names map[int]string) string {
+	var bits []int
+	for bit := 1; bit > 0 && bit <= features; bit <<= 1 {
+		if features&bit != 0 {
+			bits = append(bits, bit)
+		}
+	}
+	sort.Ints(bits)
+
+	var ret []string
+	for _, bit := range bits {
+		if name, ok := names[bit]; ok {
+			ret = append(ret, name)
+		} else {
+			ret = append(ret, fmt.Sprint(bit))
+		}
+	}
+	return strings.Join(ret, "|")
+}
@@ -0,0 +1,120 @@
+package platform
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLight(t *testing.T) {
+	require.Nil(t, lightData(nil))
+	require.Equal(t, map[string]interface{}{
+		"brightness":        float64(128),
+		"color_temp_kelvin": 2700,
+		"transition":        1.5,
+		"flash":             "long",
+	}, lightData([]LightOption{
+		WithBrightness(0.5), WithColorTemp(2700), WithTransition(1500 * time.Millisecond), WithFlash(true),
+	}))
+	require.Equal(t, float64(255), lightData([]LightOption{WithBrightness(2)})["brightness"])
+
+	s := &LightState{api.State{State: "on", Attributes: map[string]interface{}{
+		"brightness":            float64(51),
+		"color_mode":            "rgb",
+		"supported_color_modes": []interface{}{"color_temp", "rgb"},
+		"rgb_color":             []interface{}{float64(255), float64(128), float64(0)},
+		"supported_features":    float64(44),
+	}}}
+	require.True(t, s.IsOn())
+	b, ok := s.Brightness()
+	require.True(t, ok)
+	require.Equal(t, 0.2, b)
+	require.True(t, s.SupportsBrightness())
+	r, g, bl, ok := s.Rgb()
+	require.True(t, ok)
+	require.Equal(t, []uint8{255, 128, 0}, []uint8{r, g, bl})
+	_, ok = s.ColorTemp()
+	require.False(t, ok)
+	require.True(t, s.SupportedFeatures().Has(LightSupportTransition|LightSupportFlash))
+	require.Equal(t, "effect|flash|transition", s.SupportedFeatures().String())
+
+	off := &LightState{api.State{State: "off", Attributes: map[string]interface{}{
+		"supported_color_modes": []interface{}{"onoff"},
+	}}}
+	require.False(t, off.IsOn())
+	require.False(t, off.SupportsBrightness())
+	_, ok = off.Brightness()
+	require.False(t, ok)
+}
+
+func TestClimate(t *testing.T) {
+	s := &ClimateState{api.State{State: "heat_cool", Attributes: map[string]interface{}{
+		"hvac_modes":          []interface{}{"off", "heat", "cool", "heat_cool"},
+		"current_temperature": 21.5,
+		"target_temp_low":     float64(19),
+		"target_temp_high":    float64(24),
+		"min_temp":            float64(7),
+		"max_temp":            float64(35),
+		"supported_features":  float64(1 | 2 | 8 | 128 | 256),
+	}}}
+	require.Equal(t, "heat_cool", s.HvacMode())
+	require.Contains(t, s.HvacModes(), "cool")
+	current, ok := s.CurrentTemperature()
+	require.True(t, ok)
+	require.Equal(t, 21.5, current)
+	_, ok = s.Temperature()
+	require.False(t, ok)
+	low, high, ok := s.TemperatureRange()
+	require.True(t, ok)
+	require.Equal(t, []float64{19, 24}, []float64{low, high})
+	min, max := s.TemperatureLimits()
+	require.Equal(t, []float64{7, 35}, []float64{min, max})
+	require.True(t, s.SupportedFeatures().Has(ClimateSupportTargetTemperatureRange))
+	require.False(t, s.SupportedFeatures().Has(ClimateSupportPresetMode))
+	require.Equal(t, "target_temperature|target_temperature_range|fan_mode|turn_off|turn_on",
+		s.SupportedFeatures().String())
+}
+
+func TestCover(t *testing.T) {
+	s := &CoverState{api.State{State: "opening", Attributes: map[string]interface{}{
+		"current_position":   float64(40),
+		"device_class":       "garage",
+		"supported_features": float64(15),
+	}}}
+	require.True(t, s.IsMoving())
+	require.False(t, s.IsOpen())
+	position, ok := s.CurrentPosition()
+	require.True(t, ok)
+	require.Equal(t, 40, position)
+	_, ok = s.CurrentTiltPosition()
+	require.False(t, ok)
+	require.Equal(t, "open|close|set_position|stop", s.SupportedFeatures().String())
+
+	c := NewCover(nil, "cover.garage")
+	require.Equal(t, "cover", c.Domain())
+	require.Error(t, c.SetPosition(101))
+	require.Error(t, c.SetTiltPosition(-1))
+}
+
+func TestMediaPlayer(t *testing.T) {
+	s := &MediaPlayerState{api.State{State: "playing", Attributes: map[string]interface{}{
+		"volume_level":       0.35,
+		"is_volume_muted":    false,
+		"source_list":        []interface{}{"TV", "Spotify"},
+		"media_title":        "Song",
+		"supported_features": float64(1 | 4 | 1<<20),
+	}}}
+	require.True(t, s.IsOn())
+	require.True(t, s.IsPlaying())
+	volume, ok := s.VolumeLevel()
+	require.True(t, ok)
+	require.Equal(t, 0.35, volume)
+	require.Equal(t, []string{"TV", "Spotify"}, s.SourceList())
+	require.Equal(t, "Song", s.MediaTitle())
+	require.Equal(t, "pause|volume_set|1048576", s.SupportedFeatures().String())
+
+	require.False(t, (&MediaPlayerState{api.State{State: "standby"}}).IsOn())
+	require.Error(t, NewMediaPlayer(nil, "media_player.tv").SetVolume(1.5))
+}
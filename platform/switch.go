@@ -0,0 +1,47 @@
+package platform
+
+import (
+	"github.com/asymmetricia/ghastly/api"
+)
+
+type Switch struct {
+	Entity
+}
+
+func NewSwitch(c *api.Client, entityId string) *Switch {
+	return &Switch{Entity{c, entityId}}
+}
+
+func (s *Switch) TurnOn() error {
+	return s.Call("turn_on", nil)
+}
+
+func (s *Switch) TurnOff() error {
+	return s.Call("turn_off", nil)
+}
+
+func (s *Switch) Toggle() error {
+	return s.Call("toggle", nil)
+}
+
+func (s *Switch) State() (*SwitchState, error) {
+	state, err := s.get()
+	if err != nil {
+		return nil, err
+	}
+	return &SwitchState{*state}, nil
+}
+
+// SwitchState is the state of a switch.
+type SwitchState struct {
+	api.State
+}
+
+func (s *SwitchState) IsOn() bool {
+	return s.State.State == "on"
+}
+
+// DeviceClass returns the kind of switch, e.g. `outlet`, or the empty string if it has none.
+func (s *SwitchState) DeviceClass() string {
+	return attrString(&s.State, "device_class")
+}
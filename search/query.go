@@ -0,0 +1,17 @@
+package search
+
+import "fmt"
+
+// ParseQuery parses the given query string, e.g. `platform:hue AND area_id:kitchen`.
+func ParseQuery(qs string) (Node, error) {
+	queryI, err := Parse("query", []byte(qs))
+	if err != nil {
+		return nil, err
+	}
+
+	query, ok := queryI.(Node)
+	if !ok {
+		return nil, fmt.Errorf("query parser returned nil error, but query was %T not search.Node", queryI)
+	}
+	return query, nil
+}
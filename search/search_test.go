@@ -2,6 +2,7 @@ package search
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -24,3 +25,74 @@ func TestParse(t *testing.T) {
 		fmt.Println(valid, "ok")
 	}
 }
+
+func TestParseQuery(t *testing.T) {
+	query, err := ParseQuery("domain:light AND entity_id:light.k*")
+	require.NoError(t, err)
+
+	match, err := query.Evaluate(map[string]string{"domain": "light", "entity_id": "light.kitchen"})
+	require.NoError(t, err)
+	require.True(t, match)
+
+	match, err = query.Evaluate(map[string]string{"domain": "light", "entity_id": "light.porch"})
+	require.NoError(t, err)
+	require.False(t, match)
+
+	_, err = ParseQuery("domain:light AND")
+	require.Error(t, err)
+}
+
+func TestTerms(t *testing.T) {
+	query, err := Parse("test input", []byte("(foo:bar AND bar:baz*) AND blee:bloo"))
+	require.NoError(t, err)
+
+	terms, err := Terms(query.(Node))
+	require.NoError(t, err)
+
+	var got []string
+	for _, term := range terms {
+		got = append(got, term.Field+":"+term.Pattern)
+	}
+	require.Equal(t, []string{"foo:bar", "bar:baz*", "blee:bloo"}, got)
+}
+
+func TestAttributes(t *testing.T) {
+	attrs, err := Attributes(struct {
+		EntityId string                            `json:"entity_id"`
+		Labels   []string                          `json:"labels"`
+		Options  map[string]map[string]interface{} `json:"options"`
+		Hidden   bool
+		Ignored  string `json:"-"`
+	}{
+		EntityId: "sensor.outside",
+		Labels:   []string{"weather"},
+		Options:  map[string]map[string]interface{}{"sensor": {"unit_of_measurement": "°F", "precision": float64(1)}},
+		Hidden:   true,
+		Ignored:  "x",
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"entity_id":                          "sensor.outside",
+		"labels_0":                           "weather",
+		"options_sensor_unit_of_measurement": "°F",
+		"options_sensor_precision":           "1",
+		"hidden":                             "true",
+	}, attrs)
+}
+
+func TestStringFields(t *testing.T) {
+	name := "kitchen"
+	obj := struct {
+		Id      string   `json:"id"`
+		Name    *string  `json:"name"`
+		AreaId  *string  `json:"area_id"`
+		Labels  []string `json:"labels"`
+		Ignored string   `json:"-"`
+	}{Id: "abc", Name: &name}
+
+	fields := StringFields(reflect.TypeOf(obj), "id")
+	require.Equal(t, map[string]int{"name": 1, "area_id": 2}, fields)
+	require.Equal(t, "kitchen", StringField(obj, fields["name"]))
+	require.Equal(t, "", StringField(&obj, fields["area_id"]))
+	require.Equal(t, "abc", StringField(&obj, 0))
+}
@@ -0,0 +1,27 @@
+package search
+
+import "fmt"
+
+// Terms flattens a query consisting only of AND-ed field:value terms into the
+// list of those terms, in the order they appear. An error is returned if the
+// query contains any other kind of node.
+func Terms(n Node) ([]*Variable, error) {
+	switch node := n.(type) {
+	case *Variable:
+		return []*Variable{node}, nil
+	case *Term:
+		return Terms(node.node)
+	case *AndNode:
+		left, err := Terms(node.Left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := Terms(node.Right)
+		if err != nil {
+			return nil, err
+		}
+		return append(left, right...), nil
+	default:
+		return nil, fmt.Errorf("cannot flatten query node %T into terms", n)
+	}
+}
@@ -8,9 +8,12 @@ import (
 )
 
 // ToAttributes uses JSON struct tags to convert the object to a flat map of
-// attributes. Nested structures receive names concatenated with `_`.
+// attributes. Nested structures and maps receive names concatenated with `_`.
 // Collisions are resolved arbitrarily.
 func Attributes(obj interface{}) (map[string]string, error) {
+	if obj == nil {
+		return nil, nil
+	}
 	val := reflect.ValueOf(obj)
 	typ := val.Type()
 	if typ.Kind() == reflect.Ptr {
@@ -50,6 +53,28 @@ func Attributes(obj interface{}) (map[string]string, error) {
 		return ret, nil
 	case reflect.String:
 		return map[string]string{"": val.String()}, nil
+	case reflect.Bool:
+		return map[string]string{"": strconv.FormatBool(val.Bool())}, nil
+	case reflect.Int, reflect.Int64, reflect.Float64:
+		// fmt prefers String() for types that have one, e.g. time.Duration
+		return map[string]string{"": fmt.Sprint(val.Interface())}, nil
+	case reflect.Map:
+		ret := map[string]string{}
+		iter := val.MapRange()
+		for iter.Next() {
+			attributes, err := Attributes(iter.Value().Interface())
+			if err != nil {
+				return nil, fmt.Errorf("%v: %v", iter.Key(), err)
+			}
+			for k, v := range attributes {
+				if k == "" {
+					ret[fmt.Sprint(iter.Key())] = v
+				} else {
+					ret[fmt.Sprint(iter.Key())+"_"+k] = v
+				}
+			}
+		}
+		return ret, nil
 	case reflect.Slice:
 		fallthrough
 	case reflect.Array:
@@ -76,3 +101,37 @@ func Attributes(obj interface{}) (map[string]string, error) {
 		return map[string]string{"": s.String()}, nil
 	}
 }
+
+// StringFields returns the indices of the struct type's string and *string fields by their JSON names, leaving out
+// those named in exclude.
+func StringFields(typ reflect.Type, exclude ...string) map[string]int {
+	ret := map[string]int{}
+fields:
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		jsonName := strings.Split(field.Tag.Get("json"), ",")[0]
+		for _, name := range exclude {
+			if jsonName == name {
+				continue fields
+			}
+		}
+		kind := field.Type.Kind()
+		if kind == reflect.Ptr {
+			kind = field.Type.Elem().Kind()
+		}
+		if kind == reflect.String && jsonName != "" && jsonName != "-" {
+			ret[jsonName] = i
+		}
+	}
+	return ret
+}
+
+// StringField returns the value of the string or *string field with the given index of the struct obj, or of the
+// struct obj points to; or "" if the field is a nil pointer.
+func StringField(obj interface{}, i int) string {
+	val := reflect.Indirect(reflect.Indirect(reflect.ValueOf(obj)).Field(i))
+	if !val.IsValid() {
+		return ""
+	}
+	return val.String()
+}
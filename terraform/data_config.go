@@ -0,0 +1,80 @@
+package main
+
+import (
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func dataConfig() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"location_name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"latitude": {
+				Type:     schema.TypeFloat,
+				Computed: true,
+			},
+			"longitude": {
+				Type:     schema.TypeFloat,
+				Computed: true,
+			},
+			"elevation": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"time_zone": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"unit_system": {
+				Type:        schema.TypeMap,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Computed:    true,
+				Description: "the unit of each kind of measurement, e.g. `length` or `temperature`",
+			},
+			"version": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"config_dir": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"components": {
+				Type:     schema.TypeSet,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Computed: true,
+			},
+		},
+		Read: func(data *schema.ResourceData, i interface{}) error {
+			config, err := i.(*api.Client).GetConfig()
+			if err != nil {
+				return err
+			}
+
+			unitSystem := config.UnitSystem
+			if unitSystem == nil {
+				unitSystem = map[string]string{}
+			}
+			components := config.Components
+			if components == nil {
+				components = []string{}
+			}
+
+			data.SetId("config")
+			return setAll(data, map[string]interface{}{
+				"location_name": config.LocationName,
+				"latitude":      config.Latitude,
+				"longitude":     config.Longitude,
+				"elevation":     config.Elevation,
+				"time_zone":     config.TimeZone,
+				"unit_system":   unitSystem,
+				"version":       config.Version,
+				"config_dir":    config.ConfigDir,
+				"components":    components,
+			})
+		},
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/asymmetricia/ghastly/search"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/hashcode"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+// deviceFields returns the indices of the api.Device fields that can be filtered on, by their JSON names.
+func deviceFields() map[string]int {
+	return filterFields(reflect.TypeOf(api.Device{}))
+}
+
+func deviceFilter() map[string]*schema.Schema {
+	deviceSchema := map[string]*schema.Schema{
+		"device_id": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Optional:    true,
+			Description: "match the device with this ID",
+		},
+		"config_entry_id": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "match devices that belong to this config entry",
+		},
+	}
+
+	for jsonName := range deviceFields() {
+		deviceSchema[jsonName] = &schema.Schema{
+			Type:        schema.TypeString,
+			Computed:    true,
+			Optional:    true,
+			Description: fmt.Sprintf("match against the device %q field", jsonName),
+		}
+	}
+
+	return deviceSchema
+}
+
+// matchDevices returns the devices that match the filter attributes (see deviceFilter) returned by get.
+func matchDevices(client *api.Client, get func(attr string) string) ([]*api.Device, error) {
+	devices, err := client.ListDevices()
+	if err != nil {
+		return nil, fmt.Errorf("listing devices: %w", err)
+	}
+
+	fields := deviceFields()
+
+	var filtered []*api.Device
+devices:
+	for _, device := range devices {
+		for attrName, i := range fields {
+			attr := get(attrName)
+			if len(attr) == 0 {
+				continue
+			}
+			if search.StringField(device, i) != attr {
+				continue devices
+			}
+		}
+
+		if id := get("device_id"); id != "" && device.ID != id {
+			continue
+		}
+
+		if entryId := get("config_entry_id"); entryId != "" {
+			found := false
+			for _, candidate := range device.ConfigEntries {
+				found = found || candidate == entryId
+			}
+			if !found {
+				continue
+			}
+		}
+
+		filtered = append(filtered, device)
+	}
+
+	return filtered, nil
+}
+
+func getMatchingDevices(data *schema.ResourceData, i interface{}) ([]*api.Device, error) {
+	return matchDevices(i.(*api.Client), func(attr string) string {
+		ret, _ := data.Get(attr).(string)
+		return ret
+	})
+}
+
+// deviceName returns the name the UI shows for the device.
+func deviceName(device *api.Device) string {
+	for _, name := range []*string{device.NameByUser, device.Name} {
+		if name != nil && *name != "" {
+			return *name
+		}
+	}
+	return ""
+}
+
+func dataDevice() *schema.Resource {
+	deviceSchema := deviceFilter()
+	deviceSchema["entity_ids"] = &schema.Schema{
+		Type:        schema.TypeSet,
+		Elem:        &schema.Schema{Type: schema.TypeString},
+		Computed:    true,
+		Description: "the IDs of the device's entities",
+	}
+
+	return &schema.Resource{
+		Schema: deviceSchema,
+		Read: func(data *schema.ResourceData, i interface{}) error {
+			client := i.(*api.Client)
+			devices, err := getMatchingDevices(data, i)
+			if err != nil {
+				return err
+			}
+
+			if len(devices) != 1 {
+				var candidates []string
+				for _, device := range devices {
+					candidates = append(candidates, fmt.Sprintf("%s (%s)", device.ID, deviceName(device)))
+				}
+				sort.Strings(candidates)
+				return fmt.Errorf("exactly one device must match, but %d did: %s", len(candidates),
+					strings.Join(candidates, ", "))
+			}
+			device := devices[0]
+
+			entities, err := client.ListEntities()
+			if err != nil {
+				return fmt.Errorf("listing entities: %w", err)
+			}
+			entityIds := []string{}
+			for _, entity := range entities {
+				if entity.DeviceId == device.ID {
+					entityIds = append(entityIds, entity.EntityId)
+				}
+			}
+
+			values := map[string]interface{}{
+				"device_id":  device.ID,
+				"entity_ids": entityIds,
+			}
+			for attrName, i := range deviceFields() {
+				values[attrName] = search.StringField(device, i)
+			}
+
+			data.SetId(device.ID)
+			return setAll(data, values)
+		},
+	}
+}
+
+func dataDevices() *schema.Resource {
+	deviceSchema := deviceFilter()
+	for _, s := range deviceSchema {
+		s.Computed = false
+	}
+	deviceSchema["device_ids"] = &schema.Schema{
+		Type:     schema.TypeSet,
+		Elem:     &schema.Schema{Type: schema.TypeString},
+		Computed: true,
+	}
+
+	return &schema.Resource{
+		Schema: deviceSchema,
+		Read: func(data *schema.ResourceData, i interface{}) error {
+			devices, err := getMatchingDevices(data, i)
+			if err != nil {
+				return err
+			}
+
+			deviceIds := []string{}
+			for _, device := range devices {
+				deviceIds = append(deviceIds, device.ID)
+			}
+			sort.Strings(deviceIds)
+
+			data.SetId(hashcode.Strings(deviceIds))
+			return data.Set("device_ids", deviceIds)
+		},
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+)
+
+// checkSetElem checks that the given string set attribute holds value.
+func checkSetElem(name, attr, value string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return fmt.Errorf("%s not found", name)
+		}
+		for k, v := range rs.Primary.Attributes {
+			if strings.HasPrefix(k, attr+".") && k != attr+".#" && v == value {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s: %s does not hold %q", name, attr, value)
+	}
+}
+
+func fakeDevices(ha *fakeHomeAssistant) {
+	ha.websocket["config/device_registry/list"] = func(map[string]interface{}) (interface{}, error) {
+		return []map[string]interface{}{
+			{"id": "dev1", "name": "Kitchen Light", "manufacturer": "Acme", "model": "L1", "area_id": "kitchen",
+				"config_entries": []string{"entry1"}},
+			{"id": "dev2", "name": "Porch Light", "name_by_user": "Front Porch", "manufacturer": "Acme",
+				"model": "L1", "config_entries": []string{"entry2"}},
+			{"id": "dev3", "name": "Thermostat", "manufacturer": "Other", "config_entries": []string{"entry1"}},
+		}, nil
+	}
+	ha.Entities["light.kitchen"] = &api.Entity{EntityId: "light.kitchen", DeviceId: "dev1"}
+	ha.Entities["sensor.kitchen_power"] = &api.Entity{EntityId: "sensor.kitchen_power", DeviceId: "dev1"}
+	ha.Entities["light.porch"] = &api.Entity{EntityId: "light.porch", DeviceId: "dev2"}
+}
+
+func TestDataDevice(t *testing.T) {
+	ha := newFakeHomeAssistant(t)
+	fakeDevices(ha)
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers: ha.Providers(),
+		Steps: []resource.TestStep{
+			{
+				Config: ha.Config(`
+data "homeassistant_device" "kitchen" {
+  area_id = "kitchen"
+}
+
+data "homeassistant_devices" "acme" {
+  manufacturer = "Acme"
+}
+
+data "homeassistant_devices" "entry1" {
+  config_entry_id = "entry1"
+}`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.homeassistant_device.kitchen", "device_id", "dev1"),
+					resource.TestCheckResourceAttr("data.homeassistant_device.kitchen", "name", "Kitchen Light"),
+					resource.TestCheckResourceAttr("data.homeassistant_device.kitchen", "name_by_user", ""),
+					resource.TestCheckResourceAttr("data.homeassistant_device.kitchen", "entity_ids.#", "2"),
+					checkSetElem("data.homeassistant_device.kitchen", "entity_ids",
+						"sensor.kitchen_power"),
+					resource.TestCheckResourceAttr("data.homeassistant_devices.acme", "device_ids.#", "2"),
+					checkSetElem("data.homeassistant_devices.acme", "device_ids", "dev2"),
+					resource.TestCheckResourceAttr("data.homeassistant_devices.entry1", "device_ids.#", "2"),
+					checkSetElem("data.homeassistant_devices.entry1", "device_ids", "dev3"),
+				),
+			},
+			{
+				Config: ha.Config(`
+data "homeassistant_device" "light" {
+  model = "L1"
+}`),
+				ExpectError: regexp.MustCompile(`exactly one device must match, but 2 did: dev1 \(Kitchen Light\), ` +
+					`dev2 \(Front Porch\)`),
+			},
+		},
+	})
+}
@@ -3,27 +3,68 @@ package main
 import (
 	"fmt"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
 	"github.com/asymmetricia/ghastly/api"
-	"github.com/pkg/errors"
+	"github.com/asymmetricia/ghastly/search"
 	"reflect"
+	"regexp"
+	"sort"
 	"strings"
 )
 
+// maxCandidates bounds how many candidate entities an error lists.
+const maxCandidates = 10
+
+// filterFields returns the indices of the fields of the given api type (e.g., api.Entity) that data sources can filter
+// on, by their JSON names: those that are strings or pointers to strings, other than `id`, which terraform reserves.
+func filterFields(typ reflect.Type) map[string]int {
+	return search.StringFields(typ, "id")
+}
+
+// entityFields returns the indices of the api.Entity fields that can be filtered on, by their JSON names.
+func entityFields() map[string]int {
+	return filterFields(reflect.TypeOf(api.Entity{}))
+}
+
 func entityFilter() map[string]*schema.Schema {
 	entitySchema := map[string]*schema.Schema{
-		// bonus input
+		// bonus inputs
 		"entity_id_prefix": {
 			Type:        schema.TypeString,
 			Computed:    true,
 			Optional:    true,
 			Description: "match entities whose entity_id has this prefix",
 		},
+		"entity_id_regex": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ValidateFunc: validation.StringIsValidRegExp,
+			Description:  "match entities whose entity_id matches this regular expression",
+		},
+		"domain": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "match entities in this domain, e.g. `light`",
+		},
+		"query": {
+			Type:     schema.TypeString,
+			Optional: true,
+			ValidateFunc: func(i interface{}, k string) ([]string, []error) {
+				if _, err := search.ParseQuery(i.(string)); err != nil {
+					return nil, []error{fmt.Errorf("%s: %w", k, err)}
+				}
+				return nil, nil
+			},
+			Description: "match entities with a search query, as `ghastly search` uses, e.g. `platform:hue AND name:*lamp*`",
+		},
+		"exclude_disabled": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Description: "do not match disabled entities",
+		},
 	}
 
-	typ := reflect.TypeOf(api.Entity{})
-	for i := 0; i < typ.NumField(); i++ {
-		field := typ.Field(i)
-		jsonName := strings.Split(field.Tag.Get("json"), ",")[0]
+	for jsonName := range entityFields() {
 		entitySchema[jsonName] = &schema.Schema{
 			Type:        schema.TypeString,
 			Computed:    true,
@@ -31,45 +72,181 @@ func entityFilter() map[string]*schema.Schema {
 			Description: fmt.Sprintf("match against the entity %q field", jsonName),
 		}
 	}
+	entitySchema["area_id"].Description = "match entities in this area, or with no area of their own whose device " +
+		"is in this area"
 
 	return entitySchema
 }
 
+// entityPredicate is one filter of an entity data source: match reports whether an entity passes the filter given by
+// attr.
+type entityPredicate struct {
+	attr  string
+	match func(entity api.Entity) bool
+}
+
+// entityPredicates returns the filters set by the filter attributes (see entityFilter) returned by get.
+func entityPredicates(client *api.Client, get func(attr string) interface{}) ([]entityPredicate, error) {
+	str := func(attr string) string {
+		ret, _ := get(attr).(string)
+		return ret
+	}
+
+	var ret []entityPredicate
+	for attrName, i := range entityFields() {
+		attr, i := str(attrName), i
+		if attr == "" || attrName == "area_id" {
+			continue
+		}
+		ret = append(ret, entityPredicate{attrName, func(entity api.Entity) bool {
+			return search.StringField(entity, i) == attr
+		}})
+	}
+
+	if prefix := str("entity_id_prefix"); prefix != "" {
+		ret = append(ret, entityPredicate{"entity_id_prefix", func(entity api.Entity) bool {
+			return strings.HasPrefix(entity.EntityId, prefix)
+		}})
+	}
+
+	if expr := str("entity_id_regex"); expr != "" {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("entity_id_regex: %w", err)
+		}
+		ret = append(ret, entityPredicate{"entity_id_regex", func(entity api.Entity) bool {
+			return re.MatchString(entity.EntityId)
+		}})
+	}
+
+	if domain := str("domain"); domain != "" {
+		ret = append(ret, entityPredicate{"domain", func(entity api.Entity) bool {
+			return entityDomain(entity.EntityId) == domain
+		}})
+	}
+
+	if qs := str("query"); qs != "" {
+		query, err := search.ParseQuery(qs)
+		if err != nil {
+			return nil, fmt.Errorf("query: %w", err)
+		}
+		ret = append(ret, entityPredicate{"query", func(entity api.Entity) bool {
+			attrs, err := search.Attributes(entity)
+			if err != nil {
+				return false
+			}
+			match, err := query.Evaluate(attrs)
+			return err == nil && match
+		}})
+	}
+
+	if areaId := str("area_id"); areaId != "" {
+		devices, err := client.ListDevices()
+		if err != nil {
+			return nil, fmt.Errorf("listing devices: %w", err)
+		}
+		deviceAreas := map[string]string{}
+		for _, device := range devices {
+			if device.AreaId != nil {
+				deviceAreas[device.ID] = *device.AreaId
+			}
+		}
+		ret = append(ret, entityPredicate{"area_id", func(entity api.Entity) bool {
+			if entity.AreaId != "" {
+				return entity.AreaId == areaId
+			}
+			return entity.DeviceId != "" && deviceAreas[entity.DeviceId] == areaId
+		}})
+	}
+
+	if exclude, _ := get("exclude_disabled").(bool); exclude {
+		ret = append(ret, entityPredicate{"exclude_disabled", func(entity api.Entity) bool {
+			return entity.DisabledBy == ""
+		}})
+	}
+
+	sort.Slice(ret, func(i, j int) bool { return ret[i].attr < ret[j].attr })
+	return ret, nil
+}
+
 func getMatchingEntities(data *schema.ResourceData, i interface{}) ([]api.Entity, error) {
-	client := i.(*api.Client)
-	entities, err := client.ListEntities()
+	return matchEntities(i.(*api.Client), data.Get)
+}
+
+// matchEntities returns the entities that match the filter attributes (see entityFilter) returned by get.
+func matchEntities(client *api.Client, get func(attr string) interface{}) ([]api.Entity, error) {
+	filtered, _, err := filterEntities(client, get)
+	return filtered, err
+}
+
+// filterEntities returns the entities that match the filter attributes (see entityFilter) returned by get, and, for
+// use in errors, the IDs of those that fail only one filter, with that filter.
+func filterEntities(client *api.Client, get func(attr string) interface{}) ([]api.Entity, []string, error) {
+	predicates, err := entityPredicates(client, get)
 	if err != nil {
-		return nil, fmt.Errorf("listing entities: %w", err)
+		return nil, nil, err
 	}
 
-	typ := reflect.TypeOf(api.Entity{})
+	entities, err := client.ListEntities()
+	if err != nil {
+		return nil, nil, fmt.Errorf("listing entities: %w", err)
+	}
 
 	var filtered []api.Entity
-entities:
+	var nearMisses []string
 	for _, entity := range entities {
-		for i := 0; i < typ.NumField(); i++ {
-			field := typ.Field(i)
-			attrName := strings.Split(field.Tag.Get("json"), ",")[0]
-			attr, _ := data.Get(attrName).(string)
-			if len(attr) == 0 {
-				continue
+		var failed []string
+		for _, predicate := range predicates {
+			if !predicate.match(entity) {
+				failed = append(failed, predicate.attr)
 			}
+		}
 
-			actual := reflect.ValueOf(entity).Field(i).String()
-			if actual != attr {
-				continue entities
-			}
+		switch len(failed) {
+		case 0:
+			filtered = append(filtered, entity)
+		case 1:
+			nearMisses = append(nearMisses, fmt.Sprintf("%s (not %s)", entity.EntityId, failed[0]))
 		}
+	}
 
-		entityIdPrefix, _ := data.Get("entity_id_prefix").(string)
-		if entityIdPrefix != "" && !strings.HasPrefix(entity.EntityId, entityIdPrefix) {
-			continue
+	sort.Strings(nearMisses)
+	return filtered, nearMisses, nil
+}
+
+// matchEntity returns the one entity that matches the filter attributes (see entityFilter) returned by get. If none
+// or several do, the error lists the candidates: those that match, or those that fail only one filter.
+func matchEntity(client *api.Client, get func(attr string) interface{}) (*api.Entity, error) {
+	entities, nearMisses, err := filterEntities(client, get)
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(entities) {
+	case 1:
+		return &entities[0], nil
+	case 0:
+		if len(nearMisses) == 0 {
+			return nil, fmt.Errorf("no entity matches, nor any that fails only one filter")
 		}
+		return nil, fmt.Errorf("no entity matches; these fail only one filter: %s", candidateList(nearMisses))
+	}
 
-		filtered = append(filtered, entity)
+	var ids []string
+	for _, entity := range entities {
+		ids = append(ids, entity.EntityId)
 	}
+	sort.Strings(ids)
+	return nil, fmt.Errorf("exactly one entity must match, but %d did: %s", len(ids), candidateList(ids))
+}
 
-	return filtered, nil
+// candidateList joins the given candidates for an error, listing at most maxCandidates.
+func candidateList(candidates []string) string {
+	if len(candidates) <= maxCandidates {
+		return strings.Join(candidates, ", ")
+	}
+	return fmt.Sprintf("%s, and %d more", strings.Join(candidates[:maxCandidates], ", "),
+		len(candidates)-maxCandidates)
 }
 
 func dataEntity() *schema.Resource {
@@ -78,29 +255,16 @@ func dataEntity() *schema.Resource {
 	return &schema.Resource{
 		Schema: entitySchema,
 		Read: func(data *schema.ResourceData, i interface{}) error {
-			entities, err := getMatchingEntities(data, i)
+			entity, err := matchEntity(i.(*api.Client), data.Get)
 			if err != nil {
 				return err
 			}
 
-			if len(entities) < 1 {
-				return errors.New("no match")
-			}
-
-			if len(entities) > 1 {
-				return errors.New("too many matches")
-			}
-
-			val := reflect.ValueOf(entities[0])
-			typ := val.Type()
-			for i := 0; i < typ.NumField(); i++ {
-				data.Set(
-					strings.Split(typ.Field(i).Tag.Get("json"), ",")[0],
-					val.Field(i).String(),
-				)
+			for attrName, i := range entityFields() {
+				data.Set(attrName, search.StringField(entity, i))
 			}
 
-			data.SetId(entities[0].EntityId)
+			data.SetId(entity.EntityId)
 			return nil
 		},
 	}
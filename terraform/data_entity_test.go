@@ -0,0 +1,88 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+)
+
+func TestDataEntity_Filters(t *testing.T) {
+	ha := newFakeHomeAssistant(t)
+	ha.websocket["config/device_registry/list"] = func(map[string]interface{}) (interface{}, error) {
+		return []map[string]interface{}{{"id": "dev1", "area_id": "kitchen"}}, nil
+	}
+	for _, entity := range []*api.Entity{
+		{EntityId: "light.kitchen", DeviceId: "dev1", Platform: "hue", Name: "Kitchen Lamp"},
+		{EntityId: "light.kitchen_2", DeviceId: "dev1", Platform: "hue", DisabledBy: "user"},
+		{EntityId: "light.porch", AreaId: "porch", DeviceId: "dev1", Platform: "hue", Name: "Porch Lamp"},
+		{EntityId: "sensor.kitchen_power", DeviceId: "dev1", Platform: "hue"},
+		{EntityId: "switch.kitchen", AreaId: "kitchen", Platform: "zwave"},
+	} {
+		ha.Entities[entity.EntityId] = entity
+	}
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers: ha.Providers(),
+		Steps: []resource.TestStep{
+			{
+				Config: ha.Config(`
+data "homeassistant_entity_ids" "kitchen" {
+  area_id = "kitchen"
+}
+
+data "homeassistant_entity_ids" "kitchen_lights" {
+  area_id          = "kitchen"
+  domain           = "light"
+  exclude_disabled = true
+}
+
+data "homeassistant_entity_ids" "numbered" {
+  entity_id_regex = "_[0-9]+$"
+}
+
+data "homeassistant_entity" "porch" {
+  query = "platform:hue AND name:porch*"
+}`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.homeassistant_entity_ids.kitchen", "entity_ids.#", "4"),
+					checkSetElem("data.homeassistant_entity_ids.kitchen", "entity_ids", "switch.kitchen"),
+					checkSetElem("data.homeassistant_entity_ids.kitchen", "entity_ids", "sensor.kitchen_power"),
+					resource.TestCheckResourceAttr("data.homeassistant_entity_ids.kitchen_lights", "entity_ids.#",
+						"1"),
+					checkSetElem("data.homeassistant_entity_ids.kitchen_lights", "entity_ids", "light.kitchen"),
+					resource.TestCheckResourceAttr("data.homeassistant_entity_ids.numbered", "entity_ids.#", "1"),
+					checkSetElem("data.homeassistant_entity_ids.numbered", "entity_ids", "light.kitchen_2"),
+					resource.TestCheckResourceAttr("data.homeassistant_entity.porch", "entity_id", "light.porch"),
+				),
+			},
+			{
+				Config: ha.Config(`
+data "homeassistant_entity" "kitchen" {
+  area_id = "kitchen"
+  domain  = "light"
+}`),
+				ExpectError: regexp.MustCompile(`exactly one entity must match, but 2 did: light.kitchen, ` +
+					`light.kitchen_2`),
+			},
+			{
+				Config: ha.Config(`
+data "homeassistant_entity" "kitchen" {
+  area_id  = "kitchen"
+  platform = "lutron"
+}`),
+				ExpectError: regexp.MustCompile(`no entity matches; these fail only one filter: light.kitchen ` +
+					`\(not platform\), light.kitchen_2 \(not platform\), sensor.kitchen_power \(not platform\), ` +
+					`switch.kitchen \(not platform\)`),
+			},
+			{
+				Config: ha.Config(`
+data "homeassistant_entity" "kitchen" {
+  query = "platform:hue AND"
+}`),
+				ExpectError: regexp.MustCompile(`query: `),
+			},
+		},
+	})
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+// lookupSchema returns the schema of a data source that finds one of the things the given resource schema describes,
+// by the ID attribute given or by name. All other attributes are exported.
+func lookupSchema(resourceSchema map[string]*schema.Schema, idAttr string) map[string]*schema.Schema {
+	for attr, s := range resourceSchema {
+		*s = schema.Schema{Type: s.Type, Elem: s.Elem, Computed: true, Description: s.Description}
+		if attr == idAttr || attr == "name" {
+			s.Optional = true
+			s.ExactlyOneOf = []string{idAttr, "name"}
+		}
+	}
+	return resourceSchema
+}
+
+// lookup returns the index of the one candidate whose ID or name, as given by idName, matches the data source's
+// idAttr or name. kind names the candidates in errors, which list those there are to choose from.
+func lookup(data *schema.ResourceData, kind string, idAttr string, n int, idName func(i int) (string, string)) (int,
+	error) {
+	id := data.Get(idAttr).(string)
+	name := data.Get("name").(string)
+
+	found := -1
+	var candidates []string
+	for i := 0; i < n; i++ {
+		candidateId, candidateName := idName(i)
+		candidates = append(candidates, fmt.Sprintf("%s (%s)", candidateId, candidateName))
+		if id != "" && candidateId != id || id == "" && candidateName != name {
+			continue
+		}
+		if found >= 0 {
+			return -1, fmt.Errorf("more than one %s is named %q", kind, name)
+		}
+		found = i
+	}
+
+	if found < 0 {
+		sort.Strings(candidates)
+		what := fmt.Sprintf("with %s %q", idAttr, id)
+		if id == "" {
+			what = fmt.Sprintf("named %q", name)
+		}
+		return -1, fmt.Errorf("no %s %s; there are: %s", kind, what, strings.Join(candidates, ", "))
+	}
+	return found, nil
+}
+
+func dataArea() *schema.Resource {
+	return &schema.Resource{
+		Schema: lookupSchema(areaSchema(), "area_id"),
+		Read: func(data *schema.ResourceData, i interface{}) error {
+			areas, err := i.(*api.Client).ListAreas()
+			if err != nil {
+				return err
+			}
+			found, err := lookup(data, "area", "area_id", len(areas), func(i int) (string, string) {
+				return areas[i].AreaId, areas[i].Name
+			})
+			if err != nil {
+				return err
+			}
+			data.SetId(areas[found].AreaId)
+			return setArea(data, &areas[found])
+		},
+	}
+}
+
+func dataFloor() *schema.Resource {
+	return &schema.Resource{
+		Schema: lookupSchema(floorSchema(), "floor_id"),
+		Read: func(data *schema.ResourceData, i interface{}) error {
+			floors, err := i.(*api.Client).ListFloors()
+			if err != nil {
+				return err
+			}
+			found, err := lookup(data, "floor", "floor_id", len(floors), func(i int) (string, string) {
+				return floors[i].FloorId, floors[i].Name
+			})
+			if err != nil {
+				return err
+			}
+			data.SetId(floors[found].FloorId)
+			return setFloor(data, &floors[found])
+		},
+	}
+}
+
+func dataLabel() *schema.Resource {
+	return &schema.Resource{
+		Schema: lookupSchema(labelSchema(), "label_id"),
+		Read: func(data *schema.ResourceData, i interface{}) error {
+			labels, err := i.(*api.Client).ListLabels()
+			if err != nil {
+				return err
+			}
+			found, err := lookup(data, "label", "label_id", len(labels), func(i int) (string, string) {
+				return labels[i].LabelId, labels[i].Name
+			})
+			if err != nil {
+				return err
+			}
+			data.SetId(labels[found].LabelId)
+			return setLabel(data, &labels[found])
+		},
+	}
+}
+
+func dataZone() *schema.Resource {
+	return &schema.Resource{
+		Schema: lookupSchema(zoneSchema(), "zone_id"),
+		Read: func(data *schema.ResourceData, i interface{}) error {
+			zones, err := i.(*api.Client).ListZones()
+			if err != nil {
+				return err
+			}
+			found, err := lookup(data, "zone", "zone_id", len(zones), func(i int) (string, string) {
+				return zones[i].Id, zones[i].Name
+			})
+			if err != nil {
+				return err
+			}
+			data.SetId(zones[found].Id)
+			return setZone(data, &zones[found])
+		},
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func dataService() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"domain": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"service": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"name": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "the service's ID, `<domain>.<service>`, for use in automation actions",
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"fields": {
+				Type:     schema.TypeSet,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Computed: true,
+			},
+			"required_fields": {
+				Type:     schema.TypeSet,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Computed: true,
+			},
+		},
+		Read: func(data *schema.ResourceData, i interface{}) error {
+			client := i.(*api.Client)
+			domain := data.Get("domain").(string)
+			name := data.Get("service").(string)
+
+			service, err := client.GetService(domain, name)
+			if errors.Is(err, api.ServiceNotFound) {
+				return serviceNotFound(client, domain, name)
+			}
+			if err != nil {
+				return err
+			}
+
+			fields := []string{}
+			required := []string{}
+			for field, f := range service.Fields {
+				fields = append(fields, field)
+				if f.Required {
+					required = append(required, field)
+				}
+			}
+
+			data.SetId(domain + "." + name)
+			return setAll(data, map[string]interface{}{
+				"name":            domain + "." + name,
+				"description":     service.Description,
+				"fields":          fields,
+				"required_fields": required,
+			})
+		},
+	}
+}
+
+// serviceNotFound returns an error saying that the given service does not exist, which lists the services the domain
+// does have, or the domains there are if it has none.
+func serviceNotFound(client *api.Client, domain, name string) error {
+	services, err := client.ListServices()
+	if err != nil {
+		return fmt.Errorf("service %s.%s not found", domain, name)
+	}
+
+	var inDomain []string
+	domains := map[string]bool{}
+	for _, service := range services {
+		domains[service.Domain] = true
+		if service.Domain == domain {
+			inDomain = append(inDomain, service.Name)
+		}
+	}
+	if len(inDomain) > 0 {
+		sort.Strings(inDomain)
+		return fmt.Errorf("domain %s has no service %q; it has: %s", domain, name, strings.Join(inDomain, ", "))
+	}
+
+	var all []string
+	for d := range domains {
+		all = append(all, d)
+	}
+	sort.Strings(all)
+	return fmt.Errorf("there is no service domain %q; there are: %s", domain, strings.Join(all, ", "))
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/asymmetricia/ghastly/search"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func dataState() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"entity_id": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"state": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"attributes": {
+				Type:        schema.TypeMap,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Computed:    true,
+				Description: "the entity's attributes, as strings; nested values are flattened, with keys joined by `_`",
+			},
+			"attributes_json": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "the entity's attributes as JSON, for use with `jsondecode`",
+			},
+			"last_changed": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"last_updated": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+		Read: func(data *schema.ResourceData, i interface{}) error {
+			entityId := data.Get("entity_id").(string)
+			state, err := i.(*api.Client).GetState(entityId)
+			if err != nil {
+				return err
+			}
+
+			attributes, err := search.Attributes(state.Attributes)
+			if err != nil {
+				return fmt.Errorf("flattening attributes of %q: %w", entityId, err)
+			}
+			if attributes == nil {
+				attributes = map[string]string{}
+			}
+			attributesJson, err := json.Marshal(state.Attributes)
+			if err != nil {
+				return fmt.Errorf("encoding attributes of %q: %w", entityId, err)
+			}
+
+			data.SetId(state.EntityId)
+			return setAll(data, map[string]interface{}{
+				"state":           state.State,
+				"attributes":      attributes,
+				"attributes_json": string(attributesJson),
+				"last_changed":    state.LastChanged.Format(time.RFC3339),
+				"last_updated":    state.LastUpdated.Format(time.RFC3339),
+			})
+		},
+	}
+}
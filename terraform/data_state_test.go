@@ -0,0 +1,143 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+)
+
+func TestDataState(t *testing.T) {
+	ha := newFakeHomeAssistant(t)
+	ha.rest["GET /api/states/light.kitchen"] = func(map[string]interface{}) (interface{}, error) {
+		return map[string]interface{}{
+			"entity_id":    "light.kitchen",
+			"state":        "on",
+			"last_changed": "2024-01-02T03:04:05Z",
+			"last_updated": "2024-01-02T03:04:06Z",
+			"attributes": map[string]interface{}{
+				"brightness": 255,
+				"rgb_color":  []interface{}{255, 128, 0},
+			},
+		}, nil
+	}
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers: ha.Providers(),
+		Steps: []resource.TestStep{
+			{
+				Config: ha.Config(`
+data "homeassistant_state" "kitchen" {
+  entity_id = "light.kitchen"
+}`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.homeassistant_state.kitchen", "state", "on"),
+					resource.TestCheckResourceAttr("data.homeassistant_state.kitchen", "attributes.brightness", "255"),
+					resource.TestCheckResourceAttr("data.homeassistant_state.kitchen", "attributes.rgb_color_1", "128"),
+					resource.TestCheckResourceAttr("data.homeassistant_state.kitchen", "attributes_json",
+						`{"brightness":255,"rgb_color":[255,128,0]}`),
+					resource.TestCheckResourceAttr("data.homeassistant_state.kitchen", "last_changed",
+						"2024-01-02T03:04:05Z"),
+				),
+			},
+			{
+				Config: ha.Config(`
+data "homeassistant_state" "gone" {
+  entity_id = "light.gone"
+}`),
+				ExpectError: regexp.MustCompile(`getting state of "light.gone"`),
+			},
+		},
+	})
+}
+
+func TestDataService(t *testing.T) {
+	ha := newFakeHomeAssistant(t)
+	ha.rest["GET /api/services"] = func(map[string]interface{}) (interface{}, error) {
+		return []interface{}{
+			map[string]interface{}{"domain": "light", "services": map[string]interface{}{
+				"turn_on": map[string]interface{}{
+					"description": "Turn on a light.",
+					"fields": map[string]interface{}{
+						"brightness": map[string]interface{}{"selector": map[string]interface{}{"number": nil}},
+						"transition": map[string]interface{}{"required": true},
+					},
+				},
+				"turn_off": map[string]interface{}{"fields": map[string]interface{}{}},
+			}},
+			map[string]interface{}{"domain": "switch", "services": map[string]interface{}{
+				"toggle": map[string]interface{}{},
+			}},
+		}, nil
+	}
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers: ha.Providers(),
+		Steps: []resource.TestStep{
+			{
+				Config: ha.Config(`
+data "homeassistant_service" "on" {
+  domain  = "light"
+  service = "turn_on"
+}`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.homeassistant_service.on", "name", "light.turn_on"),
+					resource.TestCheckResourceAttr("data.homeassistant_service.on", "description", "Turn on a light."),
+					resource.TestCheckResourceAttr("data.homeassistant_service.on", "fields.#", "2"),
+					resource.TestCheckResourceAttr("data.homeassistant_service.on", "required_fields.#", "1"),
+					checkSetElem("data.homeassistant_service.on", "required_fields",
+						"transition"),
+				),
+			},
+			{
+				Config: ha.Config(`
+data "homeassistant_service" "on" {
+  domain  = "light"
+  service = "turn_up"
+}`),
+				ExpectError: regexp.MustCompile(`domain light has no service "turn_up"; it has: turn_off, turn_on`),
+			},
+			{
+				Config: ha.Config(`
+data "homeassistant_service" "on" {
+  domain  = "lights"
+  service = "turn_on"
+}`),
+				ExpectError: regexp.MustCompile(`there is no service domain "lights"; there are: light, switch`),
+			},
+		},
+	})
+}
+
+func TestDataConfig(t *testing.T) {
+	ha := newFakeHomeAssistant(t)
+	ha.websocket["get_config"] = func(map[string]interface{}) (interface{}, error) {
+		return map[string]interface{}{
+			"location_name": "Home",
+			"latitude":      52.5,
+			"longitude":     13.4,
+			"elevation":     34,
+			"time_zone":     "Europe/Berlin",
+			"unit_system":   map[string]string{"length": "km", "temperature": "°C"},
+			"version":       "2024.1.0",
+			"components":    []string{"light", "mqtt"},
+		}, nil
+	}
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers: ha.Providers(),
+		Steps: []resource.TestStep{
+			{
+				Config: ha.Config(`data "homeassistant_config" "ha" {}`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.homeassistant_config.ha", "location_name", "Home"),
+					resource.TestCheckResourceAttr("data.homeassistant_config.ha", "latitude", "52.5"),
+					resource.TestCheckResourceAttr("data.homeassistant_config.ha", "elevation", "34"),
+					resource.TestCheckResourceAttr("data.homeassistant_config.ha", "unit_system.temperature", "°C"),
+					resource.TestCheckResourceAttr("data.homeassistant_config.ha", "version", "2024.1.0"),
+					resource.TestCheckResourceAttr("data.homeassistant_config.ha", "components.#", "2"),
+				),
+			},
+		},
+	})
+}
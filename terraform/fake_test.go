@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/gorilla/websocket"
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+)
+
+// fakeHandler answers a request to the fake server, whose body (for REST requests) or message (for websocket requests)
+// is given. Returning an api.ResultError fails the request with that error's code; for REST requests, `not_found`
+// becomes a 404.
+type fakeHandler func(request map[string]interface{}) (interface{}, error)
+
+// fakeHomeAssistant is a homeassistant server that keeps its entity registry in memory, for testing resources without
+// a real server. Tests may add handlers for other websocket messages, by type, or REST requests, by method and path
+// (e.g., `GET /api/states/light.kitchen`). A REST handler whose path ends in `/` handles every path under it, and finds
+// the rest of the path in the request's `_path`.
+type fakeHomeAssistant struct {
+	sync.Mutex
+	server    *httptest.Server
+	websocket map[string]fakeHandler
+	rest      map[string]fakeHandler
+
+	Entities map[string]*api.Entity
+	// Requests holds the type of each websocket request and the method and path of each REST request, in order.
+	Requests []string
+}
+
+func newFakeHomeAssistant(t *testing.T) *fakeHomeAssistant {
+	f := &fakeHomeAssistant{
+		websocket: map[string]fakeHandler{},
+		rest:      map[string]fakeHandler{},
+		Entities:  map[string]*api.Entity{},
+	}
+
+	f.websocket["get_config"] = func(map[string]interface{}) (interface{}, error) {
+		return map[string]interface{}{"version": "fake"}, nil
+	}
+	f.websocket["config/entity_registry/list"] = func(map[string]interface{}) (interface{}, error) {
+		var ids []string
+		for id := range f.Entities {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		ret := []*api.Entity{}
+		for _, id := range ids {
+			ret = append(ret, f.Entities[id])
+		}
+		return ret, nil
+	}
+	f.websocket["config/entity_registry/get"] = func(request map[string]interface{}) (interface{}, error) {
+		return f.entity(request)
+	}
+	f.websocket["config/entity_registry/update"] = func(request map[string]interface{}) (interface{}, error) {
+		entity, err := f.entity(request)
+		if err != nil {
+			return nil, err
+		}
+		for key, field := range map[string]*string{
+			"name":         &entity.Name,
+			"icon":         &entity.Icon,
+			"area_id":      &entity.AreaId,
+			"disabled_by":  &entity.DisabledBy,
+			"hidden_by":    &entity.HiddenBy,
+			"device_class": &entity.DeviceClass,
+		} {
+			if value, ok := request[key]; ok {
+				*field, _ = value.(string)
+			}
+		}
+		if labels, ok := request["labels"].([]interface{}); ok {
+			entity.Labels = nil
+			for _, label := range labels {
+				entity.Labels = append(entity.Labels, label.(string))
+			}
+		}
+		if domain, ok := request["options_domain"].(string); ok {
+			if entity.Options == nil {
+				entity.Options = map[string]map[string]interface{}{}
+			}
+			entity.Options[domain], _ = request["options"].(map[string]interface{})
+		}
+		if newId, ok := request["new_entity_id"].(string); ok {
+			if _, taken := f.Entities[newId]; taken {
+				return nil, api.ResultError{Code: "invalid_info", Message: "Entity with this ID is already registered"}
+			}
+			delete(f.Entities, entity.EntityId)
+			entity.EntityId = newId
+			f.Entities[newId] = entity
+		}
+		return map[string]interface{}{"entity_entry": entity}, nil
+	}
+
+	upgrader := websocket.Upgrader{}
+	f.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/websocket" {
+			f.serveWebsocket(t, upgrader, w, r)
+			return
+		}
+
+		var request map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		result, err := f.handle(f.rest, r.Method+" "+r.URL.Path, request)
+		var resultErr api.ResultError
+		switch {
+		case errors.As(err, &resultErr) && resultErr.Code == "not_found":
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			_ = json.NewEncoder(w).Encode(result)
+		}
+	}))
+	t.Cleanup(f.server.Close)
+
+	return f
+}
+
+// entity returns the entity named by the request's entity_id.
+func (f *fakeHomeAssistant) entity(request map[string]interface{}) (*api.Entity, error) {
+	id, _ := request["entity_id"].(string)
+	entity, ok := f.Entities[id]
+	if !ok {
+		return nil, api.ResultError{Code: "not_found", Message: "Entity not found"}
+	}
+	return entity, nil
+}
+
+func (f *fakeHomeAssistant) handle(handlers map[string]fakeHandler, key string, request map[string]interface{}) (
+	interface{}, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	f.Requests = append(f.Requests, key)
+	handler, ok := handlers[key]
+	if !ok {
+		for prefix, h := range handlers {
+			if strings.HasSuffix(prefix, "/") && strings.HasPrefix(key, prefix) {
+				if request == nil {
+					request = map[string]interface{}{}
+				}
+				request["_path"] = strings.TrimPrefix(key, prefix)
+				handler, ok = h, true
+			}
+		}
+	}
+	if !ok {
+		return nil, api.ResultError{Code: "unknown_command", Message: "no fake handler for " + key}
+	}
+	return handler(request)
+}
+
+func (f *fakeHomeAssistant) serveWebsocket(t *testing.T, upgrader websocket.Upgrader, w http.ResponseWriter,
+	r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer conn.Close()
+
+	_ = conn.WriteJSON(map[string]interface{}{"type": "auth_required"})
+	var auth map[string]interface{}
+	if err := conn.ReadJSON(&auth); err != nil {
+		return
+	}
+	_ = conn.WriteJSON(map[string]interface{}{"type": "auth_ok"})
+
+	for {
+		var request map[string]interface{}
+		if err := conn.ReadJSON(&request); err != nil {
+			return
+		}
+		typ, _ := request["type"].(string)
+		response := map[string]interface{}{"id": request["id"], "type": "result", "success": true}
+		result, err := f.handle(f.websocket, typ, request)
+		if err != nil {
+			code := "unknown_error"
+			var resultErr api.ResultError
+			if errors.As(err, &resultErr) {
+				code = resultErr.Code
+			}
+			response["success"] = false
+			response["error"] = map[string]interface{}{"code": code, "message": err.Error()}
+		} else {
+			response["result"] = result
+		}
+		if err := conn.WriteJSON(response); err != nil {
+			return
+		}
+	}
+}
+
+// Providers returns the providers for a resource.TestCase.
+func (f *fakeHomeAssistant) Providers() map[string]terraform.ResourceProvider {
+	return map[string]terraform.ResourceProvider{"homeassistant": Provider()}
+}
+
+// Config returns the given configuration, with a provider block that uses the fake server.
+func (f *fakeHomeAssistant) Config(config string) string {
+	return fmt.Sprintf("provider \"homeassistant\" {\n  url   = %q\n  token = \"fake\"\n}\n\n%s", f.server.URL,
+		strings.TrimSpace(config))
+}
+
+// Count returns how many requests of the given kind the server has seen; see Requests.
+func (f *fakeHomeAssistant) Count(request string) int {
+	f.Lock()
+	defer f.Unlock()
+
+	ret := 0
+	for _, r := range f.Requests {
+		if r == request {
+			ret++
+		}
+	}
+	return ret
+}
@@ -31,11 +31,34 @@ func Provider() *schema.Provider {
 			},
 		},
 		ResourcesMap: map[string]*schema.Resource{
-			"homeassistant_entity_name": resourceEntityName(),
+			"homeassistant_area":           resourceArea(),
+			"homeassistant_automation":     resourceAutomation(),
+			"homeassistant_config_entry":   resourceConfigEntry(),
+			"homeassistant_counter":        resourceCounter(),
+			"homeassistant_entity":         resourceEntity(),
+			"homeassistant_entity_name":    resourceEntityName(),
+			"homeassistant_floor":          resourceFloor(),
+			"homeassistant_input_boolean":  resourceInputBoolean(),
+			"homeassistant_input_datetime": resourceInputDatetime(),
+			"homeassistant_input_number":   resourceInputNumber(),
+			"homeassistant_input_select":   resourceInputSelect(),
+			"homeassistant_input_text":     resourceInputText(),
+			"homeassistant_label":          resourceLabel(),
+			"homeassistant_timer":          resourceTimer(),
+			"homeassistant_zone":           resourceZone(),
 		},
 		DataSourcesMap: map[string]*schema.Resource{
+			"homeassistant_area":       dataArea(),
+			"homeassistant_config":     dataConfig(),
+			"homeassistant_device":     dataDevice(),
+			"homeassistant_devices":    dataDevices(),
 			"homeassistant_entity":     dataEntity(),
 			"homeassistant_entity_ids": dataEntityIds(),
+			"homeassistant_floor":      dataFloor(),
+			"homeassistant_label":      dataLabel(),
+			"homeassistant_service":    dataService(),
+			"homeassistant_state":      dataState(),
+			"homeassistant_zone":       dataZone(),
 		},
 	}
 }
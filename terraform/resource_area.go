@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func areaSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"area_id": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"name": {
+			Type:     schema.TypeString,
+			Required: true,
+		},
+		"aliases": {
+			Type:        schema.TypeSet,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Optional:    true,
+			Description: "other names for the area, e.g. for voice assistants",
+		},
+		"floor_id": {
+			Type:     schema.TypeString,
+			Optional: true,
+		},
+		"icon": {
+			Type:     schema.TypeString,
+			Optional: true,
+		},
+		"labels": {
+			Type:     schema.TypeSet,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+			Optional: true,
+		},
+		"picture": {
+			Type:     schema.TypeString,
+			Optional: true,
+		},
+	}
+}
+
+func resourceArea() *schema.Resource {
+	return &schema.Resource{
+		Schema: areaSchema(),
+		Create: func(data *schema.ResourceData, i interface{}) error {
+			area, err := i.(*api.Client).CreateArea(areaFromResource(data))
+			if err != nil {
+				return err
+			}
+			data.SetId(area.AreaId)
+			return setArea(data, area)
+		},
+		Read: func(data *schema.ResourceData, i interface{}) error {
+			area, err := i.(*api.Client).GetArea(data.Id())
+			if api.IsNotFound(err) {
+				data.SetId("")
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			return setArea(data, area)
+		},
+		Update: func(data *schema.ResourceData, i interface{}) error {
+			area, err := i.(*api.Client).UpdateArea(areaFromResource(data))
+			if err != nil {
+				return err
+			}
+			return setArea(data, area)
+		},
+		Delete: func(data *schema.ResourceData, i interface{}) error {
+			err := i.(*api.Client).DeleteArea(data.Id())
+			if api.IsNotFound(err) {
+				return nil
+			}
+			return err
+		},
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+	}
+}
+
+func areaFromResource(data *schema.ResourceData) api.Area {
+	return api.Area{
+		AreaId:  data.Id(),
+		Name:    data.Get("name").(string),
+		Aliases: stringSet(data.Get("aliases")),
+		FloorId: data.Get("floor_id").(string),
+		Icon:    data.Get("icon").(string),
+		Labels:  stringSet(data.Get("labels")),
+		Picture: data.Get("picture").(string),
+	}
+}
+
+func setArea(data *schema.ResourceData, area *api.Area) error {
+	return setAll(data, map[string]interface{}{
+		"area_id":  area.AreaId,
+		"name":     area.Name,
+		"aliases":  area.Aliases,
+		"floor_id": area.FloorId,
+		"icon":     area.Icon,
+		"labels":   area.Labels,
+		"picture":  area.Picture,
+	})
+}
+
+// stringSet returns the members of a schema.TypeSet of strings, in no particular order.
+func stringSet(setI interface{}) []string {
+	set, ok := setI.(*schema.Set)
+	if !ok {
+		return nil
+	}
+	var ret []string
+	for _, member := range set.List() {
+		ret = append(ret, member.(string))
+	}
+	return ret
+}
+
+// setAll sets each of the given attributes.
+func setAll(data *schema.ResourceData, values map[string]interface{}) error {
+	for attr, value := range values {
+		if err := data.Set(attr, value); err != nil {
+			return fmt.Errorf("setting %s: %w", attr, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,476 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"gopkg.in/yaml.v3"
+)
+
+// automationStructured are the attributes that describe an automation without config_yaml.
+var automationStructured = []string{"alias", "description", "mode", "trigger", "condition", "action", "options"}
+
+// automationModeled are the top-level keys of an automation that have attributes of their own; the rest are options.
+var automationModeled = map[string]bool{
+	"id": true, "alias": true, "description": true, "mode": true, "trigger": true, "condition": true, "action": true,
+	"triggers": true, "conditions": true, "actions": true,
+}
+
+// optionsSchema is a map whose values are decoded by decodeOption, and which ignores differences in how equivalent
+// values are written.
+func optionsSchema(description string) *schema.Schema {
+	return &schema.Schema{
+		Type:             schema.TypeMap,
+		Elem:             &schema.Schema{Type: schema.TypeString},
+		Optional:         true,
+		DiffSuppressFunc: suppressEquivalentOption,
+		Description:      description,
+	}
+}
+
+func resourceAutomation() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"automation_id": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+				Description: "the automation's config ID; if not given, one is generated the way the homeassistant UI does",
+			},
+			"config_yaml": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				ConflictsWith:    automationStructured,
+				DiffSuppressFunc: suppressEquivalentAutomationYAML,
+				Description:      "the whole automation as YAML, as an alternative to the structured attributes",
+			},
+			"alias": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"mode": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			"trigger": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{Schema: map[string]*schema.Schema{
+					"platform": {Type: schema.TypeString, Required: true},
+					"id":       {Type: schema.TypeString, Optional: true},
+					"options":  optionsSchema("the trigger's other fields"),
+				}},
+			},
+			"condition": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{Schema: map[string]*schema.Schema{
+					"condition": {Type: schema.TypeString, Required: true},
+					"options":   optionsSchema("the condition's other fields"),
+				}},
+			},
+			"action": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{Schema: map[string]*schema.Schema{
+					"service": {Type: schema.TypeString, Optional: true},
+					"target":  optionsSchema("the service call's target"),
+					"data":    optionsSchema("the service call's data"),
+					"options": optionsSchema("the action's other fields; for actions other than service calls, e.g. `delay`"),
+				}},
+			},
+			"options": func() *schema.Schema {
+				ret := optionsSchema("the automation's other top-level fields, e.g. `variables`, `max`, or " +
+					"`use_blueprint`; if not given, those the automation has are kept")
+				ret.Computed = true
+				return ret
+			}(),
+		},
+		Create: resourceAutomationCreate,
+		Read:   resourceAutomationRead,
+		Update: resourceAutomationUpdate,
+		Delete: resourceAutomationDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+	}
+}
+
+func resourceAutomationCreate(data *schema.ResourceData, i interface{}) error {
+	automation, err := automationFromResource(data)
+	if err != nil {
+		return err
+	}
+	if id, ok := data.GetOk("automation_id"); ok {
+		automation.Id = api.AutomationId(id.(string))
+	}
+
+	if err := saveAutomation(i.(*api.Client), automation); err != nil {
+		return err
+	}
+	data.SetId(string(automation.Id))
+	return resourceAutomationRead(data, i)
+}
+
+func resourceAutomationUpdate(data *schema.ResourceData, i interface{}) error {
+	automation, err := automationFromResource(data)
+	if err != nil {
+		return err
+	}
+	automation.Id = api.AutomationId(data.Id())
+
+	if err := saveAutomation(i.(*api.Client), automation); err != nil {
+		return err
+	}
+	return resourceAutomationRead(data, i)
+}
+
+func saveAutomation(client *api.Client, automation *api.Automation) error {
+	if err := automation.Validate(); err != nil {
+		return fmt.Errorf("automation is not valid: %w", err)
+	}
+	return client.SaveAutomation(automation)
+}
+
+func resourceAutomationRead(data *schema.ResourceData, i interface{}) error {
+	automation, err := i.(*api.Client).GetAutomation(api.AutomationId(data.Id()))
+	if api.IsNotFound(err) {
+		data.SetId("")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("getting automation %q: %w", data.Id(), err)
+	}
+	automation.Id = ""
+
+	data.Set("automation_id", data.Id())
+	if _, ok := data.GetOk("config_yaml"); ok {
+		config, err := api.EncodeYAML(automation)
+		if err != nil {
+			return err
+		}
+		data.Set("config_yaml", string(config))
+		data.Set("options", map[string]interface{}{})
+		return nil
+	}
+
+	values, err := automationValues(automation)
+	if err != nil {
+		return err
+	}
+	for attr, value := range values {
+		if err := data.Set(attr, value); err != nil {
+			return fmt.Errorf("setting %s: %w", attr, err)
+		}
+	}
+	return nil
+}
+
+func resourceAutomationDelete(data *schema.ResourceData, i interface{}) error {
+	err := i.(*api.Client).DeleteAutomation(api.AutomationId(data.Id()))
+	if api.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// automationFromResource returns the automation described by config_yaml or the structured attributes. Its Id is the
+// one from config_yaml, if any.
+func automationFromResource(data *schema.ResourceData) (*api.Automation, error) {
+	ret := &api.Automation{}
+	if config, ok := data.GetOk("config_yaml"); ok {
+		if err := yaml.Unmarshal([]byte(config.(string)), ret); err != nil {
+			return nil, fmt.Errorf("parsing config_yaml: %w", err)
+		}
+		return ret, nil
+	}
+
+	generic := map[string]interface{}{}
+	for key, value := range decodeOptions(data.Get("options")) {
+		if automationModeled[key] {
+			return nil, fmt.Errorf("options: %q has an attribute of its own", key)
+		}
+		generic[key] = value
+	}
+	for _, attr := range []string{"alias", "description", "mode"} {
+		if v, ok := data.GetOk(attr); ok {
+			generic[attr] = v
+		}
+	}
+
+	var triggers []interface{}
+	for _, blockI := range data.Get("trigger").([]interface{}) {
+		block := blockI.(map[string]interface{})
+		trigger := decodeOptions(block["options"])
+		trigger["platform"] = block["platform"]
+		if id := block["id"].(string); id != "" {
+			trigger["id"] = id
+		}
+		triggers = append(triggers, trigger)
+	}
+	if triggers != nil {
+		generic["trigger"] = triggers
+	}
+
+	var conditions []interface{}
+	for _, blockI := range data.Get("condition").([]interface{}) {
+		block := blockI.(map[string]interface{})
+		condition := decodeOptions(block["options"])
+		condition["condition"] = block["condition"]
+		conditions = append(conditions, condition)
+	}
+	if conditions != nil {
+		generic["condition"] = conditions
+	}
+
+	var actions []interface{}
+	for _, blockI := range data.Get("action").([]interface{}) {
+		block := blockI.(map[string]interface{})
+		action := decodeOptions(block["options"])
+		if service := block["service"].(string); service != "" {
+			action["service"] = service
+		}
+		for _, key := range []string{"target", "data"} {
+			if values := decodeOptions(block[key]); len(values) > 0 {
+				action[key] = values
+			}
+		}
+		actions = append(actions, action)
+	}
+	if actions != nil {
+		generic["action"] = actions
+	}
+
+	automationJson, err := json.Marshal(generic)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(automationJson, ret); err != nil {
+		return nil, fmt.Errorf("automation is not valid: %w", err)
+	}
+	return ret, nil
+}
+
+// automationValues returns the structured attributes that describe the automation.
+func automationValues(automation *api.Automation) (map[string]interface{}, error) {
+	normalizeAutomation(automation)
+	automationJson, err := json.Marshal(automation)
+	if err != nil {
+		return nil, err
+	}
+	var generic map[string]interface{}
+	if err := json.Unmarshal(automationJson, &generic); err != nil {
+		return nil, err
+	}
+
+	ret := map[string]interface{}{}
+	for _, attr := range []string{"alias", "description", "mode"} {
+		ret[attr], _ = generic[attr].(string)
+	}
+
+	triggers := []interface{}{}
+	for _, triggerI := range listOf(generic["trigger"]) {
+		trigger, _ := triggerI.(map[string]interface{})
+		block := map[string]interface{}{"platform": trigger["platform"], "id": trigger["id"]}
+		delete(trigger, "platform")
+		delete(trigger, "id")
+		block["options"] = encodeOptions(trigger)
+		triggers = append(triggers, block)
+	}
+	ret["trigger"] = triggers
+
+	conditions := []interface{}{}
+	for _, conditionI := range listOf(generic["condition"]) {
+		condition, ok := conditionI.(map[string]interface{})
+		if !ok {
+			// the shorthand for a template condition is just the template
+			condition = map[string]interface{}{"condition": "template", "value_template": conditionI}
+		}
+		block := map[string]interface{}{"condition": condition["condition"]}
+		delete(condition, "condition")
+		block["options"] = encodeOptions(condition)
+		conditions = append(conditions, block)
+	}
+	ret["condition"] = conditions
+
+	actions := []interface{}{}
+	for _, actionI := range listOf(generic["action"]) {
+		action, _ := actionI.(map[string]interface{})
+		block := map[string]interface{}{"service": action["service"]}
+		delete(action, "service")
+		for _, key := range []string{"target", "data"} {
+			values, ok := action[key].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			block[key] = encodeOptions(values)
+			delete(action, key)
+		}
+		block["options"] = encodeOptions(action)
+		actions = append(actions, block)
+	}
+	ret["action"] = actions
+
+	options := map[string]interface{}{}
+	for key, value := range generic {
+		if !automationModeled[key] {
+			options[key] = value
+		}
+	}
+	ret["options"] = encodeOptions(options)
+
+	return ret, nil
+}
+
+func listOf(v interface{}) []interface{} {
+	if list, ok := v.([]interface{}); ok {
+		return list
+	}
+	if v == nil {
+		return nil
+	}
+	return []interface{}{v}
+}
+
+// normalizeAutomation rewrites the automation the same way regardless of which of homeassistant's spellings it used,
+// and without its ID, so that equivalent automations encode the same.
+func normalizeAutomation(automation *api.Automation) {
+	automation.Id = ""
+	automation.PluralKeys = false
+	for i := range automation.Trigger {
+		automation.Trigger[i].PlatformKey = ""
+	}
+	for _, action := range automation.Action {
+		if service, ok := action.Action.(*api.ServiceAction); ok && service.Service == "" {
+			service.Service, service.Action = service.Action, ""
+		}
+	}
+}
+
+// suppressEquivalentAutomationYAML ignores differences between two config_yaml values that describe the same
+// automation, e.g. in layout or in the spelling of keys that homeassistant has renamed.
+func suppressEquivalentAutomationYAML(_, old, new string, _ *schema.ResourceData) bool {
+	normalized := func(config string) (string, error) {
+		var automation api.Automation
+		if err := yaml.Unmarshal([]byte(config), &automation); err != nil {
+			return "", err
+		}
+		normalizeAutomation(&automation)
+		ret, err := json.Marshal(automation)
+		return string(ret), err
+	}
+
+	oldNormalized, err := normalized(old)
+	if err != nil {
+		return false
+	}
+	newNormalized, err := normalized(new)
+	return err == nil && oldNormalized == newNormalized
+}
+
+// decodeOption returns the value an option stands for. Numbers written as JSON writes them, booleans, and flow-style
+// YAML lists and maps (e.g., `[light.kitchen, light.hall]`) are decoded; anything else, including templates and
+// numbers like `0123`, is a string.
+func decodeOption(option string) interface{} {
+	trimmed := strings.TrimSpace(option)
+	if trimmed == "" || strings.Contains(option, "{{") || strings.Contains(option, "{%") {
+		return option
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(option), &node); err != nil {
+		return option
+	}
+	quoteNonJSONNumbers(&node)
+	normalized, err := yaml.Marshal(&node)
+	if err != nil {
+		return option
+	}
+	value, err := api.DecodeYAML(normalized)
+	if err != nil {
+		return option
+	}
+	switch value.(type) {
+	case nil:
+		return option
+	case map[string]interface{}:
+		if !strings.HasPrefix(trimmed, "{") {
+			return option
+		}
+	case []interface{}:
+		if !strings.HasPrefix(trimmed, "[") {
+			return option
+		}
+	}
+	return value
+}
+
+// jsonNumber matches numbers as JSON writes them.
+var jsonNumber = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+
+// quoteNonJSONNumbers makes strings of the plain scalars that YAML reads as numbers but JSON would not, e.g. `0123`
+// (octal 83 to YAML) or `0x1F`, so that values like PINs keep their digits.
+func quoteNonJSONNumbers(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode && node.Style == 0 && (node.ShortTag() == "!!int" || node.ShortTag() == "!!float") &&
+		!jsonNumber.MatchString(node.Value) {
+		node.Tag = "!!str"
+	}
+	for _, child := range node.Content {
+		quoteNonJSONNumbers(child)
+	}
+}
+
+// encodeOption is the inverse of decodeOption. Strings that decodeOption would not leave as strings, e.g. "20", are
+// quoted.
+func encodeOption(value interface{}) string {
+	if s, ok := value.(string); ok {
+		if decoded, ok := decodeOption(s).(string); ok && decoded == s {
+			return s
+		}
+	}
+	ret, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(ret)
+}
+
+func decodeOptions(optionsI interface{}) map[string]interface{} {
+	ret := map[string]interface{}{}
+	options, _ := optionsI.(map[string]interface{})
+	for k, v := range options {
+		ret[k] = decodeOption(v.(string))
+	}
+	return ret
+}
+
+func encodeOptions(values map[string]interface{}) map[string]interface{} {
+	ret := map[string]interface{}{}
+	for k, v := range values {
+		ret[k] = encodeOption(v)
+	}
+	return ret
+}
+
+// suppressEquivalentOption ignores differences between options that decode to the same value, e.g. `20` and `20.0`.
+func suppressEquivalentOption(k, old, new string, _ *schema.ResourceData) bool {
+	if strings.HasSuffix(k, ".%") {
+		return false
+	}
+	oldJson, err := json.Marshal(decodeOption(old))
+	if err != nil {
+		return false
+	}
+	newJson, err := json.Marshal(decodeOption(new))
+	return err == nil && string(oldJson) == string(newJson)
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAutomations adds handlers for automation config to the fake server, and returns the automations it stores, by
+// ID. Like newer versions of homeassistant, the server returns automations with the newer spellings of their keys.
+func fakeAutomations(ha *fakeHomeAssistant) map[string]map[string]interface{} {
+	automations := map[string]map[string]interface{}{}
+	notFound := api.ResultError{Code: "not_found", Message: "Resource not found"}
+
+	ha.rest["GET /api/config/automation/config/"] = func(request map[string]interface{}) (interface{}, error) {
+		automation, ok := automations[request["_path"].(string)]
+		if !ok {
+			return nil, notFound
+		}
+		var stored map[string]interface{}
+		automationJson, _ := json.Marshal(automation)
+		_ = json.Unmarshal(automationJson, &stored)
+
+		ret := map[string]interface{}{}
+		for k, v := range stored {
+			if k == "trigger" || k == "condition" || k == "action" {
+				k += "s"
+			}
+			ret[k] = v
+		}
+		for _, trigger := range ret["triggers"].([]interface{}) {
+			trigger := trigger.(map[string]interface{})
+			trigger["trigger"] = trigger["platform"]
+			delete(trigger, "platform")
+		}
+		for _, action := range ret["actions"].([]interface{}) {
+			action := action.(map[string]interface{})
+			if service, ok := action["service"]; ok {
+				action["action"] = service
+				delete(action, "service")
+			}
+		}
+		return ret, nil
+	}
+	ha.rest["POST /api/config/automation/config/"] = func(request map[string]interface{}) (interface{}, error) {
+		id := request["_path"].(string)
+		delete(request, "_path")
+		automations[id] = request
+		return map[string]interface{}{"result": "ok"}, nil
+	}
+	ha.rest["DELETE /api/config/automation/config/"] = func(request map[string]interface{}) (interface{}, error) {
+		id := request["_path"].(string)
+		if _, ok := automations[id]; !ok {
+			return nil, notFound
+		}
+		delete(automations, id)
+		return map[string]interface{}{"result": "ok"}, nil
+	}
+
+	return automations
+}
+
+func checkNoAutomations(ha *fakeHomeAssistant, automations map[string]map[string]interface{}) resource.TestCheckFunc {
+	return func(*terraform.State) error {
+		ha.Lock()
+		defer ha.Unlock()
+		if len(automations) != 0 {
+			return fmt.Errorf("expected no automations, but found %d", len(automations))
+		}
+		return nil
+	}
+}
+
+func TestResourceAutomation(t *testing.T) {
+	ha := newFakeHomeAssistant(t)
+	automations := fakeAutomations(ha)
+
+	config := ha.Config(`
+resource "homeassistant_automation" "door" {
+  automation_id = "door_light"
+  alias         = "Door Light"
+
+  trigger {
+    platform = "state"
+    options = {
+      entity_id = "binary_sensor.door"
+      to        = "on"
+      for       = "00:01:00"
+    }
+  }
+
+  condition {
+    condition = "sun"
+    options = {
+      after = "sunset"
+    }
+  }
+
+  action {
+    service = "light.turn_on"
+    target = {
+      entity_id = "[light.hall, light.porch]"
+    }
+    data = {
+      brightness_pct = "50"
+    }
+  }
+
+  action {
+    options = {
+      delay = "00:00:30"
+    }
+  }
+}`)
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers: ha.Providers(),
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("homeassistant_automation.door", "id", "door_light"),
+					resource.TestCheckResourceAttr("homeassistant_automation.door", "trigger.0.platform", "state"),
+					func(*terraform.State) error {
+						ha.Lock()
+						defer ha.Unlock()
+						automation := automations["door_light"]
+						require.Equal(t, "Door Light", automation["alias"])
+						require.Equal(t, map[string]interface{}{
+							"platform":  "state",
+							"entity_id": "binary_sensor.door",
+							"to":        "on",
+							"for":       "00:01:00",
+						}, automation["trigger"].([]interface{})[0])
+						require.Equal(t, []interface{}{
+							map[string]interface{}{
+								"service": "light.turn_on",
+								"target": map[string]interface{}{
+									"entity_id": []interface{}{"light.hall", "light.porch"},
+								},
+								"data": map[string]interface{}{"brightness_pct": float64(50)},
+							},
+							map[string]interface{}{"delay": "00:00:30"},
+						}, automation["action"])
+						return nil
+					},
+				),
+			},
+			{
+				ResourceName:      "homeassistant_automation.door",
+				Config:            config,
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+		CheckDestroy: checkNoAutomations(ha, automations),
+	})
+}
+
+func TestResourceAutomation_Options(t *testing.T) {
+	ha := newFakeHomeAssistant(t)
+	automations := fakeAutomations(ha)
+
+	config := func(alias, options string) string {
+		return ha.Config(fmt.Sprintf(`
+resource "homeassistant_automation" "door" {
+  automation_id = "door_light"
+  alias         = %q
+  mode          = "queued"
+  %s
+
+  trigger {
+    platform = "state"
+    options = {
+      entity_id = "binary_sensor.door"
+    }
+  }
+
+  action {
+    service = "light.turn_on"
+  }
+}`, alias, options))
+	}
+
+	checkOptions := func(*terraform.State) error {
+		ha.Lock()
+		defer ha.Unlock()
+		automation := automations["door_light"]
+		require.Equal(t, float64(5), automation["max"])
+		require.Equal(t, map[string]interface{}{"room": "hall"}, automation["variables"])
+		return nil
+	}
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers: ha.Providers(),
+		Steps: []resource.TestStep{
+			{
+				Config: config("Door Light", `options = {
+    max       = "5"
+    variables = "{room: hall}"
+  }`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("homeassistant_automation.door", "options.max", "5"),
+					checkOptions,
+				),
+			},
+			{
+				// Without options, the ones the automation has are kept.
+				Config: config("Door Light, Again", ""),
+				Check: resource.ComposeTestCheckFunc(
+					func(*terraform.State) error {
+						ha.Lock()
+						defer ha.Unlock()
+						require.Equal(t, "Door Light, Again", automations["door_light"]["alias"])
+						return nil
+					},
+					checkOptions,
+				),
+			},
+			{
+				Config:            config("Door Light, Again", ""),
+				ResourceName:      "homeassistant_automation.door",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				Config:      config("Door Light", `options = { alias = "Other" }`),
+				ExpectError: regexp.MustCompile(`"alias" has an attribute of its own`),
+			},
+		},
+		CheckDestroy: checkNoAutomations(ha, automations),
+	})
+}
+
+func TestResourceAutomation_ConfigYAML(t *testing.T) {
+	ha := newFakeHomeAssistant(t)
+	automations := fakeAutomations(ha)
+
+	config := ha.Config(`
+resource "homeassistant_automation" "door" {
+  config_yaml = <<-EOT
+    alias: Door Light
+    trigger:
+      - platform: state
+        entity_id: binary_sensor.door
+        to: "on"
+    action:
+      - service: light.turn_on
+        target: {entity_id: light.hall}
+  EOT
+}`)
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers: ha.Providers(),
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestMatchResourceAttr("homeassistant_automation.door", "automation_id",
+						regexp.MustCompile(`^[0-9]+$`)),
+					func(*terraform.State) error {
+						ha.Lock()
+						defer ha.Unlock()
+						if len(automations) != 1 {
+							return fmt.Errorf("expected 1 automation, but found %d", len(automations))
+						}
+						return nil
+					},
+				),
+			},
+			{
+				// a change made outside terraform shows up in the plan
+				PreConfig: func() {
+					ha.Lock()
+					defer ha.Unlock()
+					for _, automation := range automations {
+						automation["alias"] = "Front Door Light"
+					}
+				},
+				Config:             config,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+		},
+		CheckDestroy: checkNoAutomations(ha, automations),
+	})
+}
+
+func TestDecodeOption(t *testing.T) {
+	require.Equal(t, "on", decodeOption("on"))
+	require.Equal(t, "00:01:00", decodeOption("00:01:00"))
+	require.Equal(t, float64(20), decodeOption("20"))
+	require.Equal(t, true, decodeOption("true"))
+	require.Equal(t, []interface{}{"light.a", "light.b"}, decodeOption("[light.a, light.b]"))
+	require.Equal(t, "Alert: door open", decodeOption("Alert: door open"))
+	require.Equal(t, "{{ states('sun.sun') }}", decodeOption("{{ states('sun.sun') }}"))
+	require.Equal(t, "0123", decodeOption("0123"))
+	require.Equal(t, "0x1F", decodeOption("0x1F"))
+	require.Equal(t, []interface{}{"0123", float64(1)}, decodeOption("[0123, 1]"))
+	require.Equal(t, -2.5e3, decodeOption("-2.5e3"))
+
+	for _, value := range []interface{}{"on", "20", "0123", float64(20), true, []interface{}{"light.a"}, "Alert: door open"} {
+		require.Equal(t, value, decodeOption(encodeOption(value)))
+	}
+}
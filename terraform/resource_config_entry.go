@@ -0,0 +1,236 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+// maxFlowSteps bounds how many steps a flow may take, in case it keeps asking for the same one.
+const maxFlowSteps = 20
+
+// flowStepSchema is the schema of the inputs to one step of a config or options flow.
+func flowStepSchema() *schema.Resource {
+	return &schema.Resource{Schema: map[string]*schema.Schema{
+		"step_id": {
+			Type:     schema.TypeString,
+			Required: true,
+		},
+		"data": {
+			Type:             schema.TypeMap,
+			Elem:             &schema.Schema{Type: schema.TypeString},
+			Optional:         true,
+			Sensitive:        true,
+			DiffSuppressFunc: suppressEquivalentOption,
+			Description: "the step's inputs; those the step takes as numbers, booleans, or lists are decoded like " +
+				"automation options, and the rest are strings; for a menu, `next_step_id` chooses the next step",
+		},
+	}}
+}
+
+func resourceConfigEntry() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"domain": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "the integration to set up, e.g. `mqtt` or `met`",
+			},
+			"step": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem:     flowStepSchema(),
+				// The config flow only runs when the entry is created, so changes afterward would do nothing.
+				DiffSuppressFunc: func(_, _, _ string, data *schema.ResourceData) bool {
+					return data.Id() != ""
+				},
+				Description: "the inputs to the config flow, by step; only used when the entry is created, so changes " +
+					"afterward are ignored",
+			},
+			"options_step": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem:        flowStepSchema(),
+				Description: "the inputs to the options flow, by step; run after the entry is created and when changed",
+			},
+			"entry_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"title": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"state": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"source": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+		Create: resourceConfigEntryCreate,
+		Read:   resourceConfigEntryRead,
+		Update: func(data *schema.ResourceData, i interface{}) error {
+			if data.HasChange("options_step") {
+				if err := runOptionsFlow(i.(*api.Client), data); err != nil {
+					return err
+				}
+			}
+			return resourceConfigEntryRead(data, i)
+		},
+		Delete: func(data *schema.ResourceData, i interface{}) error {
+			_, err := i.(*api.Client).DeleteEntry(api.EntryId(data.Id()))
+			if api.IsNotFound(err) {
+				return nil
+			}
+			return err
+		},
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+	}
+}
+
+func resourceConfigEntryCreate(data *schema.ResourceData, i interface{}) error {
+	client := i.(*api.Client)
+	domain := data.Get("domain").(string)
+
+	flow, err := client.StartFlow(domain)
+	if err != nil {
+		return fmt.Errorf("starting config flow for %s: %w", domain, err)
+	}
+	flow, err = walkFlow(flow, data.Get("step").([]interface{}), client.SubmitFlow)
+	if err != nil {
+		if flow != nil {
+			_ = client.AbortFlow(flow.FlowId)
+		}
+		return fmt.Errorf("config flow for %s: %w", domain, err)
+	}
+
+	entryId := flow.EntryId()
+	if entryId == "" {
+		return fmt.Errorf("config flow for %s finished, but gave no entry ID: %s", domain, string(flow.Result))
+	}
+	data.SetId(string(entryId))
+
+	if _, ok := data.GetOk("options_step"); ok {
+		if err := runOptionsFlow(client, data); err != nil {
+			return err
+		}
+	}
+	return resourceConfigEntryRead(data, i)
+}
+
+func runOptionsFlow(client *api.Client, data *schema.ResourceData) error {
+	flow, err := client.StartOptionsFlow(api.EntryId(data.Id()))
+	if err != nil {
+		return fmt.Errorf("starting options flow for %s: %w", data.Id(), err)
+	}
+	flow, err = walkFlow(flow, data.Get("options_step").([]interface{}), client.SubmitOptionsFlow)
+	if err != nil {
+		if flow != nil {
+			_ = client.AbortOptionsFlow(flow.FlowId)
+		}
+		return fmt.Errorf("options flow for %s: %w", data.Id(), err)
+	}
+	return nil
+}
+
+// walkFlow answers each step the flow asks for with the inputs the step blocks give for it, until the flow creates an
+// entry. If the flow is aborted, or asks for a step there is no block for, it returns an error; the flow is returned
+// unless it is known to be over, so that the caller can abort it.
+func walkFlow(flow *api.ConfigFlow, steps []interface{},
+	submit func(api.ConfigFlowId, map[string]interface{}) (*api.ConfigFlow, error)) (*api.ConfigFlow, error) {
+	inputs := map[string]map[string]interface{}{}
+	for _, stepI := range steps {
+		step := stepI.(map[string]interface{})
+		inputs[step["step_id"].(string)], _ = step["data"].(map[string]interface{})
+	}
+
+	for n := 0; n < maxFlowSteps; n++ {
+		switch flow.Type {
+		case "create_entry":
+			return flow, nil
+		case "abort":
+			return nil, fmt.Errorf("aborted: %s", flow.Reason)
+		case "form", "menu":
+		default:
+			return flow, fmt.Errorf("step %q is a %q, which is not supported", flow.StepId, flow.Type)
+		}
+
+		input, ok := inputs[flow.StepId]
+		if !ok {
+			var fields []string
+			for _, field := range flow.DataSchema {
+				fields = append(fields, field.Name)
+			}
+			sort.Strings(fields)
+			return flow, fmt.Errorf("flow asked for step %q, which has no step block; its fields are: %s",
+				flow.StepId, strings.Join(fields, ", "))
+		}
+
+		next, err := submit(flow.FlowId, flowInput(flow, input))
+		if err != nil {
+			return flow, fmt.Errorf("step %q: %w", flow.StepId, err)
+		}
+		flow = next
+	}
+	return flow, fmt.Errorf("flow did not finish after %d steps; it is at step %q", maxFlowSteps, flow.StepId)
+}
+
+// flowInput decodes, like automation options, the inputs that the flow's step takes as numbers, booleans, or lists;
+// the rest, e.g. a PIN like `0123`, are passed as the strings they are.
+func flowInput(flow *api.ConfigFlow, data map[string]interface{}) map[string]interface{} {
+	decoded := map[string]bool{}
+	for _, field := range flow.DataSchema {
+		switch field.Type {
+		case "integer", "float", "boolean", "multi_select":
+			decoded[field.Name] = true
+		}
+		for kind, configI := range field.Selector {
+			config, _ := configI.(map[string]interface{})
+			if kind == "number" || kind == "boolean" || config["multiple"] == true {
+				decoded[field.Name] = true
+			}
+		}
+	}
+
+	ret := map[string]interface{}{}
+	for name, value := range data {
+		if decoded[name] {
+			ret[name] = decodeOption(value.(string))
+		} else {
+			ret[name] = value
+		}
+	}
+	return ret
+}
+
+func resourceConfigEntryRead(data *schema.ResourceData, i interface{}) error {
+	entries, err := i.(*api.Client).ListConfigEntries()
+	if err != nil {
+		return fmt.Errorf("listing config entries: %w", err)
+	}
+
+	for _, entry := range entries {
+		if string(entry.EntryId) != data.Id() {
+			continue
+		}
+		return setAll(data, map[string]interface{}{
+			"domain":   entry.Domain,
+			"entry_id": string(entry.EntryId),
+			"title":    entry.Title,
+			"state":    entry.State,
+			"source":   entry.Source,
+		})
+	}
+
+	data.SetId("")
+	return nil
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMQTTFlows adds handlers for a two-step config flow (`user`, then `credentials`) that creates an MQTT entry, and
+// for its one-step options flow. It returns the entries, and the inputs each flow step received. Like homeassistant,
+// the forms describe some fields with a type and others with a selector.
+func fakeMQTTFlows(ha *fakeHomeAssistant) (map[string]*api.ConfigEntry, map[string]map[string]interface{}) {
+	entries := map[string]*api.ConfigEntry{}
+	inputs := map[string]map[string]interface{}{}
+	field := func(name, typ string) map[string]interface{} {
+		return map[string]interface{}{"name": name, "required": true, "type": typ}
+	}
+	selector := func(name, kind string) map[string]interface{} {
+		return map[string]interface{}{"name": name, "required": true,
+			"selector": map[string]interface{}{kind: map[string]interface{}{}}}
+	}
+	form := func(flowId, stepId string, fields ...map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"type": "form", "flow_id": flowId, "handler": "mqtt", "step_id": stepId,
+			"data_schema": fields}
+	}
+
+	ha.rest["POST /api/config/config_entries/flow"] = func(request map[string]interface{}) (interface{}, error) {
+		if request["handler"] != "mqtt" {
+			return nil, api.ResultError{Code: "not_found", Message: "Invalid handler specified"}
+		}
+		return form("flow1", "user", field("broker", "string"), field("port", "integer")), nil
+	}
+	ha.rest["POST /api/config/config_entries/flow/"] = func(request map[string]interface{}) (interface{}, error) {
+		flowId := request["_path"].(string)
+		delete(request, "_path")
+		if _, ok := request["broker"]; ok {
+			inputs["user"] = request
+			return form(flowId, "credentials", field("username", "string"), selector("password", "text")), nil
+		}
+		inputs["credentials"] = request
+		entries["entry1"] = &api.ConfigEntry{EntryId: "entry1", Domain: "mqtt", Title: "broker.local",
+			State: "loaded", Source: "user"}
+		return map[string]interface{}{"type": "create_entry", "flow_id": flowId, "handler": "mqtt",
+			"result": entries["entry1"]}, nil
+	}
+	ha.rest["DELETE /api/config/config_entries/flow/"] = func(map[string]interface{}) (interface{}, error) {
+		return nil, nil
+	}
+	ha.rest["POST /api/config/config_entries/options/flow"] = func(request map[string]interface{}) (interface{}, error) {
+		return form("options1", "init", selector("discovery", "boolean")), nil
+	}
+	ha.rest["POST /api/config/config_entries/options/flow/"] = func(request map[string]interface{}) (interface{}, error) {
+		delete(request, "_path")
+		inputs["init"] = request
+		return map[string]interface{}{"type": "create_entry", "flow_id": "options1", "result": true}, nil
+	}
+	ha.rest["GET /api/config/config_entries/entry"] = func(map[string]interface{}) (interface{}, error) {
+		ret := []*api.ConfigEntry{}
+		for _, entry := range entries {
+			ret = append(ret, entry)
+		}
+		return ret, nil
+	}
+	ha.rest["DELETE /api/config/config_entries/entry/"] = func(request map[string]interface{}) (interface{}, error) {
+		id := request["_path"].(string)
+		if _, ok := entries[id]; !ok {
+			return nil, api.ResultError{Code: "not_found", Message: "Entry not found"}
+		}
+		delete(entries, id)
+		return map[string]interface{}{"require_restart": false}, nil
+	}
+
+	return entries, inputs
+}
+
+func TestResourceConfigEntry(t *testing.T) {
+	ha := newFakeHomeAssistant(t)
+	entries, inputs := fakeMQTTFlows(ha)
+
+	config := func(broker string, discovery bool) string {
+		return ha.Config(fmt.Sprintf(`
+resource "homeassistant_config_entry" "mqtt" {
+  domain = "mqtt"
+
+  step {
+    step_id = "user"
+    data = {
+      broker = %q
+      port   = "1883"
+    }
+  }
+
+  step {
+    step_id = "credentials"
+    data = {
+      username = "homeassistant"
+      password = "1234"
+    }
+  }
+
+  options_step {
+    step_id = "init"
+    data = {
+      discovery = "%t"
+    }
+  }
+}`, broker, discovery))
+	}
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers: ha.Providers(),
+		Steps: []resource.TestStep{
+			{
+				Config: config("broker.local", true),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("homeassistant_config_entry.mqtt", "id", "entry1"),
+					resource.TestCheckResourceAttr("homeassistant_config_entry.mqtt", "title", "broker.local"),
+					func(*terraform.State) error {
+						ha.Lock()
+						defer ha.Unlock()
+						require.Equal(t, map[string]interface{}{"broker": "broker.local", "port": float64(1883)},
+							inputs["user"])
+						require.Equal(t, "1234", inputs["credentials"]["password"])
+						require.Equal(t, true, inputs["init"]["discovery"])
+						return nil
+					},
+				),
+			},
+			{
+				Config: config("broker.local", false),
+				Check: func(*terraform.State) error {
+					ha.Lock()
+					defer ha.Unlock()
+					require.Equal(t, false, inputs["init"]["discovery"])
+					return nil
+				},
+			},
+			{
+				// The config flow does not run again, so changes to its steps plan nothing.
+				Config:   config("broker.lan", false),
+				PlanOnly: true,
+			},
+			{
+				Config:                  config("broker.lan", false),
+				ResourceName:            "homeassistant_config_entry.mqtt",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"step", "options_step"},
+			},
+		},
+		CheckDestroy: func(*terraform.State) error {
+			ha.Lock()
+			defer ha.Unlock()
+			if len(entries) != 0 {
+				return fmt.Errorf("entries were not deleted: %v", entries)
+			}
+			return nil
+		},
+	})
+}
+
+func TestResourceConfigEntry_MissingStep(t *testing.T) {
+	ha := newFakeHomeAssistant(t)
+	fakeMQTTFlows(ha)
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers: ha.Providers(),
+		Steps: []resource.TestStep{
+			{
+				Config: ha.Config(`
+resource "homeassistant_config_entry" "mqtt" {
+  domain = "mqtt"
+
+  step {
+    step_id = "user"
+    data = {
+      broker = "broker.local"
+      port   = "1883"
+    }
+  }
+}`),
+				ExpectError: regexp.MustCompile(`step "credentials", which has no step block; its fields are: password, ` +
+					`username`),
+			},
+		},
+	})
+	require.Equal(t, 1, ha.Count("DELETE /api/config/config_entries/flow/flow1"))
+}
@@ -0,0 +1,408 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+// entityManaged are the attributes of homeassistant_entity that set registry fields, and that are restored from
+// `original` on destroy.
+var entityManaged = []string{"name", "icon", "area_id", "device_class", "unit_of_measurement", "disabled", "hidden",
+	"labels"}
+
+// entityOriginalSchema describes the registry fields of an entity as they were when it was adopted.
+func entityOriginalSchema() map[string]*schema.Schema {
+	ret := map[string]*schema.Schema{
+		"entity_id": {Type: schema.TypeString, Computed: true},
+		"disabled":  {Type: schema.TypeBool, Computed: true},
+		"hidden":    {Type: schema.TypeBool, Computed: true},
+		"labels":    {Type: schema.TypeSet, Elem: &schema.Schema{Type: schema.TypeString}, Computed: true},
+	}
+	// disabled_by and hidden_by say who disabled or hid the entity (e.g., `integration`), so that disabling or hiding
+	// it again can say the same
+	for _, attr := range []string{"name", "icon", "area_id", "device_class", "unit_of_measurement", "disabled_by",
+		"hidden_by"} {
+		ret[attr] = &schema.Schema{Type: schema.TypeString, Computed: true}
+	}
+	return ret
+}
+
+// entityMatchSchema is the schema of homeassistant_entity's `match` block, which finds the entity to adopt the same
+// way the homeassistant_entity data source does.
+func entityMatchSchema() map[string]*schema.Schema {
+	ret := entityFilter()
+	for _, s := range ret {
+		s.Computed = false
+		s.ForceNew = true
+	}
+	return ret
+}
+
+func resourceEntity() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"entity_id": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ExactlyOneOf: []string{"entity_id", "match"},
+				Description:  "the ID of the entity to manage, as it was when adopted",
+			},
+			"match": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				ForceNew:    true,
+				MaxItems:    1,
+				Elem:        &schema.Resource{Schema: entityMatchSchema()},
+				Description: "find the entity to manage by its registry fields; exactly one entity must match",
+			},
+			"new_entity_id": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "change the entity's ID to this",
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			"icon": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			"area_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			"device_class": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "override the device class the integration provides",
+			},
+			"unit_of_measurement": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "the unit the entity's state is shown in, for sensors and numbers that allow it",
+			},
+			"disabled": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Computed: true,
+			},
+			"hidden": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Computed: true,
+			},
+			"labels": {
+				Type:     schema.TypeSet,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Optional: true,
+				Computed: true,
+			},
+			"current_entity_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"platform": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"device_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"unique_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"original": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Resource{Schema: entityOriginalSchema()},
+				Description: "the managed fields as they were when the entity was adopted; restored on destroy",
+			},
+		},
+		Create: resourceEntityCreate,
+		Read:   resourceEntityRead,
+		Update: resourceEntityUpdate,
+		Delete: resourceEntityDelete,
+		Importer: &schema.ResourceImporter{
+			State: func(data *schema.ResourceData, i interface{}) ([]*schema.ResourceData, error) {
+				entity, err := i.(*api.Client).GetEntity(data.Id())
+				if err != nil {
+					return nil, fmt.Errorf("importing entity %q: %w", data.Id(), err)
+				}
+				adoptEntity(data, entity)
+				return []*schema.ResourceData{data}, nil
+			},
+		},
+	}
+}
+
+// adoptEntity sets the resource's ID and records the entity's managed fields as they are now, to be restored on
+// destroy.
+func adoptEntity(data *schema.ResourceData, entity *api.Entity) {
+	if entity.Id != "" {
+		data.SetId(entity.Id)
+	} else {
+		data.SetId(entity.EntityId)
+	}
+	data.Set("entity_id", entity.EntityId)
+	original := entityValues(entity)
+	original["disabled_by"] = entity.DisabledBy
+	original["hidden_by"] = entity.HiddenBy
+	data.Set("original", []interface{}{original})
+}
+
+func resourceEntityCreate(data *schema.ResourceData, i interface{}) error {
+	client := i.(*api.Client)
+
+	entityId := data.Get("entity_id").(string)
+	if entityId == "" {
+		match := data.Get("match.0").(map[string]interface{})
+		entity, err := matchEntity(client, func(attr string) interface{} {
+			return match[attr]
+		})
+		if err != nil {
+			return fmt.Errorf("match: %w", err)
+		}
+		entityId = entity.EntityId
+	}
+
+	entity, err := client.GetEntity(entityId)
+	if err != nil {
+		return fmt.Errorf("getting entity %q: %w", entityId, err)
+	}
+	adoptEntity(data, entity)
+
+	want := map[string]interface{}{}
+	for _, attr := range entityManaged {
+		//nolint:staticcheck // GetOk cannot tell false from unset
+		if v, ok := data.GetOkExists(attr); ok {
+			want[attr] = v
+		}
+	}
+	if newId := data.Get("new_entity_id").(string); newId != "" {
+		want["entity_id"] = newId
+	}
+
+	if err := updateEntity(client, data, entity, want); err != nil {
+		return err
+	}
+	return resourceEntityRead(data, i)
+}
+
+// findEntity returns the registry entry for the resource's entity, or nil if it no longer exists.
+func findEntity(client *api.Client, data *schema.ResourceData) (*api.Entity, error) {
+	entities, err := client.ListEntities()
+	if err != nil {
+		return nil, fmt.Errorf("listing entities: %w", err)
+	}
+	for _, entity := range entities {
+		if entity.Id == data.Id() || entity.Id == "" && entity.EntityId == data.Id() {
+			// the list leaves out some fields, e.g. device_class
+			return client.GetEntity(entity.EntityId)
+		}
+	}
+	return nil, nil
+}
+
+func resourceEntityRead(data *schema.ResourceData, i interface{}) error {
+	entity, err := findEntity(i.(*api.Client), data)
+	if err != nil {
+		return err
+	}
+	if entity == nil {
+		data.SetId("")
+		return nil
+	}
+
+	for attr, value := range entityValues(entity) {
+		if attr == "entity_id" {
+			continue
+		}
+		if err := data.Set(attr, value); err != nil {
+			return fmt.Errorf("setting %s: %w", attr, err)
+		}
+	}
+	data.Set("current_entity_id", entity.EntityId)
+	if data.Get("new_entity_id").(string) != "" {
+		data.Set("new_entity_id", entity.EntityId)
+	}
+	data.Set("platform", entity.Platform)
+	data.Set("device_id", entity.DeviceId)
+	data.Set("unique_id", entity.UniqueId)
+	return nil
+}
+
+func resourceEntityUpdate(data *schema.ResourceData, i interface{}) error {
+	client := i.(*api.Client)
+	entity, err := client.GetEntity(data.Get("current_entity_id").(string))
+	if err != nil {
+		return err
+	}
+
+	want := map[string]interface{}{}
+	for _, attr := range entityManaged {
+		if data.HasChange(attr) {
+			want[attr] = data.Get(attr)
+		}
+	}
+	if data.HasChange("new_entity_id") {
+		// without a new ID, the entity goes back to the one it had
+		want["entity_id"] = data.Get("new_entity_id").(string)
+		if want["entity_id"] == "" {
+			want["entity_id"] = data.Get("entity_id").(string)
+		}
+	}
+
+	if err := updateEntity(client, data, entity, want); err != nil {
+		return err
+	}
+	return resourceEntityRead(data, i)
+}
+
+func resourceEntityDelete(data *schema.ResourceData, i interface{}) error {
+	client := i.(*api.Client)
+	entity, err := findEntity(client, data)
+	if err != nil {
+		return err
+	}
+	if entity == nil {
+		return nil
+	}
+
+	original, ok := data.Get("original.0").(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	return updateEntity(client, data, entity, original)
+}
+
+// entityValues returns the entity's managed fields, and its ID, as attributes.
+func entityValues(entity *api.Entity) map[string]interface{} {
+	unit, _ := entity.Options[entityDomain(entity.EntityId)]["unit_of_measurement"].(string)
+	labels := entity.Labels
+	if labels == nil {
+		labels = []string{}
+	}
+	return map[string]interface{}{
+		"entity_id":           entity.EntityId,
+		"name":                entity.Name,
+		"icon":                entity.Icon,
+		"area_id":             entity.AreaId,
+		"device_class":        entity.DeviceClass,
+		"unit_of_measurement": unit,
+		"disabled":            entity.DisabledBy != "",
+		"hidden":              entity.HiddenBy != "",
+		"labels":              labels,
+	}
+}
+
+func entityDomain(entityId string) string {
+	return strings.SplitN(entityId, ".", 2)[0]
+}
+
+// updateEntity changes those of the entity's fields given in want that differ from their current values. Values are
+// as for entityValues, except that labels may be a *schema.Set. If the entity's ID changes and the resource is
+// identified by it, the resource's ID changes to match.
+func updateEntity(client *api.Client, data *schema.ResourceData, entity *api.Entity, want map[string]interface{}) error {
+	current := entityValues(entity)
+	update := api.EntityUpdate{EntityId: entity.EntityId}
+	changed := false
+
+	str := func(attr string) *string {
+		v, ok := want[attr].(string)
+		if !ok || v == current[attr] {
+			return nil
+		}
+		changed = true
+		return &v
+	}
+	update.Name = str("name")
+	update.Icon = str("icon")
+	update.AreaId = str("area_id")
+	update.DeviceClass = str("device_class")
+	if id := str("entity_id"); id != nil {
+		update.NewEntityId = *id
+	}
+
+	flag := func(attr string) *string {
+		v, ok := want[attr].(bool)
+		if !ok || v == current[attr] {
+			return nil
+		}
+		changed = true
+		by := ""
+		if v {
+			// the entity goes back to however it was disabled or hidden when adopted, if it was
+			by, _ = data.Get("original.0." + attr + "_by").(string)
+			if by == "" {
+				by = "user"
+			}
+		}
+		return &by
+	}
+	update.DisabledBy = flag("disabled")
+	update.HiddenBy = flag("hidden")
+
+	if labelsI, ok := want["labels"]; ok {
+		var labels []string
+		switch l := labelsI.(type) {
+		case *schema.Set:
+			for _, label := range l.List() {
+				labels = append(labels, label.(string))
+			}
+		case []interface{}:
+			for _, label := range l {
+				labels = append(labels, label.(string))
+			}
+		case []string:
+			labels = l
+		}
+		sort.Strings(labels)
+		have := append([]string(nil), entity.Labels...)
+		sort.Strings(have)
+		if strings.Join(labels, "\x00") != strings.Join(have, "\x00") {
+			changed = true
+			update.Labels = &labels
+		}
+	}
+
+	if unit := str("unit_of_measurement"); unit != nil {
+		domain := entityDomain(entity.EntityId)
+		update.OptionsDomain = domain
+		update.Options = map[string]interface{}{}
+		for k, v := range entity.Options[domain] {
+			update.Options[k] = v
+		}
+		if *unit == "" {
+			delete(update.Options, "unit_of_measurement")
+		} else {
+			update.Options["unit_of_measurement"] = *unit
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	updated, err := client.UpdateEntity(update)
+	if err != nil {
+		return err
+	}
+	if updated.Id == "" {
+		data.SetId(updated.EntityId)
+	}
+	return nil
+}
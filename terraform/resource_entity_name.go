@@ -16,7 +16,6 @@ func resourceEntityName() *schema.Resource {
 			"name": {
 				Type:     schema.TypeString,
 				Required: true,
-				ForceNew: true,
 			},
 		},
 		Create: func(data *schema.ResourceData, i interface{}) error {
@@ -36,7 +35,12 @@ func resourceEntityName() *schema.Resource {
 		},
 		Read: func(data *schema.ResourceData, i interface{}) error {
 			client := i.(*api.Client)
-			entity, err := client.GetEntity(data.Get("entity_id").(string))
+			entity, err := client.GetEntity(data.Id())
+			if api.IsNotFound(err) {
+				// the entity is gone, and its name with it
+				data.SetId("")
+				return nil
+			}
 			if err != nil {
 				return err
 			}
@@ -45,14 +49,25 @@ func resourceEntityName() *schema.Resource {
 			data.Set("name", entity.Name)
 			return nil
 		},
+		Update: func(data *schema.ResourceData, i interface{}) error {
+			client := i.(*api.Client)
+			return client.SetEntityName(data.Id(), data.Get("name").(string))
+		},
 		Delete: func(data *schema.ResourceData, i interface{}) error {
 			client := i.(*api.Client)
-			entity, err := client.GetEntity(data.Get("entity_id").(string))
+			entity, err := client.GetEntity(data.Id())
+			if api.IsNotFound(err) {
+				return nil
+			}
 			if err != nil {
 				return err
 			}
 
 			return client.SetEntityName(entity.EntityId, "")
 		},
+		// entity names are imported by entity ID, which is also the resource ID
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
 	}
 }
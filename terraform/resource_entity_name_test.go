@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+)
+
+// checkEntityName checks that the fake server's entity has the given name.
+func checkEntityName(ha *fakeHomeAssistant, entityId, name string) resource.TestCheckFunc {
+	return func(*terraform.State) error {
+		ha.Lock()
+		defer ha.Unlock()
+		if actual := ha.Entities[entityId].Name; actual != name {
+			return fmt.Errorf("expected %s to be named %q, but it is named %q", entityId, name, actual)
+		}
+		return nil
+	}
+}
+
+// These run against the fake server, so unlike most acceptance tests they are safe to run without TF_ACC.
+func TestResourceEntityName(t *testing.T) {
+	ha := newFakeHomeAssistant(t)
+	ha.Entities["light.kitchen"] = &api.Entity{EntityId: "light.kitchen", Platform: "hue"}
+
+	renamed := ha.Config(`
+resource "homeassistant_entity_name" "kitchen" {
+  entity_id = "light.kitchen"
+  name      = "Kitchen Ceiling"
+}`)
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers: ha.Providers(),
+		Steps: []resource.TestStep{
+			{
+				Config: ha.Config(`
+resource "homeassistant_entity_name" "kitchen" {
+  entity_id = "light.kitchen"
+  name      = "Kitchen"
+}`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("homeassistant_entity_name.kitchen", "id", "light.kitchen"),
+					checkEntityName(ha, "light.kitchen", "Kitchen"),
+				),
+			},
+			{
+				Config: renamed,
+				Check: resource.ComposeTestCheckFunc(
+					checkEntityName(ha, "light.kitchen", "Kitchen Ceiling"),
+					func(*terraform.State) error {
+						// a rename would have cleared the name before setting it again
+						if n := ha.Count("config/entity_registry/update"); n != 2 {
+							return fmt.Errorf("expected 2 updates, one to create and one to rename, but saw %d", n)
+						}
+						return nil
+					},
+				),
+			},
+			{
+				Config:            renamed,
+				ResourceName:      "homeassistant_entity_name.kitchen",
+				ImportState:       true,
+				ImportStateId:     "light.kitchen",
+				ImportStateVerify: true,
+			},
+		},
+		CheckDestroy: checkEntityName(ha, "light.kitchen", ""),
+	})
+}
+
+func TestResourceEntityName_EntityGone(t *testing.T) {
+	ha := newFakeHomeAssistant(t)
+	ha.Entities["switch.fan"] = &api.Entity{EntityId: "switch.fan"}
+
+	config := ha.Config(`
+resource "homeassistant_entity_name" "fan" {
+  entity_id = "switch.fan"
+  name      = "Fan"
+}`)
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers: ha.Providers(),
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check:  checkEntityName(ha, "switch.fan", "Fan"),
+			},
+			{
+				PreConfig: func() {
+					ha.Lock()
+					defer ha.Unlock()
+					delete(ha.Entities, "switch.fan")
+				},
+				Config:             config,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
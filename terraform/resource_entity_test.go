@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+)
+
+// checkEntity checks that the fake server has an entity with the given ID, and that check approves of it.
+func checkEntity(ha *fakeHomeAssistant, entityId string, check func(*api.Entity) error) resource.TestCheckFunc {
+	return func(*terraform.State) error {
+		ha.Lock()
+		defer ha.Unlock()
+		entity, ok := ha.Entities[entityId]
+		if !ok {
+			return fmt.Errorf("no entity %s", entityId)
+		}
+		if err := check(entity); err != nil {
+			return fmt.Errorf("%s: %w", entityId, err)
+		}
+		return nil
+	}
+}
+
+func TestResourceEntity(t *testing.T) {
+	ha := newFakeHomeAssistant(t)
+	ha.Entities["sensor.kitchen"] = &api.Entity{
+		Id:       "0123abcd",
+		EntityId: "sensor.kitchen",
+		Platform: "zha",
+		Labels:   []string{"climate"},
+		Options:  map[string]map[string]interface{}{"sensor": {"unit_of_measurement": "°C", "display_precision": 1.0}},
+	}
+
+	restored := ha.Config(`
+resource "homeassistant_entity" "kitchen" {
+  entity_id = "sensor.kitchen"
+  name      = "Kitchen Temperature"
+}`)
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers: ha.Providers(),
+		Steps: []resource.TestStep{
+			{
+				Config: ha.Config(`
+resource "homeassistant_entity" "kitchen" {
+  entity_id           = "sensor.kitchen"
+  new_entity_id       = "sensor.kitchen_temperature"
+  name                = "Kitchen Temperature"
+  unit_of_measurement = "°F"
+  hidden              = true
+  labels              = ["climate", "kitchen"]
+}`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("homeassistant_entity.kitchen", "id", "0123abcd"),
+					resource.TestCheckResourceAttr("homeassistant_entity.kitchen", "current_entity_id",
+						"sensor.kitchen_temperature"),
+					resource.TestCheckResourceAttr("homeassistant_entity.kitchen", "platform", "zha"),
+					resource.TestCheckResourceAttr("homeassistant_entity.kitchen", "original.0.unit_of_measurement",
+						"°C"),
+					checkEntity(ha, "sensor.kitchen_temperature", func(entity *api.Entity) error {
+						switch {
+						case entity.Name != "Kitchen Temperature":
+							return fmt.Errorf("name is %q", entity.Name)
+						case entity.HiddenBy != "user":
+							return fmt.Errorf("hidden_by is %q", entity.HiddenBy)
+						case strings.Join(entity.Labels, ",") != "climate,kitchen":
+							return fmt.Errorf("labels are %v", entity.Labels)
+						case entity.Options["sensor"]["unit_of_measurement"] != "°F":
+							return fmt.Errorf("options are %v", entity.Options)
+						case entity.Options["sensor"]["display_precision"] != 1.0:
+							return fmt.Errorf("other options were lost: %v", entity.Options)
+						}
+						return nil
+					}),
+				),
+			},
+			{
+				// dropping new_entity_id puts the old ID back; the others are only computed now, so stay as they are
+				Config: restored,
+				Check: checkEntity(ha, "sensor.kitchen", func(entity *api.Entity) error {
+					if entity.HiddenBy != "user" {
+						return fmt.Errorf("hidden_by is %q", entity.HiddenBy)
+					}
+					return nil
+				}),
+			},
+			{
+				Config:                  restored,
+				ResourceName:            "homeassistant_entity.kitchen",
+				ImportState:             true,
+				ImportStateId:           "sensor.kitchen",
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"original", "new_entity_id"},
+			},
+		},
+		CheckDestroy: checkEntity(ha, "sensor.kitchen", func(entity *api.Entity) error {
+			switch {
+			case entity.Name != "" || entity.HiddenBy != "":
+				return fmt.Errorf("name %q and hidden_by %q were not restored", entity.Name, entity.HiddenBy)
+			case strings.Join(entity.Labels, ",") != "climate":
+				return fmt.Errorf("labels %v were not restored", entity.Labels)
+			case entity.Options["sensor"]["unit_of_measurement"] != "°C":
+				return fmt.Errorf("options %v were not restored", entity.Options)
+			}
+			return nil
+		}),
+	})
+}
+
+func TestResourceEntity_Match(t *testing.T) {
+	ha := newFakeHomeAssistant(t)
+	ha.Entities["light.hall"] = &api.Entity{Id: "1", EntityId: "light.hall", Platform: "hue"}
+	ha.Entities["light.kitchen"] = &api.Entity{Id: "2", EntityId: "light.kitchen", Platform: "hue"}
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers: ha.Providers(),
+		Steps: []resource.TestStep{
+			{
+				Config: ha.Config(`
+resource "homeassistant_entity" "hue" {
+  match {
+    platform = "hue"
+  }
+  name = "Hue"
+}`),
+				ExpectError: regexp.MustCompile(`exactly one entity must match, but 2 did: light.hall, light.kitchen`),
+			},
+			{
+				Config: ha.Config(`
+resource "homeassistant_entity" "hall" {
+  match {
+    platform         = "hue"
+    entity_id_prefix = "light.h"
+  }
+  name = "Hall"
+}`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("homeassistant_entity.hall", "id", "1"),
+					resource.TestCheckResourceAttr("homeassistant_entity.hall", "entity_id", "light.hall"),
+					checkEntityName(ha, "light.hall", "Hall"),
+				),
+			},
+		},
+		CheckDestroy: checkEntityName(ha, "light.hall", ""),
+	})
+}
+
+func TestResourceEntity_DisabledBy(t *testing.T) {
+	ha := newFakeHomeAssistant(t)
+	ha.Entities["sensor.signal"] = &api.Entity{Id: "1", EntityId: "sensor.signal", Platform: "zha",
+		DisabledBy: "integration"}
+
+	disabledBy := func(want string) resource.TestCheckFunc {
+		return checkEntity(ha, "sensor.signal", func(entity *api.Entity) error {
+			if entity.DisabledBy != want {
+				return fmt.Errorf("disabled_by is %q, not %q", entity.DisabledBy, want)
+			}
+			return nil
+		})
+	}
+	config := func(disabled bool) string {
+		return ha.Config(fmt.Sprintf(`
+resource "homeassistant_entity" "signal" {
+  entity_id = "sensor.signal"
+  disabled  = %t
+}`, disabled))
+	}
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers: ha.Providers(),
+		Steps: []resource.TestStep{
+			{
+				Config: config(false),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("homeassistant_entity.signal", "original.0.disabled_by",
+						"integration"),
+					disabledBy(""),
+				),
+			},
+			{
+				// disabling it again puts back who disabled it, rather than claiming the user did
+				Config: config(true),
+				Check:  disabledBy("integration"),
+			},
+			{
+				Config: config(false),
+				Check:  disabledBy(""),
+			},
+		},
+		CheckDestroy: disabledBy("integration"),
+	})
+}
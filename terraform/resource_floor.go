@@ -0,0 +1,105 @@
+package main
+
+import (
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func floorSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"floor_id": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"name": {
+			Type:     schema.TypeString,
+			Required: true,
+		},
+		"aliases": {
+			Type:        schema.TypeSet,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Optional:    true,
+			Description: "other names for the floor, e.g. for voice assistants",
+		},
+		"icon": {
+			Type:     schema.TypeString,
+			Optional: true,
+		},
+		"level": {
+			Type:        schema.TypeInt,
+			Optional:    true,
+			Description: "orders the floors; 0 is the ground floor, and basements are negative",
+		},
+	}
+}
+
+func resourceFloor() *schema.Resource {
+	return &schema.Resource{
+		Schema: floorSchema(),
+		Create: func(data *schema.ResourceData, i interface{}) error {
+			floor, err := i.(*api.Client).CreateFloor(floorFromResource(data))
+			if err != nil {
+				return err
+			}
+			data.SetId(floor.FloorId)
+			return setFloor(data, floor)
+		},
+		Read: func(data *schema.ResourceData, i interface{}) error {
+			floor, err := i.(*api.Client).GetFloor(data.Id())
+			if api.IsNotFound(err) {
+				data.SetId("")
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			return setFloor(data, floor)
+		},
+		Update: func(data *schema.ResourceData, i interface{}) error {
+			floor, err := i.(*api.Client).UpdateFloor(floorFromResource(data))
+			if err != nil {
+				return err
+			}
+			return setFloor(data, floor)
+		},
+		Delete: func(data *schema.ResourceData, i interface{}) error {
+			err := i.(*api.Client).DeleteFloor(data.Id())
+			if api.IsNotFound(err) {
+				return nil
+			}
+			return err
+		},
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+	}
+}
+
+func floorFromResource(data *schema.ResourceData) api.Floor {
+	ret := api.Floor{
+		FloorId: data.Id(),
+		Name:    data.Get("name").(string),
+		Aliases: stringSet(data.Get("aliases")),
+		Icon:    data.Get("icon").(string),
+	}
+	//nolint:staticcheck // GetOk cannot tell 0 from unset
+	if level, ok := data.GetOkExists("level"); ok {
+		level := level.(int)
+		ret.Level = &level
+	}
+	return ret
+}
+
+func setFloor(data *schema.ResourceData, floor *api.Floor) error {
+	values := map[string]interface{}{
+		"floor_id": floor.FloorId,
+		"name":     floor.Name,
+		"aliases":  floor.Aliases,
+		"icon":     floor.Icon,
+		"level":    nil,
+	}
+	if floor.Level != nil {
+		values["level"] = *floor.Level
+	}
+	return setAll(data, values)
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+// resourceHelper returns a resource for helpers in the given domain, whose configuration, other than name and icon, is
+// given by fields. Each field is sent to homeassistant under its own name. Fields are optional and computed unless
+// the schema says otherwise, so that homeassistant's defaults do not show up as changes.
+func resourceHelper(domain string, fields map[string]*schema.Schema) *schema.Resource {
+	for _, s := range fields {
+		if !s.Required && s.Default == nil {
+			s.Optional = true
+			s.Computed = true
+		}
+	}
+	fields["name"] = &schema.Schema{Type: schema.TypeString, Required: true}
+	fields["icon"] = &schema.Schema{Type: schema.TypeString, Optional: true}
+
+	helperSchema := map[string]*schema.Schema{
+		"entity_id": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "the helper's entity ID, as homeassistant first assigns it",
+		},
+	}
+	for attr, s := range fields {
+		helperSchema[attr] = s
+	}
+
+	return &schema.Resource{
+		Schema: helperSchema,
+		Create: func(data *schema.ResourceData, i interface{}) error {
+			helper, err := i.(*api.Client).CreateHelper(domain, helperFields(data, fields))
+			if err != nil {
+				return err
+			}
+			data.SetId(helper.Id())
+			return setHelper(data, domain, fields, helper)
+		},
+		Read: func(data *schema.ResourceData, i interface{}) error {
+			helper, err := i.(*api.Client).GetHelper(domain, data.Id())
+			if api.IsNotFound(err) {
+				data.SetId("")
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			return setHelper(data, domain, fields, helper)
+		},
+		Update: func(data *schema.ResourceData, i interface{}) error {
+			helper, err := i.(*api.Client).UpdateHelper(domain, data.Id(), helperFields(data, fields))
+			if err != nil {
+				return err
+			}
+			return setHelper(data, domain, fields, helper)
+		},
+		Delete: func(data *schema.ResourceData, i interface{}) error {
+			err := i.(*api.Client).DeleteHelper(domain, data.Id())
+			if api.IsNotFound(err) {
+				return nil
+			}
+			return err
+		},
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+	}
+}
+
+// helperFields returns the configuration to send to homeassistant: those fields that are set.
+func helperFields(data *schema.ResourceData, fields map[string]*schema.Schema) map[string]interface{} {
+	ret := map[string]interface{}{}
+	for attr, s := range fields {
+		if s.Type == schema.TypeList {
+			if v, ok := data.GetOk(attr); ok {
+				ret[attr] = v
+			}
+			continue
+		}
+		//nolint:staticcheck // GetOk cannot tell false or 0 from unset
+		if v, ok := data.GetOkExists(attr); ok {
+			ret[attr] = v
+		}
+	}
+	return ret
+}
+
+func setHelper(data *schema.ResourceData, domain string, fields map[string]*schema.Schema, helper api.Helper) error {
+	data.Set("entity_id", helper.EntityId(domain))
+	for attr, s := range fields {
+		value := helper[attr]
+		switch v := value.(type) {
+		case float64:
+			if s.Type == schema.TypeInt {
+				value = int(v)
+			}
+		case nil, string, bool, []interface{}:
+		default:
+			value = fmt.Sprint(v)
+		}
+		if err := data.Set(attr, value); err != nil {
+			return fmt.Errorf("setting %s: %w", attr, err)
+		}
+	}
+	return nil
+}
+
+// suppressEquivalentDuration ignores differences between durations of the same length, e.g. `00:01:00` and `0:01:00`.
+func suppressEquivalentDuration(_, old, new string, _ *schema.ResourceData) bool {
+	var oldDuration, newDuration api.Duration
+	oldJson, _ := json.Marshal(old)
+	newJson, _ := json.Marshal(new)
+	if json.Unmarshal(oldJson, &oldDuration) != nil || json.Unmarshal(newJson, &newDuration) != nil {
+		return false
+	}
+	return oldDuration.Duration == newDuration.Duration
+}
+
+func resourceInputBoolean() *schema.Resource {
+	return resourceHelper("input_boolean", map[string]*schema.Schema{
+		"initial": {Type: schema.TypeBool, Description: "the state after homeassistant starts; by default, it is restored"},
+	})
+}
+
+func resourceInputNumber() *schema.Resource {
+	return resourceHelper("input_number", map[string]*schema.Schema{
+		"min":                 {Type: schema.TypeFloat, Required: true},
+		"max":                 {Type: schema.TypeFloat, Required: true},
+		"step":                {Type: schema.TypeFloat},
+		"initial":             {Type: schema.TypeFloat},
+		"mode":                {Type: schema.TypeString, Description: "`slider` or `box`"},
+		"unit_of_measurement": {Type: schema.TypeString},
+	})
+}
+
+func resourceInputSelect() *schema.Resource {
+	return resourceHelper("input_select", map[string]*schema.Schema{
+		"options": {Type: schema.TypeList, Elem: &schema.Schema{Type: schema.TypeString}, Required: true},
+		"initial": {Type: schema.TypeString},
+	})
+}
+
+func resourceInputText() *schema.Resource {
+	return resourceHelper("input_text", map[string]*schema.Schema{
+		"min":     {Type: schema.TypeInt, Description: "the minimum length"},
+		"max":     {Type: schema.TypeInt, Description: "the maximum length"},
+		"initial": {Type: schema.TypeString},
+		"mode":    {Type: schema.TypeString, Description: "`text` or `password`"},
+		"pattern": {Type: schema.TypeString, Description: "a regular expression the value must match"},
+	})
+}
+
+func resourceInputDatetime() *schema.Resource {
+	return resourceHelper("input_datetime", map[string]*schema.Schema{
+		"has_date": {Type: schema.TypeBool},
+		"has_time": {Type: schema.TypeBool},
+		"initial":  {Type: schema.TypeString},
+	})
+}
+
+func resourceCounter() *schema.Resource {
+	return resourceHelper("counter", map[string]*schema.Schema{
+		"initial": {Type: schema.TypeInt},
+		"minimum": {Type: schema.TypeInt},
+		"maximum": {Type: schema.TypeInt},
+		"step":    {Type: schema.TypeInt},
+		"restore": {Type: schema.TypeBool, Description: "restore the count after homeassistant starts"},
+	})
+}
+
+func resourceTimer() *schema.Resource {
+	return resourceHelper("timer", map[string]*schema.Schema{
+		"duration": {
+			Type:             schema.TypeString,
+			DiffSuppressFunc: suppressEquivalentDuration,
+			Description:      "e.g. `00:05:00`",
+		},
+		"restore": {Type: schema.TypeBool, Description: "restore an active timer after homeassistant starts"},
+	})
+}
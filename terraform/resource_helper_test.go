@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+)
+
+// fakeHelpers adds handlers for helpers in the given domain, which fill in defaults the way homeassistant does.
+func fakeHelpers(ha *fakeHomeAssistant, domain string, defaults map[string]interface{}) map[string]map[string]interface{} {
+	helpers := fakeRegistry(ha, domain+"/", domain+"_id", "id")
+	create := ha.websocket[domain+"/create"]
+	ha.websocket[domain+"/create"] = func(request map[string]interface{}) (interface{}, error) {
+		for k, v := range defaults {
+			if _, ok := request[k]; !ok {
+				request[k] = v
+			}
+		}
+		if duration, ok := request["duration"].(string); ok && duration == "00:01:30" {
+			request["duration"] = "0:01:30"
+		}
+		return create(request)
+	}
+	return helpers
+}
+
+func TestResourceInputNumber(t *testing.T) {
+	ha := newFakeHomeAssistant(t)
+	helpers := fakeHelpers(ha, "input_number", map[string]interface{}{"step": 1, "mode": "slider"})
+
+	config := func(max int) string {
+		return ha.Config(fmt.Sprintf(`
+resource "homeassistant_input_number" "target" {
+  name = "Target Temperature"
+  min  = 0
+  max  = %d
+  unit_of_measurement = "°C"
+}`, max))
+	}
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers: ha.Providers(),
+		Steps: []resource.TestStep{
+			{
+				Config: config(30),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("homeassistant_input_number.target", "id", "target_temperature"),
+					resource.TestCheckResourceAttr("homeassistant_input_number.target", "entity_id",
+						"input_number.target_temperature"),
+					resource.TestCheckResourceAttr("homeassistant_input_number.target", "mode", "slider"),
+					checkRegistry(ha, helpers, "target_temperature", func(helper map[string]interface{}) error {
+						if helper["min"] != float64(0) || helper["max"] != float64(30) {
+							return fmt.Errorf("helper is %v", helper)
+						}
+						return nil
+					}),
+				),
+			},
+			{
+				Config: config(35),
+				Check: checkRegistry(ha, helpers, "target_temperature", func(helper map[string]interface{}) error {
+					if helper["max"] != float64(35) || helper["step"] != float64(1) {
+						return fmt.Errorf("helper is %v", helper)
+					}
+					return nil
+				}),
+			},
+			{
+				Config:            config(35),
+				ResourceName:      "homeassistant_input_number.target",
+				ImportState:       true,
+				ImportStateId:     "target_temperature",
+				ImportStateVerify: true,
+			},
+		},
+		CheckDestroy: checkRegistryEmpty(ha, helpers),
+	})
+}
+
+func TestResourceTimer(t *testing.T) {
+	ha := newFakeHomeAssistant(t)
+	helpers := fakeHelpers(ha, "timer", map[string]interface{}{"restore": false})
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers: ha.Providers(),
+		Steps: []resource.TestStep{
+			{
+				// homeassistant writes the duration back as 0:01:30, which is not a change
+				Config: ha.Config(`
+resource "homeassistant_timer" "tea" {
+  name     = "Tea"
+  duration = "00:01:30"
+}`),
+				Check: resource.TestCheckResourceAttr("homeassistant_timer.tea", "duration", "0:01:30"),
+			},
+		},
+		CheckDestroy: checkRegistryEmpty(ha, helpers),
+	})
+}
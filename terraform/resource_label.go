@@ -0,0 +1,94 @@
+package main
+
+import (
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func labelSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"label_id": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"name": {
+			Type:     schema.TypeString,
+			Required: true,
+		},
+		"color": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "a color name from the homeassistant theme, e.g. `indigo`, or an `#rrggbb` value",
+		},
+		"icon": {
+			Type:     schema.TypeString,
+			Optional: true,
+		},
+		"description": {
+			Type:     schema.TypeString,
+			Optional: true,
+		},
+	}
+}
+
+func resourceLabel() *schema.Resource {
+	return &schema.Resource{
+		Schema: labelSchema(),
+		Create: func(data *schema.ResourceData, i interface{}) error {
+			label, err := i.(*api.Client).CreateLabel(labelFromResource(data))
+			if err != nil {
+				return err
+			}
+			data.SetId(label.LabelId)
+			return setLabel(data, label)
+		},
+		Read: func(data *schema.ResourceData, i interface{}) error {
+			label, err := i.(*api.Client).GetLabel(data.Id())
+			if api.IsNotFound(err) {
+				data.SetId("")
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			return setLabel(data, label)
+		},
+		Update: func(data *schema.ResourceData, i interface{}) error {
+			label, err := i.(*api.Client).UpdateLabel(labelFromResource(data))
+			if err != nil {
+				return err
+			}
+			return setLabel(data, label)
+		},
+		Delete: func(data *schema.ResourceData, i interface{}) error {
+			err := i.(*api.Client).DeleteLabel(data.Id())
+			if api.IsNotFound(err) {
+				return nil
+			}
+			return err
+		},
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+	}
+}
+
+func labelFromResource(data *schema.ResourceData) api.Label {
+	return api.Label{
+		LabelId:     data.Id(),
+		Name:        data.Get("name").(string),
+		Color:       data.Get("color").(string),
+		Icon:        data.Get("icon").(string),
+		Description: data.Get("description").(string),
+	}
+}
+
+func setLabel(data *schema.ResourceData, label *api.Label) error {
+	return setAll(data, map[string]interface{}{
+		"label_id":    label.LabelId,
+		"name":        label.Name,
+		"color":       label.Color,
+		"icon":        label.Icon,
+		"description": label.Description,
+	})
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+)
+
+// fakeRegistry adds handlers for a registry (or collection) whose messages are prefix + `list`, `create`, `update`, and
+// `delete`, and returns its entries by ID. Requests name entries by idKey. New entries get IDs derived from their
+// names, as homeassistant's registries do, which they store as entryIdKey.
+func fakeRegistry(ha *fakeHomeAssistant, prefix, idKey, entryIdKey string) map[string]map[string]interface{} {
+	entries := map[string]map[string]interface{}{}
+	entry := func(request map[string]interface{}) (map[string]interface{}, error) {
+		id, _ := request[idKey].(string)
+		ret, ok := entries[id]
+		if !ok {
+			return nil, api.ResultError{Code: "not_found", Message: id + " not found"}
+		}
+		return ret, nil
+	}
+
+	ha.websocket[prefix+"list"] = func(map[string]interface{}) (interface{}, error) {
+		var ids []string
+		for id := range entries {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		ret := []interface{}{}
+		for _, id := range ids {
+			ret = append(ret, entries[id])
+		}
+		return ret, nil
+	}
+	ha.websocket[prefix+"create"] = func(request map[string]interface{}) (interface{}, error) {
+		id := strings.ReplaceAll(strings.ToLower(request["name"].(string)), " ", "_")
+		delete(request, "id")
+		delete(request, "type")
+		request[entryIdKey] = id
+		entries[id] = request
+		return request, nil
+	}
+	ha.websocket[prefix+"update"] = func(request map[string]interface{}) (interface{}, error) {
+		ret, err := entry(request)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range request {
+			if k != "id" && k != "type" && k != idKey {
+				ret[k] = v
+			}
+		}
+		return ret, nil
+	}
+	ha.websocket[prefix+"delete"] = func(request map[string]interface{}) (interface{}, error) {
+		if _, err := entry(request); err != nil {
+			return nil, err
+		}
+		delete(entries, request[idKey].(string))
+		return nil, nil
+	}
+
+	return entries
+}
+
+func checkRegistry(ha *fakeHomeAssistant, entries map[string]map[string]interface{}, id string,
+	check func(map[string]interface{}) error) resource.TestCheckFunc {
+	return func(*terraform.State) error {
+		ha.Lock()
+		defer ha.Unlock()
+		entry, ok := entries[id]
+		if !ok {
+			return fmt.Errorf("no entry %q", id)
+		}
+		return check(entry)
+	}
+}
+
+func checkRegistryEmpty(ha *fakeHomeAssistant, entries ...map[string]map[string]interface{}) resource.TestCheckFunc {
+	return func(*terraform.State) error {
+		ha.Lock()
+		defer ha.Unlock()
+		for _, registry := range entries {
+			for id := range registry {
+				return fmt.Errorf("%q was not deleted", id)
+			}
+		}
+		return nil
+	}
+}
+
+func TestResourceArea(t *testing.T) {
+	ha := newFakeHomeAssistant(t)
+	areas := fakeRegistry(ha, "config/area_registry/", "area_id", "area_id")
+	floors := fakeRegistry(ha, "config/floor_registry/", "floor_id", "floor_id")
+	labels := fakeRegistry(ha, "config/label_registry/", "label_id", "label_id")
+
+	config := func(areaName string) string {
+		return ha.Config(`
+resource "homeassistant_floor" "ground" {
+  name  = "Ground Floor"
+  level = 0
+}
+
+resource "homeassistant_label" "cozy" {
+  name  = "Cozy"
+  color = "indigo"
+}
+
+resource "homeassistant_area" "living" {
+  name     = "` + areaName + `"
+  aliases  = ["lounge"]
+  floor_id = homeassistant_floor.ground.floor_id
+  labels   = [homeassistant_label.cozy.label_id]
+}`)
+	}
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers: ha.Providers(),
+		Steps: []resource.TestStep{
+			{
+				Config: config("Living Room"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("homeassistant_area.living", "id", "living_room"),
+					resource.TestCheckResourceAttr("homeassistant_floor.ground", "level", "0"),
+					checkRegistry(ha, areas, "living_room", func(area map[string]interface{}) error {
+						if area["floor_id"] != "ground_floor" || fmt.Sprint(area["labels"]) != "[cozy]" {
+							return fmt.Errorf("area is %v", area)
+						}
+						return nil
+					}),
+					checkRegistry(ha, floors, "ground_floor", func(floor map[string]interface{}) error {
+						if floor["level"] != float64(0) {
+							return fmt.Errorf("level is %v", floor["level"])
+						}
+						return nil
+					}),
+				),
+			},
+			{
+				// renaming keeps the ID
+				Config: config("Family Room"),
+				Check: checkRegistry(ha, areas, "living_room", func(area map[string]interface{}) error {
+					if area["name"] != "Family Room" {
+						return fmt.Errorf("name is %v", area["name"])
+					}
+					return nil
+				}),
+			},
+			{
+				Config:            config("Family Room"),
+				ResourceName:      "homeassistant_area.living",
+				ImportState:       true,
+				ImportStateId:     "living_room",
+				ImportStateVerify: true,
+			},
+			{
+				PreConfig: func() {
+					ha.Lock()
+					defer ha.Unlock()
+					delete(areas, "living_room")
+				},
+				Config:             config("Family Room"),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+		},
+		CheckDestroy: checkRegistryEmpty(ha, areas, floors, labels),
+	})
+}
+
+func TestResourceZone(t *testing.T) {
+	ha := newFakeHomeAssistant(t)
+	zones := fakeRegistry(ha, "zone/", "zone_id", "id")
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers: ha.Providers(),
+		Steps: []resource.TestStep{
+			{
+				Config: ha.Config(`
+resource "homeassistant_zone" "work" {
+  name      = "Work"
+  latitude  = 52.3731
+  longitude = 4.8922
+  passive   = true
+}`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("homeassistant_zone.work", "radius", "100"),
+					checkRegistry(ha, zones, "work", func(zone map[string]interface{}) error {
+						if zone["latitude"] != 52.3731 || zone["radius"] != float64(100) || zone["passive"] != true {
+							return fmt.Errorf("zone is %v", zone)
+						}
+						return nil
+					}),
+				),
+			},
+		},
+		CheckDestroy: checkRegistryEmpty(ha, zones),
+	})
+}
+
+func TestDataLabel(t *testing.T) {
+	ha := newFakeHomeAssistant(t)
+	labels := fakeRegistry(ha, "config/label_registry/", "label_id", "label_id")
+	labels["cozy"] = map[string]interface{}{"label_id": "cozy", "name": "Cozy", "color": "indigo"}
+	labels["outdoor"] = map[string]interface{}{"label_id": "outdoor", "name": "Outdoor"}
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers: ha.Providers(),
+		Steps: []resource.TestStep{
+			{
+				Config: ha.Config(`
+data "homeassistant_label" "cozy" {
+  name = "Cozy"
+}`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.homeassistant_label.cozy", "label_id", "cozy"),
+					resource.TestCheckResourceAttr("data.homeassistant_label.cozy", "color", "indigo"),
+				),
+			},
+			{
+				Config: ha.Config(`
+data "homeassistant_label" "cozy" {
+  name = "Cosy"
+}`),
+				ExpectError: regexp.MustCompile(`no label named "Cosy"; there are: cozy \(Cozy\), outdoor \(Outdoor\)`),
+			},
+		},
+	})
+}
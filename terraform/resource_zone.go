@@ -0,0 +1,108 @@
+package main
+
+import (
+	"github.com/asymmetricia/ghastly/api"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func zoneSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"zone_id": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"name": {
+			Type:     schema.TypeString,
+			Required: true,
+		},
+		"latitude": {
+			Type:     schema.TypeFloat,
+			Required: true,
+		},
+		"longitude": {
+			Type:     schema.TypeFloat,
+			Required: true,
+		},
+		"radius": {
+			Type:        schema.TypeFloat,
+			Optional:    true,
+			Default:     100,
+			Description: "in meters",
+		},
+		"icon": {
+			Type:     schema.TypeString,
+			Optional: true,
+		},
+		"passive": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Description: "only use the zone for automations; entities are not shown as being in it",
+		},
+	}
+}
+
+func resourceZone() *schema.Resource {
+	return &schema.Resource{
+		Schema: zoneSchema(),
+		Create: func(data *schema.ResourceData, i interface{}) error {
+			zone, err := i.(*api.Client).CreateZone(zoneFromResource(data))
+			if err != nil {
+				return err
+			}
+			data.SetId(zone.Id)
+			return setZone(data, zone)
+		},
+		Read: func(data *schema.ResourceData, i interface{}) error {
+			zone, err := i.(*api.Client).GetZone(data.Id())
+			if api.IsNotFound(err) {
+				data.SetId("")
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			return setZone(data, zone)
+		},
+		Update: func(data *schema.ResourceData, i interface{}) error {
+			zone, err := i.(*api.Client).UpdateZone(zoneFromResource(data))
+			if err != nil {
+				return err
+			}
+			return setZone(data, zone)
+		},
+		Delete: func(data *schema.ResourceData, i interface{}) error {
+			err := i.(*api.Client).DeleteZone(data.Id())
+			if api.IsNotFound(err) {
+				return nil
+			}
+			return err
+		},
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+	}
+}
+
+func zoneFromResource(data *schema.ResourceData) api.Zone {
+	return api.Zone{
+		Id:        data.Id(),
+		Name:      data.Get("name").(string),
+		Latitude:  data.Get("latitude").(float64),
+		Longitude: data.Get("longitude").(float64),
+		Radius:    data.Get("radius").(float64),
+		Icon:      data.Get("icon").(string),
+		Passive:   data.Get("passive").(bool),
+	}
+}
+
+func setZone(data *schema.ResourceData, zone *api.Zone) error {
+	return setAll(data, map[string]interface{}{
+		"zone_id":   zone.Id,
+		"name":      zone.Name,
+		"latitude":  zone.Latitude,
+		"longitude": zone.Longitude,
+		"radius":    zone.Radius,
+		"icon":      zone.Icon,
+		"passive":   zone.Passive,
+	})
+}